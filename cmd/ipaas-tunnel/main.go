@@ -0,0 +1,135 @@
+// ipaas-tunnel listens on a local address and forwards every connection to the
+// listening port of a storage application through the tunnel endpoint, so that
+// local clients can connect to the database without exposing it publicly:
+//
+//	IPAAS_ACCESS_TOKEN=... ipaas-tunnel -app <application id> -listen 127.0.0.1:5432
+//	psql -h 127.0.0.1 -p 5432 -U <user> <database>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+func main() {
+	apiURL := flag.String("api", envOrDefault("IPAAS_API_URL", "http://localhost:8082"), "base url of the ipaas backend")
+	token := flag.String("token", os.Getenv("IPAAS_ACCESS_TOKEN"), "access token, defaults to $IPAAS_ACCESS_TOKEN")
+	applicationID := flag.String("app", "", "id of the storage application")
+	listen := flag.String("listen", "127.0.0.1:5432", "local address to listen on")
+	flag.Parse()
+
+	if *applicationID == "" || *token == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	tunnelURL, err := buildTunnelURL(*apiURL, *applicationID)
+	if err != nil {
+		log.Fatalf("invalid api url: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("error listening on %s: %v", *listen, err)
+	}
+	defer listener.Close()
+	log.Printf("forwarding %s to application %s", listener.Addr(), *applicationID)
+
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			log.Fatalf("error accepting connection: %v", err)
+		}
+		go forward(local, tunnelURL, *token)
+	}
+}
+
+func envOrDefault(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+func buildTunnelURL(apiURL, applicationID string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1/application/" + applicationID + "/tunnel"
+	return u.String(), nil
+}
+
+// each local connection is forwarded in its own websocket
+func forward(local net.Conn, tunnelURL, token string) {
+	defer local.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	remote, resp, err := websocket.DefaultDialer.Dial(tunnelURL, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			log.Printf("error opening tunnel: %s: %s", resp.Status, body)
+			return
+		}
+		log.Printf("error opening tunnel: %v", err)
+		return
+	}
+	defer remote.Close()
+	log.Printf("connection from %s opened", local.RemoteAddr())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			messageType, data, err := remote.ReadMessage()
+			if err != nil {
+				local.Close()
+				return
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			if _, err := local.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := local.Read(buf)
+		if n > 0 {
+			if err := remote.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	remote.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	wg.Wait()
+	log.Printf("connection from %s closed", local.RemoteAddr())
+}
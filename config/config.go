@@ -12,16 +12,30 @@ import (
 
 type (
 	Config struct {
-		App         `yaml:"app"`
-		Log         `yaml:"logger"`
-		JWT         `yaml:"jwt"`
-		GitProvider `yaml:"gitProvider"`
-		RMQ         `yaml:"rabbitmq"`
-		HTTP        `yaml:"http"`
-		Database    `yaml:"database"`
-		Traefik     `yaml:"traefik"`
-		K8s         `yaml:"k8s"`
-		LogProvider `yaml:"logProvider"`
+		App             `yaml:"app"`
+		Log             `yaml:"logger"`
+		JWT             `yaml:"jwt"`
+		GitProvider     `yaml:"gitProvider"`
+		RMQ             `yaml:"rabbitmq"`
+		HTTP            `yaml:"http"`
+		Database        `yaml:"database"`
+		Traefik         `yaml:"traefik"`
+		K8s             `yaml:"k8s"`
+		LogProvider     `yaml:"logProvider"`
+		MetricsProvider `yaml:"metricsProvider"`
+		Quota           `yaml:"quota"`
+		Rollout         `yaml:"rollout"`
+		Secrets         `yaml:"secrets"`
+		Volumes         `yaml:"volumes"`
+		Backups         `yaml:"backups"`
+		Jobs            `yaml:"jobs"`
+		Exec            `yaml:"exec"`
+		Tunnel          `yaml:"tunnel"`
+		Notifications   `yaml:"notifications"`
+		CrashLoop       `yaml:"crashLoop"`
+		Reconciler      `yaml:"reconciler"`
+		ClusterWatch    `yaml:"clusterWatch"`
+		LeaderElection  `yaml:"leaderElection"`
 	}
 
 	App struct {
@@ -84,11 +98,151 @@ type (
 		RegistryPassword string `env-required:"true" yaml:"registryPassword" env:"K8S_REGISTRY_PASSWORD"`
 	}
 
+	Quota struct {
+		DefaultTier string               `env-required:"true" yaml:"defaultTier" env:"QUOTA_DEFAULT_TIER"`
+		Tiers       map[string]QuotaTier `yaml:"tiers"`
+	}
+
+	// QuotaTier describes the resources a user namespace is allowed to use,
+	// cpu, memory and storage are k8s quantities (ex: 500m, 2Gi)
+	QuotaTier struct {
+		CPU             string `yaml:"cpu"`
+		Memory          string `yaml:"memory"`
+		Storage         string `yaml:"storage"`
+		Applications    int64  `yaml:"applications"`
+		Pods            int64  `yaml:"pods"`
+		ContainerCPU    string `yaml:"containerCpu"`
+		ContainerMemory string `yaml:"containerMemory"`
+	}
+
+	// maxSurge and maxUnavailable accept both absolute numbers and percentages (ex: 1, 25%)
+	Rollout struct {
+		MaxSurge       string        `yaml:"maxSurge" env:"ROLLOUT_MAX_SURGE" env-default:"1"`
+		MaxUnavailable string        `yaml:"maxUnavailable" env:"ROLLOUT_MAX_UNAVAILABLE" env-default:"0"`
+		Deadline       time.Duration `yaml:"deadline" env:"ROLLOUT_DEADLINE" env-default:"5m"`
+	}
+
+	// the encryption key is used to encrypt the application secrets stored in the database,
+	// changing it makes the already stored secrets unreadable
+	Secrets struct {
+		EncryptionKey string `env-required:"true" env:"SECRETS_ENCRYPTION_KEY"`
+	}
+
+	// sizes are in Gi, the storage templates get a volume of TemplateSize
+	Volumes struct {
+		DefaultStorageClass string `yaml:"defaultStorageClass" env:"VOLUMES_DEFAULT_STORAGE_CLASS" env-default:"longhorn"`
+		TemplateSize        int64  `yaml:"templateSize" env:"VOLUMES_TEMPLATE_SIZE" env-default:"1"`
+		MaxSize             int64  `yaml:"maxSize" env:"VOLUMES_MAX_SIZE" env-default:"10"`
+		MaxPerApplication   int    `yaml:"maxPerApplication" env:"VOLUMES_MAX_PER_APPLICATION" env-default:"5"`
+	}
+
+	// backups are uploaded to an s3 compatible bucket by the jobs, they are
+	// disabled if the endpoint is not set
+	Backups struct {
+		S3Endpoint    string `yaml:"s3Endpoint" env:"BACKUPS_S3_ENDPOINT"`
+		S3Bucket      string `yaml:"s3Bucket" env:"BACKUPS_S3_BUCKET"`
+		S3AccessKey   string `env:"BACKUPS_S3_ACCESS_KEY"`
+		S3SecretKey   string `env:"BACKUPS_S3_SECRET_KEY"`
+		UploaderImage string `yaml:"uploaderImage" env:"BACKUPS_UPLOADER_IMAGE" env-default:"minio/mc:latest"`
+		JobsHistory   int32  `yaml:"jobsHistory" env:"BACKUPS_JOBS_HISTORY" env-default:"3"`
+	}
+
+	// scheduled jobs and tasks of the applications, the logs of a run are stored
+	// when it finishes, keeping only the last LogLines lines
+	Jobs struct {
+		MaxPerApplication int   `yaml:"maxPerApplication" env:"JOBS_MAX_PER_APPLICATION" env-default:"5"`
+		History           int32 `yaml:"history" env:"JOBS_HISTORY" env-default:"3"`
+		LogLines          int64 `yaml:"logLines" env:"JOBS_LOG_LINES" env-default:"500"`
+		//seconds after which a finished task job is deleted from the cluster
+		TaskTTL int32 `yaml:"taskTTL" env:"JOBS_TASK_TTL" env-default:"3600"`
+	}
+
+	// interactive shells opened in the application containers, the sessions
+	// are closed after MaxDuration
+	Exec struct {
+		Enabled     bool          `yaml:"enabled" env:"EXEC_ENABLED" env-default:"true"`
+		MaxDuration time.Duration `yaml:"maxDuration" env:"EXEC_MAX_DURATION" env-default:"30m"`
+	}
+
+	// tunnels to the private port of the storage applications, used to connect
+	// local clients to the databases
+	Tunnel struct {
+		Enabled     bool          `yaml:"enabled" env:"TUNNEL_ENABLED" env-default:"true"`
+		MaxDuration time.Duration `yaml:"maxDuration" env:"TUNNEL_MAX_DURATION" env-default:"8h"`
+	}
+
+	// crash and failure notifications sent to the channels of the applications,
+	// a channel gets at most one notification per event every RateLimit.
+	// Email channels are available only when the smtp host is set
+	Notifications struct {
+		Enabled                   bool          `yaml:"enabled" env:"NOTIFICATIONS_ENABLED" env-default:"true"`
+		RateLimit                 time.Duration `yaml:"rateLimit" env:"NOTIFICATIONS_RATE_LIMIT" env-default:"15m"`
+		Timeout                   time.Duration `yaml:"timeout" env:"NOTIFICATIONS_TIMEOUT" env-default:"10s"`
+		MaxChannelsPerApplication int           `yaml:"maxChannelsPerApplication" env:"NOTIFICATIONS_MAX_CHANNELS_PER_APPLICATION" env-default:"5"`
+		SMTPHost                  string        `yaml:"smtpHost" env:"NOTIFICATIONS_SMTP_HOST"`
+		SMTPPort                  int           `yaml:"smtpPort" env:"NOTIFICATIONS_SMTP_PORT" env-default:"587"`
+		SMTPUsername              string        `yaml:"smtpUsername" env:"NOTIFICATIONS_SMTP_USERNAME"`
+		SMTPPassword              string        `env:"NOTIFICATIONS_SMTP_PASSWORD"`
+		SMTPFrom                  string        `yaml:"smtpFrom" env:"NOTIFICATIONS_SMTP_FROM"`
+	}
+
+	// policy applied to the applications in CrashLoopBackOff, the exit code and the
+	// last LogLines lines of the crashed container are kept on the application.
+	// After RestartThreshold restarts the deployment is scaled to 0 until the
+	// user resumes it, 0 never stops the applications
+	CrashLoop struct {
+		RestartThreshold int32 `yaml:"restartThreshold" env:"CRASH_LOOP_RESTART_THRESHOLD" env-default:"5"`
+		LogLines         int64 `yaml:"logLines" env:"CRASH_LOOP_LOG_LINES" env-default:"50"`
+	}
+
+	// periodic comparison of the applications in the database with their resources
+	// in the cluster. Applications changed in the last StaleAfter and younger
+	// resources are skipped as they may still be handled by a running operation.
+	// With DryRun the divergences are only logged
+	Reconciler struct {
+		Enabled    bool          `yaml:"enabled" env:"RECONCILER_ENABLED" env-default:"true"`
+		Interval   time.Duration `yaml:"interval" env:"RECONCILER_INTERVAL" env-default:"10m"`
+		StaleAfter time.Duration `yaml:"staleAfter" env:"RECONCILER_STALE_AFTER" env-default:"15m"`
+		DryRun     bool          `yaml:"dryRun" env:"RECONCILER_DRY_RUN" env-default:"false"`
+	}
+
+	// informers of the pods, deployments and replica sets of the applications, the
+	// changes are queued by application and handled by Workers goroutines, the
+	// events of the same application are never handled concurrently
+	ClusterWatch struct {
+		Resync  time.Duration `yaml:"resync" env:"CLUSTER_WATCH_RESYNC" env-default:"10m"`
+		Workers int           `yaml:"workers" env:"CLUSTER_WATCH_WORKERS" env-default:"4"`
+	}
+
+	// election of the replica running the container event handler and the reconciler
+	// through a kubernetes lease, every replica serves http and consumes the build
+	// responses. When disabled the process runs everything on its own.
+	// The identity defaults to the hostname, which is the pod name in the cluster
+	LeaderElection struct {
+		Enabled       bool          `yaml:"enabled" env:"LEADER_ELECTION_ENABLED" env-default:"false"`
+		Namespace     string        `yaml:"namespace" env:"LEADER_ELECTION_NAMESPACE" env-default:"ipaas"`
+		LeaseName     string        `yaml:"leaseName" env:"LEADER_ELECTION_LEASE_NAME" env-default:"ipaas-backend"`
+		Identity      string        `yaml:"identity" env:"LEADER_ELECTION_IDENTITY"`
+		LeaseDuration time.Duration `yaml:"leaseDuration" env:"LEADER_ELECTION_LEASE_DURATION" env-default:"15s"`
+		RenewDeadline time.Duration `yaml:"renewDeadline" env:"LEADER_ELECTION_RENEW_DEADLINE" env-default:"10s"`
+		RetryPeriod   time.Duration `yaml:"retryPeriod" env:"LEADER_ELECTION_RETRY_PERIOD" env-default:"2s"`
+	}
+
 	LogProvider struct {
 		Provider string `env-required:"true" yaml:"provider" env:"LOG_PROVIDER"`
 		BaseUrl  string `env-required:"true" yaml:"baseUrl" env:"LOG_PROVIDER_BASE_URL"`
 		Token    string `env-required:"true" env:"LOG_PROVIDER_TOKEN"`
 	}
+
+	// source of the application stats, the kubernetes provider needs
+	// metrics-server and only returns the current usage
+	MetricsProvider struct {
+		Provider string `yaml:"provider" env:"METRICS_PROVIDER" env-default:"mock"`
+		BaseUrl  string `yaml:"baseUrl" env:"METRICS_PROVIDER_BASE_URL"`
+		Token    string `env:"METRICS_PROVIDER_TOKEN"`
+		//max number of points of a series
+		MaxPoints int `yaml:"maxPoints" env:"METRICS_PROVIDER_MAX_POINTS" env-default:"1000"`
+	}
 )
 
 func NewConfig(configPath ...string) (*Config, error) {
@@ -107,7 +261,7 @@ func NewConfig(configPath ...string) (*Config, error) {
 		}
 	}
 
-	mustCheck := []string{"JWT_SECRET", "GIT_PROVIDER_CLIENT_ID", "GIT_PROVIDER_CLIENT_SECRET"}
+	mustCheck := []string{"JWT_SECRET", "GIT_PROVIDER_CLIENT_ID", "GIT_PROVIDER_CLIENT_SECRET", "SECRETS_ENCRYPTION_KEY"}
 
 	for _, v := range mustCheck {
 		logrus.Debug(os.Getenv(v))
@@ -130,5 +284,9 @@ func NewConfig(configPath ...string) (*Config, error) {
 		}
 	}
 
+	if _, ok := cfg.Quota.Tiers[cfg.Quota.DefaultTier]; !ok {
+		return nil, fmt.Errorf("default quota tier %q is not defined in the quota tiers", cfg.Quota.DefaultTier)
+	}
+
 	return cfg, nil
 }
@@ -52,30 +52,105 @@ func (c *Controller) InsertApplication(ctx context.Context, app *model.Applicati
 	return nil
 }
 
+// saves the application only if it wasn't changed since it was read,
+// returns ErrApplicationChanged otherwise
 func (c *Controller) updateApplication(ctx context.Context, app *model.Application) error {
 	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
+		if err == repo.ErrVersionConflict {
+			c.l.Warnf("application %s changed since it was read, not updating", app.ID.Hex())
+			return ErrApplicationChanged
+		}
 		c.l.Errorf("error updating application: %v", err)
 		return err
 	}
 	return nil
 }
 
+// applies modify to the application and saves it, if the application was changed
+// in the meantime it's read again and modify is applied to the latest version.
+// It's used by the long operations whose result must not be lost, modify can
+// abort the update by returning an error
+func (c *Controller) modifyApplication(ctx context.Context, app *model.Application, modify func(app *model.Application) error) error {
+	for attempt := 0; ; attempt++ {
+		if err := modify(app); err != nil {
+			return err
+		}
+		_, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID)
+		if err == nil {
+			return nil
+		}
+		if err != repo.ErrVersionConflict {
+			c.l.Errorf("error updating application: %v", err)
+			return err
+		}
+		if attempt == maxModifyRetries {
+			c.l.Errorf("application %s changed %d times while updating it, giving up", app.ID.Hex(), attempt+1)
+			return ErrApplicationChanged
+		}
+		c.l.Debugf("application %s changed while updating it, retrying on the latest version", app.ID.Hex())
+		current, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			return err
+		}
+		*app = *current
+	}
+}
+
 // this function will insert a new application and send the build request to image builder
-func (c *Controller) CreateNewWebApplication(ctx context.Context, userCode, providerAccessToken, name, gitRepo, gitBranch, listeningPort string, envs []model.KeyValue, rootDirectory string) (*model.Application, error) {
+func (c *Controller) CreateNewWebApplication(ctx context.Context, userCode, providerAccessToken, name, gitRepo, gitBranch, listeningPort string, envs, secrets []model.KeyValue, rootDirectory string, healthChecks *model.HealthChecks) (*model.Application, error) {
+	if healthChecks == nil {
+		healthChecks = defaultHealthChecks(listeningPort)
+	}
+	if err := validateHealthChecks(healthChecks); err != nil {
+		return nil, err
+	}
+	return c.createRepoApplication(ctx, model.ApplicationKindWeb, userCode, providerAccessToken, name, gitRepo, gitBranch, listeningPort, envs, secrets, rootDirectory, healthChecks)
+}
+
+// workers are built like web applications but they have no port, service and
+// ingress route (ex: queue consumers, bots). By default they have no probes
+func (c *Controller) CreateNewWorkerApplication(ctx context.Context, userCode, providerAccessToken, name, gitRepo, gitBranch string, envs, secrets []model.KeyValue, rootDirectory string, healthChecks *model.HealthChecks) (*model.Application, error) {
+	if healthChecks == nil {
+		healthChecks = defaultHealthChecks("")
+	}
+	if err := validateWorkerHealthChecks(healthChecks); err != nil {
+		return nil, err
+	}
+	return c.createRepoApplication(ctx, model.ApplicationKindWorker, userCode, providerAccessToken, name, gitRepo, gitBranch, "", envs, secrets, rootDirectory, healthChecks)
+}
+
+func (c *Controller) createRepoApplication(ctx context.Context, kind model.ApplicationKind, userCode, providerAccessToken, name, gitRepo, gitBranch, listeningPort string, envs, secrets []model.KeyValue, rootDirectory string, healthChecks *model.HealthChecks) (*model.Application, error) {
+	user, err := c.UserRepo.FindByCode(ctx, userCode)
+	if err != nil {
+		c.l.Errorf("error finding user by code: %v", err)
+		return nil, err
+	}
+	if err := c.checkApplicationQuota(ctx, user); err != nil {
+		return nil, err
+	}
+
 	app := new(model.Application)
 	app.Name = name
-	app.Kind = model.ApplicationKindWeb
-	app.State = model.ApplicationStatePending
+	app.Kind = kind
 	app.CreatedAt = time.Now()
 	app.Owner = userCode
 	//todo: allow user to set visibility
-	//! for now all applications are public
+	//! for now all web applications are public
 	app.Visiblity = model.ApplicationVisiblityPublic
+	if kind == model.ApplicationKindWorker {
+		app.Visiblity = model.ApplicationVisiblityPrivate
+	}
 	app.IsUpdatable = false
 	app.ListeningPort = listeningPort
 	app.GithubBranch = gitBranch
 	app.GithubRepo = gitRepo
 	app.Envs = envs
+	app.Secrets, err = c.encryptSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+	app.HealthChecks = healthChecks
+	app.Health = model.ApplicationHealthUnknown
 	// app.BuildConfig = buildConfig
 	if rootDirectory == "" {
 		rootDirectory = "/"
@@ -84,7 +159,7 @@ func (c *Controller) CreateNewWebApplication(ctx context.Context, userCode, prov
 		RootDirectory: rootDirectory,
 	}
 
-	if err := c.InsertApplication(ctx, app); err != nil {
+	if err := c.insertNewApplication(ctx, app, model.ApplicationStatePending, "application created", userCode); err != nil {
 		c.l.Errorf("error inserting application: %v", err)
 		return nil, err
 	}
@@ -122,48 +197,95 @@ func (c *Controller) CreateApplicationFromApplicationIDandImageID(ctx context.Co
 		return err
 	}
 
-	c.l.Infof("create application deployment for %s[%s]", app.Name, app.ID.Hex())
-	//update status of application to starting and set the built commit to builtCommit
-	app.State = model.ApplicationStateStarting
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-		c.l.Errorf("error updating application: %v", err)
-		return err
+	//the application may have been deleted while building
+	next := model.ApplicationStateStarting
+	if app.Service != nil {
+		next = model.ApplicationStateRollingOut
+	}
+	if !app.State.CanTransitionTo(next) {
+		c.l.Warnf("application %s is %s, ignoring build of commit %s", app.ID.Hex(), app.State, build.BuiltCommit)
+		return ErrInvalidStateTransition
 	}
 
+	previousCommit := app.BuiltCommit
 	app.BuiltCommit = build.BuiltCommit
-	app.State = model.ApplicationStateFailed
 	app.BuildOutput = build.BuildOutput
 	app.BuildPlan = build.PlanUsed
 	app.RepoAnalisys = build.RepoAnalisys
 
 	if app.Service != nil {
-		c.l.Infof("updating deployment with new image")
+		c.l.Infof("rolling out application %s[%s] with new image", app.Name, app.ID.Hex())
+		previousImage := app.Service.Deployment.ImageRegistry
 
-		deployment, err := c.ServiceManager.UpdateDeployment(ctx, user.Namespace, app.Service.Deployment.Name, build.ImageName, app.Service.Deployment.Replicas, app.Service.Deployment.Port, app.Service.Deployment.Labels, "")
+		deployment, err := c.ServiceManager.UpdateDeployment(ctx, user.Namespace, app.Service.Deployment.Name, build.ImageName, app.Service.Deployment.Replicas, app.Service.Deployment.Port, app.Service.Deployment.Labels, nil, app.HealthChecks)
 		if err != nil {
 			c.l.Errorf("error updating deployment: %v", err)
-			return err
+			app.BuiltCommit = previousCommit
+			if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, fmt.Sprintf("unable to update the deployment with commit %s", build.BuiltCommit), model.StateTransitionActorImageBuilder); err != nil {
+				return err
+			}
+			return convertServiceManagerError(err)
 		}
-		deployment.ConfigMap = app.Service.Deployment.ConfigMap
-		deployment.Volume = app.Service.Deployment.Volume
-		app.Service.Deployment = deployment
-		if err := c.updateApplication(ctx, app); err != nil {
+		//the deployment is already updated, the rollout is saved even if the
+		//application was changed in the meantime (ex: by the container events)
+		if err := c.transitionApplicationLatest(ctx, app, model.ApplicationStateRollingOut, fmt.Sprintf("rolling out commit %s", build.BuiltCommit), model.StateTransitionActorImageBuilder, func(app *model.Application) {
+			app.BuiltCommit = build.BuiltCommit
+			app.BuildOutput = build.BuildOutput
+			app.BuildPlan = build.PlanUsed
+			app.RepoAnalisys = build.RepoAnalisys
+			deployment.ConfigMap = app.Service.Deployment.ConfigMap
+			deployment.Secret = app.Service.Deployment.Secret
+			deployment.Volume = app.Service.Deployment.Volume
+			deployment.CurrentPodName = app.Service.Deployment.CurrentPodName
+			app.Service.Deployment = deployment
+			app.LastRollout = &model.Rollout{
+				Image:          build.ImageName,
+				PreviousImage:  previousImage,
+				Commit:         build.BuiltCommit,
+				PreviousCommit: previousCommit,
+				Status:         model.RolloutStatusProgressing,
+				StartedAt:      time.Now(),
+			}
+		}); err != nil {
 			return err
 		}
+
+		//the old pods keep serving traffic until the new ones are ready,
+		//the rollout is watched in background to not block the build responses
+		go c.watchRollout(ctx, app.ID, user.Namespace)
 		return nil
 	}
 
+	c.l.Infof("create application deployment for %s[%s]", app.Name, app.ID.Hex())
+	//update status of application to starting, the state will be set to running or failed at the end
+	if err := c.TransitionApplication(ctx, app, model.ApplicationStateStarting, fmt.Sprintf("commit %s built, creating the deployment", build.BuiltCommit), model.StateTransitionActorImageBuilder); err != nil {
+		return err
+	}
+
 	configMap, err := c.createConfigMap(ctx, app, user, app.Envs)
 	if err != nil {
 		return err
 	}
 
-	//todo: implement volumes
-	deployment, err := c.createDeployment(ctx, app, user, build.ImageName, configMap.Name, nil)
+	secret, err := c.createApplicationSecret(ctx, app, user)
+	if err != nil {
+		return err
+	}
+	secretName := ""
+	if secret != nil {
+		secretName = secret.Name
+	}
+
+	if err := c.createApplicationVolumes(ctx, app, user); err != nil {
+		return err
+	}
+
+	deployment, err := c.createDeployment(ctx, app, user, build.ImageName, configMap.Name, secretName, app.Volumes, nil)
 	if err != nil {
 		return err
 	}
 	deployment.ConfigMap = configMap
+	deployment.Secret = secret
 
 	//watch for deployment ready state, it's non blocking so we just check at the end
 	done, errChan := c.ServiceManager.WaitDeploymentReadyState(ctx, user.Namespace, deployment.Name)
@@ -191,50 +313,86 @@ loop:
 		}
 	}
 
-	service, err := c.createService(ctx, app, user)
-	if err != nil {
-		return err
+	//workers are not reachable, only the deployment is kept in the service
+	service := &model.Service{}
+	host := ""
+	if app.Kind != model.ApplicationKindWorker {
+		service, err = c.createService(ctx, app, user)
+		if err != nil {
+			return err
+		}
+
+		host = fmt.Sprintf("%s.%s", app.Name, c.app.BaseDefaultDomain)
+		ingressRoute, err := c.createIngressRoute(ctx, app, user, host, service.Name, service.Port)
+		if err != nil {
+			return err
+		}
+		service.IngressRoute = ingressRoute
 	}
 	service.Deployment = deployment
 
-	host := fmt.Sprintf("%s.%s", app.Name, c.app.BaseDefaultDomain)
-	ingressRoute, err := c.createIngressRoute(ctx, app, user, host, service.Name, service.Port)
-	if err != nil {
-		return err
-	}
-	service.IngressRoute = ingressRoute
+	//todo: handle waiting error, it's probably because it reached a timeout
+	//in this case it probably means that we reached a cpu/mem cap and we should
+	//expand the infra, it should really not happen
+	//* actually im wrong, this error can happen, require study
+	//todo: check when this error may verify
+	return c.completeApplicationStartup(ctx, app, service, host, errWhileWaiting)
+}
 
+// saves the resources of a new application and moves it from starting to running,
+// or to failed if its deployment didn't become ready. The container events may have
+// already changed the state of the application, in that case it's kept
+func (c *Controller) completeApplicationStartup(ctx context.Context, app *model.Application, service *model.Service, dnsName string, errWhileWaiting error) error {
+	state := model.ApplicationStateRunning
+	reason := "the deployment is ready"
 	if errWhileWaiting != nil {
-		//todo: handle waiting error, it's probably because it reached a timeout
-		//in this case it probably means that we reached a cpu/mem cap and we should
-		//expand the infra, it should really not happen
-		//* actually im wrong, this error can happen, require study
-		//todo: check when this error may verify
 		c.l.Errorf("internal error reached, this should not happen, check infrastructure resources left")
-		app.State = model.ApplicationStateFailed
+		state = model.ApplicationStateFailed
+		reason = fmt.Sprintf("the deployment did not become ready: %v", errWhileWaiting)
 	} else {
-		app.State = model.ApplicationStateRunning
-		app.Service = service
-		app.DnsName = host
+		observeApplicationStartup(app)
 	}
 
-	//update the application with the container informations and status running
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-		c.l.Errorf("error updating application: %v", err)
-		return err
-	}
-
-	return nil
+	//the container events may have already moved the application out of starting
+	return c.transitionApplicationLatestFrom(ctx, app, model.ApplicationStateStarting, state, reason, model.StateTransitionActorSystem, func(app *model.Application) {
+		if errWhileWaiting == nil {
+			app.Service = service
+			app.DnsName = dnsName
+		}
+	})
 }
 
+// the applications of a stack can only be deleted with the stack
 func (c *Controller) DeleteApplication(ctx context.Context, app *model.Application, user *model.User) error {
+	if !app.StackID.IsZero() {
+		return ErrApplicationPartOfStack
+	}
+	return c.deleteApplication(ctx, app, user)
+}
+
+func (c *Controller) deleteApplication(ctx context.Context, app *model.Application, user *model.User) error {
 	//! this version is not able to delete a pending build cause it's unable to delete a message
 	// in the rmq queue, in the next version it will not be a problem cause we will also be able to stop the building process
-	if app.State == model.ApplicationStateBuilding ||
-		app.State == model.ApplicationStateStarting {
+	if !app.State.CanTransitionTo(model.ApplicationStateDeleting) {
 		return ErrInvalidOperationInCurrentState
 	}
 
+	if err := c.removeApplicationLinks(ctx, user, app); err != nil {
+		return err
+	}
+
+	if err := c.deleteTargetingApplications(ctx, user, app); err != nil {
+		return err
+	}
+
+	//the deletion wins over any concurrent update of the application
+	if err := c.forceTransitionApplication(ctx, app, model.ApplicationStateDeleting, "application deleted", user.Code); err != nil {
+		if err == ErrInvalidStateTransition {
+			return ErrInvalidOperationInCurrentState
+		}
+		return err
+	}
+
 	if app.Service == nil {
 		c.l.Warnf("user trying to delete application %s without service, this might cause an error with image builder", app.ID.Hex())
 		if _, err := c.ApplicationRepo.DeleteByID(ctx, app.ID); err != nil {
@@ -243,12 +401,6 @@ func (c *Controller) DeleteApplication(ctx context.Context, app *model.Applicati
 		}
 	}
 
-	app.State = model.ApplicationStateDeleting
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-		c.l.Errorf("error updating application: %v", err)
-		return err
-	}
-
 	if err := c.deleteService(ctx, app, user); err != nil {
 		return err
 	}
@@ -265,10 +417,30 @@ func (c *Controller) DeleteApplication(ctx context.Context, app *model.Applicati
 		return err
 	}
 
+	if err := c.deleteApplicationVolumes(ctx, app, user); err != nil {
+		return err
+	}
+
+	if err := c.deleteBackupCronJob(ctx, app, user); err != nil {
+		return err
+	}
+
+	if err := c.deleteApplicationJobs(ctx, app, user); err != nil {
+		return err
+	}
+
+	if err := c.deleteApplicationNotificationChannels(ctx, app); err != nil {
+		return err
+	}
+
 	if err := c.deleteConfigMap(ctx, app, user); err != nil {
 		return err
 	}
 
+	if err := c.deleteSecret(ctx, app, user); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -284,46 +456,71 @@ func (c *Controller) FailedBuild(ctx context.Context, info *model.BuildResponse)
 	}
 
 	app.BuiltCommit = info.BuiltCommit
-	app.State = model.ApplicationStateFailed
 	app.BuildOutput = info.BuildOutput
 	app.BuildPlan = info.PlanUsed
 	app.RepoAnalisys = info.RepoAnalisys
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-		c.l.Errorf("error updating application: %v", err)
+	if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, fmt.Sprintf("the build of commit %s failed", info.BuiltCommit), model.StateTransitionActorImageBuilder); err != nil {
 		return err
 	}
+	c.NotifyApplicationEvent(app, model.NotificationEventBuildFailed, fmt.Sprintf("the build of commit %s failed: %s", info.BuiltCommit, info.Message))
 	return nil
 }
 
+// restarts the deployment so the containers load the current envs, secrets and
+// volumes. Only the service of the application is saved, the callers that changed
+// other fields use saveAndRedeployApplication
 func (c *Controller) RedeployApplication(ctx context.Context, user *model.User, application *model.Application) error {
 	c.l.Infof("force restart of deployment %s (appID=%s) of user %s", application.Service.Deployment.Name, application.ID.Hex(), user.Code)
 	application.Service.Deployment.CurrentPodName = ""
+	if err := c.ApplicationRepo.UpdateService(ctx, application.ID, application.Service); err != nil {
+		c.l.Errorf("error updating service of application %s: %v", application.ID.Hex(), err)
+		return err
+	}
+	return c.restartApplicationDeployment(ctx, user, application)
+}
+
+// like RedeployApplication but the whole application is saved with the changes of
+// the caller, nothing is restarted if it was changed since it was read
+func (c *Controller) saveAndRedeployApplication(ctx context.Context, user *model.User, application *model.Application) error {
+	c.l.Infof("saving and restarting deployment %s (appID=%s) of user %s", application.Service.Deployment.Name, application.ID.Hex(), user.Code)
+	application.Service.Deployment.CurrentPodName = ""
 	if err := c.updateApplication(ctx, application); err != nil {
 		return err
 	}
+	return c.restartApplicationDeployment(ctx, user, application)
+}
+
+func (c *Controller) restartApplicationDeployment(ctx context.Context, user *model.User, application *model.Application) error {
 	if err := c.ServiceManager.RestartDeployment(ctx, user.Namespace, application.Service.Deployment.Name); err != nil {
 		c.l.Errorf("error restarting deployment %s: %v", application.Service.Deployment.Name, err)
 		return err
 	}
+	c.refreshScheduledJobs(ctx, application, user.Namespace)
 	return nil
 }
 
 func (c *Controller) AddCurrentPodToApplication(ctx context.Context, applicationID primitive.ObjectID, podName string) {
 	go func() {
 		for {
-			app, err := c.ApplicationRepo.FindByID(ctx, applicationID)
-			if err != nil {
-				c.l.Errorf("error finding application by id: %v", err)
-				return
-			}
-			if app.Service == nil || app.Service.Deployment == nil {
+			//fails until the deployment is saved in the application
+			err := c.ApplicationRepo.UpdateCurrentPod(ctx, applicationID, podName)
+			if err == repo.ErrNotFound {
+				exists, err := c.DoesApplicationExists(ctx, applicationID)
+				if err != nil {
+					c.l.Errorf("error finding application by id: %v", err)
+					return
+				}
+				if !exists {
+					c.l.Warnf("application %s was deleted before adding pod %s", applicationID.Hex(), podName)
+					return
+				}
 				c.l.Debugf("application still not fully created, waiting to add pod")
 				time.Sleep(50 * time.Millisecond)
 				continue
 			}
-			app.Service.Deployment.CurrentPodName = podName
-			if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-				c.l.Errorf("error updating application: %v", err)
+			if err != nil {
+				c.l.Errorf("error updating application current pod: %v", err)
+				return
 			}
 			c.l.Infof("pod %s is now the current pod for application %s", podName, applicationID.Hex())
 			return
@@ -358,6 +555,9 @@ func (c *Controller) UpdateApplicationGeneral(ctx context.Context, app *model.Ap
 	}
 
 	if patchPort != "" && app.ListeningPort != patchPort {
+		if app.Kind == model.ApplicationKindWorker {
+			return ErrInvalidOperationWithCurrentKind
+		}
 		hasSomethingChanged = true
 		fields["port"] = patchPort
 		fields["oldPort"] = app.ListeningPort
@@ -441,6 +641,17 @@ func (c *Controller) UpdateApplicationGeneral(ctx context.Context, app *model.Ap
 		return ErrNoChanges
 	}
 
+	//a new config map might have been created, the env sources must be updated
+	var envSources []model.EnvSource
+	if configMapName != "" {
+		var err error
+		envSources, err = c.deploymentEnvSources(ctx, app)
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error getting env sources: %v", err)
+			return err
+		}
+	}
+
 	updatedDeployment, err := c.ServiceManager.UpdateDeployment(
 		ctx,
 		user.Namespace,
@@ -449,34 +660,33 @@ func (c *Controller) UpdateApplicationGeneral(ctx context.Context, app *model.Ap
 		app.Service.Deployment.Replicas,
 		app.Service.Deployment.Port,
 		app.Service.Deployment.Labels,
-		configMapName)
+		envSources,
+		app.HealthChecks)
 	if err != nil {
 		c.l.WithFields(fields).Errorf("error updating deployment %s: %v", app.Service.Deployment.Name, err)
-		return err
+		return convertServiceManagerError(err)
 	}
 	c.l.WithFields(fields).Debugf("deployment %s updated succesfully", app.Service.Deployment.Name)
 	if configMapName != "" {
 		updatedDeployment.ConfigMap = app.Service.Deployment.ConfigMap
 	}
+	updatedDeployment.Secret = app.Service.Deployment.Secret
 	updatedDeployment.Volume = app.Service.Deployment.Volume
 	app.Service.Deployment = updatedDeployment
 
 	//redeploy application
 	c.l.WithFields(fields).Debugf("redeploy application to apply changes")
-	if err := c.RedeployApplication(ctx, user, app); err != nil {
+	if err := c.saveAndRedeployApplication(ctx, user, app); err != nil {
 		c.l.WithFields(fields).Errorf("error redeploy application during update")
 		return err
 	}
-
-	if err := c.updateApplication(ctx, app); err != nil {
-		return err
-	}
+	c.syncStorageLinks(ctx, user, app)
 	c.l.WithFields(fields).Infof("application updated succesfully")
 	return nil
 }
 
 func (c *Controller) UpdateApplicationBuild(ctx context.Context, app *model.Application, user *model.User, buildConfig *model.BuildConfig) error {
-	if app.Kind != model.ApplicationKindWeb {
+	if !app.Kind.IsBuiltFromRepo() {
 		// TODO: allow this for management and storage
 		// in ui it will be under "advanced settings"
 		// it will probably only be in the entrypoint
@@ -523,8 +733,7 @@ func (c *Controller) UpdateApplicationBuild(ctx context.Context, app *model.Appl
 
 	app.BuildPlan = buildConfig
 
-	app.State = model.ApplicationStateRollingOut
-	if err := c.updateApplication(ctx, app); err != nil {
+	if err := c.TransitionApplication(ctx, app, model.ApplicationStateRollingOut, "build configuration updated", user.Code); err != nil {
 		c.l.WithFields(fields).Errorf("error updating application: %v", err)
 		return err
 	}
@@ -538,7 +747,7 @@ func (c *Controller) UpdateApplicationBuild(ctx context.Context, app *model.Appl
 }
 
 func (c *Controller) RolloutApplication(ctx context.Context, user *model.User, app *model.Application) error {
-	if app.Kind != model.ApplicationKindWeb {
+	if !app.Kind.IsBuiltFromRepo() {
 		return ErrInvalidOperationWithCurrentKind
 	}
 
@@ -560,8 +769,7 @@ func (c *Controller) RolloutApplication(ctx context.Context, user *model.User, a
 		return ErrLastVersionAlreadyDeployed
 	}
 
-	app.State = model.ApplicationStateRollingOut
-	if err := c.updateApplication(ctx, app); err != nil {
+	if err := c.TransitionApplication(ctx, app, model.ApplicationStateRollingOut, fmt.Sprintf("rollout of commit %s requested", newLastHash), user.Code); err != nil {
 		return err
 	}
 
@@ -0,0 +1,528 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	backupSharedDir = "/backup"
+	backupFile      = backupSharedDir + "/dump"
+
+	// the job containers get the bucket credentials from the backup secret
+	backupUploadScript   = `mc alias set backup "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY" && mc cp ` + backupFile + ` "backup/$S3_BUCKET/$BACKUP_PREFIX/$JOB_NAME$BACKUP_EXTENSION"`
+	backupDownloadScript = `mc alias set backup "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY" && mc cp "backup/$S3_BUCKET/$BACKUP_OBJECT" ` + backupFile
+)
+
+func backupCronJobName(app *model.Application) string {
+	return "bk-" + app.ID.Hex()
+}
+
+func backupObjectPrefix(app *model.Application) string {
+	return fmt.Sprintf("%s/%s-%s", app.Owner, app.Name, app.ID.Hex())
+}
+
+func backupExtension(template *model.Template) string {
+	if template.Backup.Extension == "" {
+		return ""
+	}
+	return "." + template.Backup.Extension
+}
+
+// cron jobs created by kubernetes don't validate the schedule until they run,
+// only the standard 5 fields format and the @ macros are accepted
+func validateCronSchedule(schedule string) bool {
+	if strings.HasPrefix(schedule, "@") {
+		switch schedule {
+		case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+			return true
+		}
+		return false
+	}
+	return len(strings.Fields(schedule)) == 5
+}
+
+func (c *Controller) backupsEnabled() bool {
+	return c.config.Backups.S3Endpoint != "" && c.config.Backups.S3Bucket != ""
+}
+
+// returns the template of the application if it supports backups
+func (c *Controller) backupTemplate(ctx context.Context, app *model.Application) (*model.Template, error) {
+	if !c.backupsEnabled() {
+		return nil, ErrBackupsNotConfigured
+	}
+	if app.Kind != model.ApplicationKindStorage {
+		return nil, ErrInvalidOperationWithCurrentKind
+	}
+	template, err := c.TemplateRepo.FindByCodeAndVersion(ctx, app.BasedOn, app.TemplateVersion)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrBackupsNotSupported
+		}
+		c.l.Errorf("error finding template %s: %v", app.BasedOn, err)
+		return nil, err
+	}
+	if template.Backup == nil || template.Backup.BackupCommand == "" {
+		return nil, ErrBackupsNotSupported
+	}
+	return template, nil
+}
+
+// creates or updates the secret with the bucket credentials in the user namespace
+func (c *Controller) ensureBackupSecret(ctx context.Context, user *model.User) error {
+	data := []model.KeyValue{
+		{Key: "S3_ENDPOINT", Value: c.config.Backups.S3Endpoint},
+		{Key: "S3_BUCKET", Value: c.config.Backups.S3Bucket},
+		{Key: "S3_ACCESS_KEY", Value: c.config.Backups.S3AccessKey},
+		{Key: "S3_SECRET_KEY", Value: c.config.Backups.S3SecretKey},
+	}
+	_, err := c.ServiceManager.GetSecret(ctx, user.Namespace, staticBackupSecretName)
+	if err == nil {
+		if _, err := c.ServiceManager.UpdateSecret(ctx, user.Namespace, staticBackupSecretName, data); err != nil {
+			c.l.Errorf("error updating backup secret of user %s: %v", user.Code, err)
+			return err
+		}
+		return nil
+	}
+	if !errors.Is(err, serviceManager.ErrResourceNotFound) {
+		c.l.Errorf("error getting backup secret of user %s: %v", user.Code, err)
+		return err
+	}
+	labels := c.getDefaultLabels(user.Code, staticTempEnvironment, "", "", staticBackupSecretName)
+	if _, err := c.ServiceManager.CreateNewSecret(ctx, user.Namespace, staticBackupSecretName, data, labels); err != nil {
+		c.l.Errorf("error creating backup secret of user %s: %v", user.Code, err)
+		return err
+	}
+	return nil
+}
+
+// returns the envs and env sources needed by the backup commands to reach the application
+func (c *Controller) backupCommandEnvs(ctx context.Context, app *model.Application, user *model.User) ([]model.KeyValue, []model.EnvSource, error) {
+	envSources, err := c.deploymentEnvSources(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+	envs := []model.KeyValue{
+		{Key: "BACKUP_HOST", Value: serviceDNSName(app, user)},
+		{Key: "BACKUP_PORT", Value: app.ListeningPort},
+		{Key: "BACKUP_FILE", Value: backupFile},
+	}
+	return envs, envSources, nil
+}
+
+func backupImage(template *model.Template) string {
+	if template.Backup.Image != "" {
+		return template.Backup.Image
+	}
+	return template.ImageName
+}
+
+// the dump is created by an init container and uploaded by the uploader image
+func (c *Controller) backupJobSpec(ctx context.Context, app *model.Application, user *model.User, template *model.Template) (model.JobSpec, error) {
+	envs, envSources, err := c.backupCommandEnvs(ctx, app, user)
+	if err != nil {
+		return model.JobSpec{}, err
+	}
+	return model.JobSpec{
+		InitContainers: []model.JobContainer{{
+			Name:       "dump",
+			Image:      backupImage(template),
+			Command:    []string{"sh", "-c", template.Backup.BackupCommand},
+			Envs:       envs,
+			EnvSources: envSources,
+		}},
+		Containers: []model.JobContainer{{
+			Name:    "upload",
+			Image:   c.config.Backups.UploaderImage,
+			Command: []string{"sh", "-c", backupUploadScript},
+			Envs: []model.KeyValue{
+				{Key: "BACKUP_PREFIX", Value: backupObjectPrefix(app)},
+				{Key: "BACKUP_EXTENSION", Value: backupExtension(template)},
+			},
+			EnvSources: []model.EnvSource{{Kind: model.EnvSourceKindSecret, Name: staticBackupSecretName}},
+		}},
+		SharedDir:    backupSharedDir,
+		BackoffLimit: 1,
+	}, nil
+}
+
+// the dump is downloaded by an init container and restored in the target application
+func (c *Controller) restoreJobSpec(ctx context.Context, backup *model.Backup, target *model.Application, user *model.User, template *model.Template) (model.JobSpec, error) {
+	envs, envSources, err := c.backupCommandEnvs(ctx, target, user)
+	if err != nil {
+		return model.JobSpec{}, err
+	}
+	return model.JobSpec{
+		InitContainers: []model.JobContainer{{
+			Name:       "download",
+			Image:      c.config.Backups.UploaderImage,
+			Command:    []string{"sh", "-c", backupDownloadScript},
+			Envs:       []model.KeyValue{{Key: "BACKUP_OBJECT", Value: backup.ObjectKey}},
+			EnvSources: []model.EnvSource{{Kind: model.EnvSourceKindSecret, Name: staticBackupSecretName}},
+		}},
+		Containers: []model.JobContainer{{
+			Name:       "restore",
+			Image:      backupImage(template),
+			Command:    []string{"sh", "-c", template.Backup.RestoreCommand},
+			Envs:       envs,
+			EnvSources: envSources,
+		}},
+		SharedDir:    backupSharedDir,
+		BackoffLimit: 0,
+	}, nil
+}
+
+func (c *Controller) backupJobLabels(user *model.User, app *model.Application, resourceName string) []model.KeyValue {
+	labels := c.filledDefaultLabels(user, app, resourceName)
+	return append(labels, model.KeyValue{Key: model.BackupOfLabel, Value: app.ID.Hex()})
+}
+
+// sets the schedule (cron format) of the automatic backups of the application,
+// an empty schedule disables them
+func (c *Controller) SetApplicationBackupSchedule(ctx context.Context, app *model.Application, user *model.User, schedule string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "SetApplicationBackupSchedule",
+		"schedule":      schedule,
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+	template, err := c.backupTemplate(ctx, app)
+	if err != nil {
+		return err
+	}
+	schedule = strings.TrimSpace(schedule)
+	if schedule != "" {
+		if !validateCronSchedule(schedule) {
+			return ErrInvalidBackupSchedule
+		}
+	}
+
+	cronJobName := backupCronJobName(app)
+	switch {
+	case schedule == "" && app.BackupCron != "":
+		if err := c.ServiceManager.DeleteCronJob(ctx, user.Namespace, cronJobName, 0); err != nil {
+			c.l.WithFields(fields).Errorf("error deleting backup cron job: %v", err)
+			return err
+		}
+	case schedule != "" && app.BackupCron == "":
+		if err := c.ensureBackupSecret(ctx, user); err != nil {
+			return err
+		}
+		spec, err := c.backupJobSpec(ctx, app, user, template)
+		if err != nil {
+			return err
+		}
+		labels := c.backupJobLabels(user, app, cronJobName)
+		if _, err := c.ServiceManager.CreateNewCronJob(ctx, user.Namespace, cronJobName, schedule, spec, c.config.Backups.JobsHistory, labels); err != nil {
+			c.l.WithFields(fields).Errorf("error creating backup cron job: %v", err)
+			return convertServiceManagerError(err)
+		}
+	case schedule != "":
+		if _, err := c.ServiceManager.UpdateCronJobSchedule(ctx, user.Namespace, cronJobName, schedule); err != nil {
+			c.l.WithFields(fields).Errorf("error updating backup cron job: %v", err)
+			return err
+		}
+	}
+
+	app.BackupCron = schedule
+	if err := c.updateApplication(ctx, app); err != nil {
+		return err
+	}
+	c.l.WithFields(fields).Infof("backup schedule of application %s updated", app.Name)
+	return nil
+}
+
+// starts a backup of the application, the backup is uploaded in background
+func (c *Controller) CreateApplicationBackup(ctx context.Context, app *model.Application, user *model.User) (*model.Backup, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "CreateApplicationBackup",
+	}
+
+	if app.State != model.ApplicationStateRunning {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+	template, err := c.backupTemplate(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ensureBackupSecret(ctx, user); err != nil {
+		return nil, err
+	}
+	spec, err := c.backupJobSpec(ctx, app, user, template)
+	if err != nil {
+		return nil, err
+	}
+
+	jobName := fmt.Sprintf("%s-%d", backupCronJobName(app), time.Now().Unix())
+	if _, err := c.ServiceManager.CreateNewJob(ctx, user.Namespace, jobName, spec, c.backupJobLabels(user, app, jobName)); err != nil {
+		c.l.WithFields(fields).Errorf("error creating backup job: %v", err)
+		return nil, convertServiceManagerError(err)
+	}
+
+	backup := &model.Backup{
+		ID:              primitive.NewObjectID(),
+		CreatedAt:       time.Now(),
+		ApplicationID:   app.ID,
+		ApplicationName: app.Name,
+		Owner:           user.Code,
+		TemplateCode:    template.Code,
+		Kind:            model.BackupKindManual,
+		Status:          model.JobStatusPending,
+		JobName:         jobName,
+		ObjectKey:       backupObjectPrefix(app) + "/" + jobName + backupExtension(template),
+	}
+	if _, err := c.BackupRepo.InsertOne(ctx, backup); err != nil {
+		c.l.WithFields(fields).Errorf("error inserting backup: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("backup %s of application %s started", backup.ID.Hex(), app.Name)
+	return backup, nil
+}
+
+// updates the backups of the application with the status of their jobs, the jobs
+// created by the cron job are recorded the first time they are seen
+func (c *Controller) syncApplicationBackups(ctx context.Context, app *model.Application, user *model.User) error {
+	jobs, err := c.ServiceManager.ListJobs(ctx, user.Namespace, []model.KeyValue{{Key: model.BackupOfLabel, Value: app.ID.Hex()}})
+	if err != nil {
+		return err
+	}
+
+	var template *model.Template
+	for _, job := range jobs {
+		backup, err := c.BackupRepo.FindByJobName(ctx, job.Name)
+		if err != nil && err != repo.ErrNotFound {
+			return err
+		}
+		if err == repo.ErrNotFound {
+			if job.CronJobName == "" {
+				continue
+			}
+			if template == nil {
+				if template, err = c.backupTemplate(ctx, app); err != nil {
+					return err
+				}
+			}
+			backup = &model.Backup{
+				ID:              primitive.NewObjectID(),
+				CreatedAt:       job.StartedAt,
+				FinishedAt:      job.FinishedAt,
+				ApplicationID:   app.ID,
+				ApplicationName: app.Name,
+				Owner:           app.Owner,
+				TemplateCode:    template.Code,
+				Kind:            model.BackupKindScheduled,
+				Status:          job.Status,
+				JobName:         job.Name,
+				ObjectKey:       backupObjectPrefix(app) + "/" + job.Name + backupExtension(template),
+			}
+			if backup.CreatedAt.IsZero() {
+				backup.CreatedAt = time.Now()
+			}
+			if _, err := c.BackupRepo.InsertOne(ctx, backup); err != nil {
+				return err
+			}
+			continue
+		}
+		if backup.Status.IsFinished() || backup.Status == job.Status {
+			continue
+		}
+		backup.Status = job.Status
+		backup.FinishedAt = job.FinishedAt
+		if _, err := c.BackupRepo.UpdateByID(ctx, backup, backup.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updates the status of the restores still running
+func (c *Controller) syncBackupRestores(ctx context.Context, backup *model.Backup, user *model.User) error {
+	updated := false
+	for i := range backup.Restores {
+		restore := &backup.Restores[i]
+		if restore.Status.IsFinished() {
+			continue
+		}
+		job, err := c.ServiceManager.GetJob(ctx, user.Namespace, restore.JobName)
+		if err != nil {
+			if errors.Is(err, serviceManager.ErrResourceNotFound) {
+				restore.Status = model.JobStatusFailed
+				updated = true
+				continue
+			}
+			return err
+		}
+		if job.Status != restore.Status {
+			restore.Status = job.Status
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	_, err := c.BackupRepo.UpdateByID(ctx, backup, backup.ID)
+	return err
+}
+
+// returns the backups of the application, newest first. If the status can not be
+// read from the cluster the last known one is returned
+func (c *Controller) ListApplicationBackups(ctx context.Context, app *model.Application, user *model.User) ([]*model.Backup, error) {
+	if err := c.syncApplicationBackups(ctx, app, user); err != nil {
+		c.l.Warnf("error syncing backups of application %s: %v", app.ID.Hex(), err)
+	}
+	backups, err := c.BackupRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding backups of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	for _, backup := range backups {
+		if err := c.syncBackupRestores(ctx, backup, user); err != nil {
+			c.l.Warnf("error syncing restores of backup %s: %v", backup.ID.Hex(), err)
+		}
+	}
+	return backups, nil
+}
+
+func (c *Controller) GetBackup(ctx context.Context, user *model.User, backupID primitive.ObjectID) (*model.Backup, error) {
+	backup, err := c.BackupRepo.FindByID(ctx, backupID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrBackupNotFound
+		}
+		c.l.Errorf("error finding backup %s: %v", backupID.Hex(), err)
+		return nil, err
+	}
+	if backup.Owner != user.Code {
+		return nil, ErrBackupNotFound
+	}
+	return backup, nil
+}
+
+// restores the backup in the target application, the current data of the target
+// is overwritten. The target must be based on the same template of the backup
+func (c *Controller) RestoreBackup(ctx context.Context, user *model.User, backup *model.Backup, target *model.Application) (*model.BackupRestore, error) {
+	fields := logrus.Fields{
+		"backupID":      backup.ID.Hex(),
+		"applicationID": target.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "RestoreBackup",
+	}
+
+	if backup.Status != model.JobStatusSucceeded {
+		return nil, ErrBackupNotRestorable
+	}
+	if target.State != model.ApplicationStateRunning {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+	template, err := c.backupTemplate(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if target.BasedOn != backup.TemplateCode {
+		return nil, ErrBackupTemplateMismatch
+	}
+	if template.Backup.RestoreCommand == "" {
+		return nil, ErrBackupNotRestorable
+	}
+	if err := c.ensureBackupSecret(ctx, user); err != nil {
+		return nil, err
+	}
+	spec, err := c.restoreJobSpec(ctx, backup, target, user, template)
+	if err != nil {
+		return nil, err
+	}
+
+	jobName := fmt.Sprintf("rs-%s-%d", backup.ID.Hex(), time.Now().Unix())
+	if _, err := c.ServiceManager.CreateNewJob(ctx, user.Namespace, jobName, spec, c.filledDefaultLabels(user, target, jobName)); err != nil {
+		c.l.WithFields(fields).Errorf("error creating restore job: %v", err)
+		return nil, convertServiceManagerError(err)
+	}
+
+	restore := model.BackupRestore{
+		ApplicationID: target.ID,
+		JobName:       jobName,
+		Status:        model.JobStatusPending,
+		CreatedAt:     time.Now(),
+	}
+	backup.Restores = append(backup.Restores, restore)
+	if _, err := c.BackupRepo.UpdateByID(ctx, backup, backup.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error updating backup: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("restore of backup %s in application %s started", backup.ID.Hex(), target.Name)
+	return &restore, nil
+}
+
+// creates a new application based on the template of the backup and restores the
+// backup in it. The credentials are copied from the backed up application, so it
+// must still exist
+func (c *Controller) RestoreBackupToNewApplication(ctx context.Context, user *model.User, backup *model.Backup, name string) (*model.Application, *model.BackupRestore, error) {
+	source, err := c.ApplicationRepo.FindByID(ctx, backup.ApplicationID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, nil, ErrBackupNotRestorable
+		}
+		c.l.Errorf("error finding application %s: %v", backup.ApplicationID.Hex(), err)
+		return nil, nil, err
+	}
+	template, err := c.backupTemplate(ctx, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !c.IsNameAvailableUserWide(ctx, name, user.Code) {
+		return nil, nil, ErrApplicationNameNotAvailable
+	}
+
+	secrets, err := c.decryptSecrets(source.Secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	//the default envs are added again by the template
+	defaults := make(map[string]bool, len(template.DefaultEnvs))
+	for _, env := range template.DefaultEnvs {
+		defaults[env.Key] = true
+	}
+	envs := make([]model.KeyValue, 0, len(source.Envs)+len(secrets))
+	for _, env := range append(source.Envs, secrets...) {
+		if !defaults[env.Key] {
+			envs = append(envs, env)
+		}
+	}
+
+	app, _, err := c.CreateNewApplicationBasedOnTemplate(ctx, user.Code, name, template, envs)
+	if err != nil {
+		return nil, nil, err
+	}
+	restore, err := c.RestoreBackup(ctx, user, backup, app)
+	if err != nil {
+		return app, nil, err
+	}
+	return app, restore, nil
+}
+
+func (c *Controller) deleteBackupCronJob(ctx context.Context, app *model.Application, user *model.User) error {
+	if app.BackupCron == "" {
+		return nil
+	}
+	c.l.Debugf("deleting backup cron job of application %s", app.Name)
+	if err := c.ServiceManager.DeleteCronJob(ctx, user.Namespace, backupCronJobName(app), gracePeriod); err != nil {
+		c.l.Errorf("error deleting backup cron job of application %s: %v", app.Name, err)
+		return err
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ipaas-org/ipaas-backend/config"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/encryption"
 	"github.com/ipaas-org/ipaas-backend/pkg/jwt"
 	"github.com/ipaas-org/ipaas-backend/repo"
 	"github.com/ipaas-org/ipaas-backend/services/gitProvider"
@@ -12,6 +15,12 @@ import (
 	"github.com/ipaas-org/ipaas-backend/services/imageBuilder/ipaas"
 	logprovider "github.com/ipaas-org/ipaas-backend/services/logProvider"
 	"github.com/ipaas-org/ipaas-backend/services/logProvider/grafana"
+	metricsprovider "github.com/ipaas-org/ipaas-backend/services/metricsProvider"
+	k8smetrics "github.com/ipaas-org/ipaas-backend/services/metricsProvider/kubernetes"
+	"github.com/ipaas-org/ipaas-backend/services/metricsProvider/prometheus"
+	"github.com/ipaas-org/ipaas-backend/services/notifier"
+	"github.com/ipaas-org/ipaas-backend/services/notifier/smtp"
+	"github.com/ipaas-org/ipaas-backend/services/notifier/webhook"
 	k8smanager "github.com/ipaas-org/ipaas-backend/services/serviceManager/k8s"
 	"github.com/sirupsen/logrus"
 )
@@ -20,6 +29,12 @@ const (
 	staticTempEnvironment         = "prod"
 	staticPullSecretName          = "registrypullsecret"
 	staticErrorPageMiddlewareName = "errorpagemiddleware"
+	staticResourceQuotaName       = "ipaas-quota"
+	staticLimitRangeName          = "ipaas-limits"
+	staticBackupSecretName        = "ipaas-backup-s3"
+
+	// times an application changed concurrently is read again before giving up
+	maxModifyRetries = 5
 )
 
 type Controller struct {
@@ -27,19 +42,33 @@ type Controller struct {
 	l *logrus.Logger
 
 	// repositories
-	UserRepo        repo.UserRepoer
-	TokenRepo       repo.TokenRepoer
-	StateRepo       repo.StateRepoer
-	ApplicationRepo repo.ApplicationRepoer
-	TemplateRepo    repo.TemplateRepoer
-	TempTokenRepo   repo.TemporaryTokenStorage
+	UserRepo                repo.UserRepoer
+	TokenRepo               repo.TokenRepoer
+	StateRepo               repo.StateRepoer
+	ApplicationRepo         repo.ApplicationRepoer
+	TemplateRepo            repo.TemplateRepoer
+	TempTokenRepo           repo.TemporaryTokenStorage
+	AuditRepo               repo.AuditRepoer
+	StateHistoryRepo        repo.StateHistoryRepoer
+	EnvGroupRepo            repo.EnvGroupRepoer
+	BackupRepo              repo.BackupRepoer
+	StackTemplateRepo       repo.StackTemplateRepoer
+	StackRepo               repo.StackRepoer
+	ScheduledJobRepo        repo.ScheduledJobRepoer
+	JobRunRepo              repo.JobRunRepoer
+	NotificationChannelRepo repo.NotificationChannelRepoer
 
 	// services
-	gitProvider    gitProvider.Provider
-	jwtHandler     *jwt.JWThandler
-	ServiceManager *k8smanager.K8sOrchestratedServiceManager
-	imageBuilder   imageBuilder.ImageBuilder
-	logProvider    logprovider.LogProvider
+	gitProvider     gitProvider.Provider
+	jwtHandler      *jwt.JWThandler
+	ServiceManager  *k8smanager.InstrumentedK8sOrchestratedServiceManager
+	imageBuilder    imageBuilder.ImageBuilder
+	logProvider     logprovider.LogProvider
+	metricsProvider metricsprovider.MetricsProvider
+	encrypter       *encryption.Encrypter
+
+	notifiers           map[model.NotificationChannelKind]notifier.Notifier
+	notificationLimiter *notificationLimiter
 
 	// configs
 	app     config.App
@@ -61,11 +90,19 @@ func NewController(ctx context.Context, config *config.Config, l *logrus.Logger)
 	serviceManager, err := k8smanager.NewK8sOrchestratedServiceManager(
 		config.K8s.KubeConfigPath,
 		config.K8s.CPUResource,
-		config.K8s.MemoryResource)
+		config.K8s.MemoryResource,
+		config.Rollout.MaxSurge,
+		config.Rollout.MaxUnavailable,
+		config.Rollout.Deadline)
 	if err != nil {
 		l.Fatalf("Failed to create k8s service manager: %v", err)
 	}
 
+	c, err := NewControllerWithServiceManager(config, l, k8smanager.NewInstrumentedK8sOrchestratedServiceManager(serviceManager))
+	if err != nil {
+		l.Fatalf("Failed to create controller: %v", err)
+	}
+
 	imageBuilder := ipaas.NewIpaasImageBuilder(config.RMQ.URI, config.RMQ.RequestQueue)
 
 	if config.JWT.Duration == 0 {
@@ -88,16 +125,69 @@ func NewController(ctx context.Context, config *config.Config, l *logrus.Logger)
 		l.Fatalf("Unknown log provider: %s", config.LogProvider.Provider)
 	}
 
+	var metricsProvider metricsprovider.MetricsProvider
+	switch config.MetricsProvider.Provider {
+	case metricsprovider.MetricsProviderPrometheus:
+		l.Infof("Using Prometheus as metrics provider")
+		metricsProvider, err = prometheus.NewPrometheusMetricsProvider(config.MetricsProvider.Token, config.MetricsProvider.BaseUrl)
+		if err != nil {
+			l.Fatalf("Failed to create prometheus metrics provider: %v", err)
+		}
+	case metricsprovider.MetricsProviderKubernetes:
+		l.Infof("Using Kubernetes metrics api as metrics provider")
+		metricsProvider, err = k8smetrics.NewKubernetesMetricsProvider(config.K8s.KubeConfigPath)
+		if err != nil {
+			l.Fatalf("Failed to create kubernetes metrics provider: %v", err)
+		}
+	case metricsprovider.MetricsProviderMock:
+		l.Warnf("using mock metrics provider, currently maps to disabled")
+	default:
+		l.Fatalf("Unknown metrics provider: %s", config.MetricsProvider.Provider)
+	}
+
 	l.Infof("sending request on %s queue", config.RMQ.RequestQueue)
-	return &Controller{
-		l:              l,
-		gitProvider:    provider,
-		jwtHandler:     jwtHandler,
-		ServiceManager: serviceManager,
-		app:            config.App,
-		imageBuilder:   imageBuilder,
-		config:         config,
-		traefik:        config.Traefik,
-		logProvider:    logProvider,
+	c.gitProvider = provider
+	c.jwtHandler = jwtHandler
+	c.imageBuilder = imageBuilder
+	c.logProvider = logProvider
+	c.metricsProvider = metricsProvider
+	return c
+}
+
+// creates the controller with the given service manager and without the external
+// services (git provider, jwt, image builder, logs and metrics), the repositories
+// are set by the caller. Used by NewController and by the tests with a fake cluster
+func NewControllerWithServiceManager(config *config.Config, l *logrus.Logger, serviceManager *k8smanager.InstrumentedK8sOrchestratedServiceManager) (*Controller, error) {
+	encrypter, err := encryption.NewEncrypter(config.Secrets.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption.NewEncrypter: %w", err)
 	}
+
+	webhookClient := webhook.NewPublicClient(config.Notifications.Timeout)
+	notifiers := map[model.NotificationChannelKind]notifier.Notifier{
+		model.NotificationChannelKindWebhook: webhook.NewWebhookNotifier(webhook.FormatJSON, webhookClient),
+		model.NotificationChannelKindSlack:   webhook.NewWebhookNotifier(webhook.FormatSlack, webhookClient),
+		model.NotificationChannelKindDiscord: webhook.NewWebhookNotifier(webhook.FormatDiscord, webhookClient),
+	}
+	if config.Notifications.SMTPHost != "" {
+		notifiers[model.NotificationChannelKindEmail] = smtp.NewSMTPNotifier(
+			config.Notifications.SMTPHost,
+			config.Notifications.SMTPPort,
+			config.Notifications.SMTPUsername,
+			config.Notifications.SMTPPassword,
+			config.Notifications.SMTPFrom)
+	} else {
+		l.Warnf("smtp host not set, email notifications are disabled")
+	}
+
+	return &Controller{
+		l:                   l,
+		ServiceManager:      serviceManager,
+		app:                 config.App,
+		config:              config,
+		traefik:             config.Traefik,
+		notifiers:           notifiers,
+		notificationLimiter: newNotificationLimiter(config.Notifications.RateLimit),
+		encrypter:           encrypter,
+	}, nil
 }
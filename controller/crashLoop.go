@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// records the last crash of the current container of the application and its
+// last log lines, once the restarts reach the configured threshold the deployment
+// is scaled to 0 and the application is stopped until the user resumes it
+func (c *Controller) RecordApplicationCrash(ctx context.Context, app *model.Application, namespace, podName string, restartCount, exitCode int32, reason string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"action":        "RecordApplicationCrash",
+	}
+
+	//a new version crashing during a rollout is reverted by the rollout watcher
+	if app.State == model.ApplicationStateStopped ||
+		app.State == model.ApplicationStateDeleting ||
+		app.State == model.ApplicationStateRollingOut {
+		c.l.WithFields(fields).Debugf("application %s is %s, ignoring crash of %s", app.Name, app.State, podName)
+		return nil
+	}
+
+	//the same crash is seen again on every resync of the pod
+	if app.State == model.ApplicationStateCrashed && app.CrashLoop != nil && app.CrashLoop.RestartCount == restartCount {
+		return nil
+	}
+
+	//the container is waiting to be restarted, the logs are the ones of the crashed instance
+	logs, err := c.ServiceManager.GetPodLogs(ctx, namespace, podName, "", c.config.CrashLoop.LogLines, true)
+	if err != nil {
+		c.l.WithFields(fields).Warnf("error getting logs of crashed container %s: %v", podName, err)
+	}
+	crashedAt := time.Now()
+	recordCrash := func(app *model.Application) {
+		if app.CrashLoop == nil {
+			app.CrashLoop = new(model.CrashLoop)
+		}
+		app.CrashLoop.RestartCount = restartCount
+		app.CrashLoop.LastExitCode = exitCode
+		app.CrashLoop.LastReason = reason
+		app.CrashLoop.LastCrashAt = crashedAt
+		if logs != "" {
+			app.CrashLoop.Logs = logs
+		}
+	}
+
+	//if the application changed state in the meantime only the crash is saved
+	seen := app.State
+	threshold := c.config.CrashLoop.RestartThreshold
+	if threshold <= 0 || restartCount < threshold || app.Service == nil || app.Service.Deployment == nil {
+		return c.transitionApplicationLatestFrom(ctx, app, seen, model.ApplicationStateCrashed, fmt.Sprintf("container %s restarted %d times, last exit code %d", podName, restartCount, exitCode), model.StateTransitionActorContainerEvents, recordCrash)
+	}
+
+	//the state is saved before scaling, so a failed scale leaves a stopped application
+	//the user can resume instead of a crashed one without replicas
+	c.l.WithFields(fields).Infof("container %s restarted %d times, stopping application %s", podName, restartCount, app.Name)
+	if err := c.transitionApplicationLatestFrom(ctx, app, seen, model.ApplicationStateStopped, fmt.Sprintf("container %s restarted %d times, the restart threshold was reached", podName, restartCount), model.StateTransitionActorCrashLoopPolicy, func(app *model.Application) {
+		recordCrash(app)
+		if app.State != seen {
+			return
+		}
+		app.CrashLoop.Stopped = true
+		app.CrashLoop.StoppedAt = crashedAt
+		app.Health = model.ApplicationHealthNotReady
+		if app.Service != nil && app.Service.Deployment != nil {
+			app.Service.Deployment.CurrentPodName = ""
+		}
+	}); err != nil {
+		return err
+	}
+	if app.State != model.ApplicationStateStopped || app.Service == nil || app.Service.Deployment == nil {
+		c.l.WithFields(fields).Infof("application %s is %s, not stopping it", app.Name, app.State)
+		return nil
+	}
+
+	//the replicas of the application are kept to restore them on resume
+	if err := c.ServiceManager.ScaleDeployment(ctx, namespace, app.Service.Deployment.Name, 0); err != nil {
+		c.l.WithFields(fields).Errorf("error scaling deployment %s to 0: %v", app.Service.Deployment.Name, err)
+		return err
+	}
+	c.NotifyApplicationEvent(app, model.NotificationEventApplicationCrashed,
+		fmt.Sprintf("the application was stopped after %d restarts, last exit code %d, resume it once the issue is fixed", restartCount, exitCode))
+	return nil
+}
+
+// scales back the deployment of an application stopped by the crash loop policy,
+// the crash counters are reset while the last exit code and logs are kept
+func (c *Controller) ResumeApplication(ctx context.Context, user *model.User, app *model.Application) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "ResumeApplication",
+	}
+
+	if app.State != model.ApplicationStateStopped {
+		return ErrInvalidOperationInCurrentState
+	}
+	if app.Service == nil || app.Service.Deployment == nil {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	replicas := app.Service.Deployment.Replicas
+	if replicas <= 0 {
+		replicas = 1
+	}
+	c.l.WithFields(fields).Infof("resuming application %s with %d replicas", app.Name, replicas)
+	if err := c.ServiceManager.ScaleDeployment(ctx, user.Namespace, app.Service.Deployment.Name, replicas); err != nil {
+		c.l.WithFields(fields).Errorf("error scaling deployment %s: %v", app.Service.Deployment.Name, err)
+		return err
+	}
+
+	if app.CrashLoop != nil {
+		app.CrashLoop.RestartCount = 0
+		app.CrashLoop.Stopped = false
+		app.CrashLoop.StoppedAt = time.Time{}
+	}
+	app.Service.Deployment.CurrentPodName = ""
+	if err := c.TransitionApplication(ctx, app, model.ApplicationStateStarting, "application resumed", user.Code); err != nil {
+		return err
+	}
+	c.recordAuditEvent(ctx, user, app, model.AuditActionApplicationResumed, app.Service.Deployment.Name)
+	return nil
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+)
+
+const (
+	// only alphanumeric characters so the values can be used in urls and shell commands
+	// without escaping
+	passwordAlphabet   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	letterAlphabet     = "abcdefghijklmnopqrstuvwxyz"
+	identifierAlphabet = letterAlphabet + "0123456789"
+
+	defaultPasswordLength   = 32
+	defaultIdentifierLength = 8
+	minPasswordLength       = 16
+)
+
+func randomString(alphabet string, length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	value := make([]byte, length)
+	for i := range value {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		value[i] = alphabet[n.Int64()]
+	}
+	return string(value), nil
+}
+
+// usernames and database names start with a letter so they are valid identifiers
+// in every database
+func generateEnvValue(rule model.GeneratedEnv) (string, error) {
+	switch rule.Kind {
+	case model.GeneratedEnvKindPassword:
+		length := rule.Length
+		if length == 0 {
+			length = defaultPasswordLength
+		}
+		if length < minPasswordLength {
+			length = minPasswordLength
+		}
+		return randomString(passwordAlphabet, length)
+	case model.GeneratedEnvKindUsername, model.GeneratedEnvKindDatabase:
+		length := rule.Length
+		if length <= 0 {
+			length = defaultIdentifierLength
+		}
+		first, err := randomString(letterAlphabet, 1)
+		if err != nil {
+			return "", err
+		}
+		rest, err := randomString(identifierAlphabet, length-1)
+		if err != nil {
+			return "", err
+		}
+		return rule.Prefix + first + rest, nil
+	default:
+		return "", ErrInvalidGeneratedEnv
+	}
+}
+
+// generates the envs of the template not set by the user, the generated values
+// are returned separately so they can be shown to the user once
+func generateTemplateEnvs(template *model.Template, envs []model.KeyValue) ([]model.KeyValue, error) {
+	set := make(map[string]bool, len(envs))
+	for _, env := range envs {
+		if env.Value != "" {
+			set[env.Key] = true
+		}
+	}
+	var generated []model.KeyValue
+	for _, rule := range template.GeneratedEnvs {
+		if set[rule.Key] {
+			continue
+		}
+		value, err := generateEnvValue(rule)
+		if err != nil {
+			return nil, err
+		}
+		generated = append(generated, model.KeyValue{Key: rule.Key, Value: value})
+		set[rule.Key] = true
+	}
+	return generated, nil
+}
+
+// removes the envs the user left empty and that were generated, so that the
+// generated value is the only one with its key
+func dropGeneratedEmptyEnvs(envs, generated []model.KeyValue) []model.KeyValue {
+	keys := make(map[string]bool, len(generated))
+	for _, env := range generated {
+		keys[env.Key] = true
+	}
+	kept := make([]model.KeyValue, 0, len(envs))
+	for _, env := range envs {
+		if env.Value == "" && keys[env.Key] {
+			continue
+		}
+		kept = append(kept, env)
+	}
+	return kept
+}
@@ -0,0 +1,368 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the name is used in the kubernetes resource names so it must be a valid dns label
+var envGroupNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,38}[a-z0-9])?$`)
+
+// returns the sources the application envs are loaded from, the attached env groups
+// and links come first so the envs of the application take precedence over them
+func (c *Controller) applicationEnvSources(ctx context.Context, app *model.Application, configMapName, secretName string) ([]model.EnvSource, error) {
+	envSources := make([]model.EnvSource, 0, 2*len(app.EnvGroups)+len(app.Links)+2)
+	for _, groupID := range app.EnvGroups {
+		group, err := c.EnvGroupRepo.FindByID(ctx, groupID)
+		if err != nil {
+			if err == repo.ErrNotFound {
+				c.l.Warnf("env group %s attached to application %s does not exist, skipping", groupID.Hex(), app.ID.Hex())
+				continue
+			}
+			c.l.Errorf("error finding env group %s: %v", groupID.Hex(), err)
+			return nil, err
+		}
+		envSources = append(envSources,
+			model.EnvSource{Kind: model.EnvSourceKindConfigMap, Name: group.ConfigMapName},
+			model.EnvSource{Kind: model.EnvSourceKindSecret, Name: group.SecretName})
+	}
+	for _, link := range app.Links {
+		envSources = append(envSources, model.EnvSource{Kind: model.EnvSourceKindSecret, Name: link.SecretName})
+	}
+	if configMapName != "" {
+		envSources = append(envSources, model.EnvSource{Kind: model.EnvSourceKindConfigMap, Name: configMapName})
+	}
+	if secretName != "" {
+		envSources = append(envSources, model.EnvSource{Kind: model.EnvSourceKindSecret, Name: secretName})
+	}
+	return envSources, nil
+}
+
+// returns the env sources of an already deployed application
+func (c *Controller) deploymentEnvSources(ctx context.Context, app *model.Application) ([]model.EnvSource, error) {
+	configMapName := ""
+	secretName := ""
+	if app.Service != nil && app.Service.Deployment != nil {
+		if app.Service.Deployment.ConfigMap != nil {
+			configMapName = app.Service.Deployment.ConfigMap.Name
+		}
+		if app.Service.Deployment.Secret != nil {
+			secretName = app.Service.Deployment.Secret.Name
+		}
+	}
+	return c.applicationEnvSources(ctx, app, configMapName, secretName)
+}
+
+func validateEnvs(envs []model.KeyValue) error {
+	for _, env := range envs {
+		if env.Key == "" || env.Value == "" {
+			return ErrInvalidEnv
+		}
+	}
+	return nil
+}
+
+func (c *Controller) recordEnvGroupAuditEvent(ctx context.Context, user *model.User, group *model.EnvGroup, action model.AuditAction, target string) {
+	event := &model.AuditEvent{
+		CreatedAt:  time.Now(),
+		UserCode:   user.Code,
+		EnvGroupID: group.ID,
+		Action:     action,
+		Target:     target,
+	}
+	if _, err := c.AuditRepo.InsertOne(ctx, event); err != nil {
+		c.l.WithFields(logrus.Fields{
+			"envGroupID": group.ID.Hex(),
+			"userID":     user.Code,
+			"action":     action,
+			"target":     target,
+		}).Errorf("error recording audit event: %v", err)
+	}
+}
+
+func (c *Controller) CreateEnvGroup(ctx context.Context, user *model.User, name string, envs, secrets []model.KeyValue) (*model.EnvGroup, error) {
+	if !envGroupNameRegex.MatchString(name) {
+		return nil, ErrInvalidEnvGroupName
+	}
+	if _, err := c.EnvGroupRepo.FindByNameAndOwner(ctx, name, user.Code); err == nil {
+		return nil, ErrEnvGroupNameNotAvailable
+	} else if err != repo.ErrNotFound {
+		c.l.Errorf("error finding env group by name: %v", err)
+		return nil, err
+	}
+	if err := validateEnvs(envs); err != nil {
+		return nil, err
+	}
+	encrypted, err := c.encryptSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &model.EnvGroup{
+		ID:      primitive.NewObjectID(),
+		Name:    name,
+		Owner:   user.Code,
+		Envs:    envs,
+		Secrets: encrypted,
+	}
+	group.ConfigMapName = fmt.Sprintf("eg-cm-%s-%s", group.Name, group.ID.Hex())
+	group.SecretName = fmt.Sprintf("eg-secret-%s-%s", group.Name, group.ID.Hex())
+
+	configMapLabels := c.getDefaultLabels(user.Code, staticTempEnvironment, "", "", group.ConfigMapName)
+	if _, err := c.ServiceManager.CreateNewConfigMap(ctx, user.Namespace, group.ConfigMapName, envs, configMapLabels); err != nil {
+		c.l.Errorf("error creating config map for env group %s: %v", name, err)
+		return nil, err
+	}
+	secretLabels := c.getDefaultLabels(user.Code, staticTempEnvironment, "", "", group.SecretName)
+	if _, err := c.ServiceManager.CreateNewSecret(ctx, user.Namespace, group.SecretName, secrets, secretLabels); err != nil {
+		c.l.Errorf("error creating secret for env group %s: %v", name, err)
+		if err := c.ServiceManager.DeleteConfigMap(ctx, user.Namespace, group.ConfigMapName, 0); err != nil {
+			c.l.Errorf("error deleting config map %s: %v", group.ConfigMapName, err)
+		}
+		return nil, err
+	}
+
+	if _, err := c.EnvGroupRepo.InsertOne(ctx, group); err != nil {
+		c.l.Errorf("error inserting env group: %v", err)
+		return nil, err
+	}
+	for _, secret := range group.Secrets {
+		c.recordEnvGroupAuditEvent(ctx, user, group, model.AuditActionSecretCreated, secret.Key)
+	}
+	c.l.Infof("env group %s created for user %s", name, user.Code)
+	return group, nil
+}
+
+func (c *Controller) ListEnvGroups(ctx context.Context, user *model.User) ([]*model.EnvGroup, error) {
+	groups, err := c.EnvGroupRepo.FindByOwner(ctx, user.Code)
+	if err != nil {
+		c.l.Errorf("error finding env groups of user %s: %v", user.Code, err)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// returns the env group only if it's owned by the user
+func (c *Controller) GetEnvGroup(ctx context.Context, user *model.User, id primitive.ObjectID) (*model.EnvGroup, error) {
+	group, err := c.EnvGroupRepo.FindByID(ctx, id)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrEnvGroupNotFound
+		}
+		c.l.Errorf("error finding env group %s: %v", id.Hex(), err)
+		return nil, err
+	}
+	if group.Owner != user.Code {
+		return nil, ErrEnvGroupNotFound
+	}
+	return group, nil
+}
+
+// envs replace the current plain envs if not nil, secrets are created or updated
+// and deletedSecrets removed. Every attached application is restarted to load the changes
+func (c *Controller) UpdateEnvGroup(ctx context.Context, user *model.User, group *model.EnvGroup, envs, secrets []model.KeyValue, deletedSecrets []string) error {
+	fields := logrus.Fields{
+		"envGroupID": group.ID.Hex(),
+		"userID":     user.Code,
+		"action":     "UpdateEnvGroup",
+	}
+
+	if envs == nil && len(secrets) == 0 && len(deletedSecrets) == 0 {
+		return ErrNoChanges
+	}
+
+	if err := validateEnvs(envs); err != nil {
+		return err
+	}
+
+	secretsChanged := false
+	audit := make(map[string]model.AuditAction)
+	if len(secrets) > 0 {
+		encrypted, err := c.encryptSecrets(secrets)
+		if err != nil {
+			return err
+		}
+		for _, secret := range encrypted {
+			action := model.AuditActionSecretCreated
+			for i := range group.Secrets {
+				if group.Secrets[i].Key == secret.Key {
+					group.Secrets = append(group.Secrets[:i], group.Secrets[i+1:]...)
+					action = model.AuditActionSecretUpdated
+					break
+				}
+			}
+			group.Secrets = append(group.Secrets, secret)
+			audit[secret.Key] = action
+		}
+		secretsChanged = true
+	}
+	for _, key := range deletedSecrets {
+		found := false
+		for i := range group.Secrets {
+			if group.Secrets[i].Key == key {
+				group.Secrets = append(group.Secrets[:i], group.Secrets[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrSecretNotFound
+		}
+		audit[key] = model.AuditActionSecretDeleted
+		secretsChanged = true
+	}
+
+	if envs != nil {
+		if _, err := c.ServiceManager.UpdateConfigMap(ctx, user.Namespace, group.ConfigMapName, envs); err != nil {
+			c.l.WithFields(fields).Errorf("error updating config map %s: %v", group.ConfigMapName, err)
+			return err
+		}
+		group.Envs = envs
+	}
+
+	if secretsChanged {
+		data, err := c.decryptSecrets(group.Secrets)
+		if err != nil {
+			return err
+		}
+		if _, err := c.ServiceManager.UpdateSecret(ctx, user.Namespace, group.SecretName, data); err != nil {
+			c.l.WithFields(fields).Errorf("error updating secret %s: %v", group.SecretName, err)
+			return err
+		}
+	}
+
+	if _, err := c.EnvGroupRepo.UpdateByID(ctx, group, group.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error updating env group: %v", err)
+		return err
+	}
+	for key, action := range audit {
+		c.recordEnvGroupAuditEvent(ctx, user, group, action, key)
+	}
+
+	c.restartEnvGroupApplications(ctx, user, group)
+	c.l.WithFields(fields).Infof("env group %s updated", group.Name)
+	return nil
+}
+
+// envs are only read when the container starts, so every deployed application
+// using the group is restarted. A failing restart does not stop the others
+func (c *Controller) restartEnvGroupApplications(ctx context.Context, user *model.User, group *model.EnvGroup) {
+	apps, err := c.ApplicationRepo.FindByEnvGroupID(ctx, group.ID)
+	if err != nil {
+		c.l.Errorf("error finding applications of env group %s: %v", group.ID.Hex(), err)
+		return
+	}
+	for _, app := range apps {
+		if app.Service == nil || app.Service.Deployment == nil ||
+			app.State == model.ApplicationStateDeleting {
+			continue
+		}
+		c.l.Infof("restarting application %s to apply env group %s", app.Name, group.Name)
+		if err := c.RedeployApplication(ctx, user, app); err != nil {
+			c.l.Errorf("error restarting application %s after env group update: %v", app.ID.Hex(), err)
+		}
+	}
+}
+
+func (c *Controller) DeleteEnvGroup(ctx context.Context, user *model.User, group *model.EnvGroup) error {
+	apps, err := c.ApplicationRepo.FindByEnvGroupID(ctx, group.ID)
+	if err != nil {
+		c.l.Errorf("error finding applications of env group %s: %v", group.ID.Hex(), err)
+		return err
+	}
+	if len(apps) > 0 {
+		return ErrEnvGroupInUse
+	}
+
+	if err := c.ServiceManager.DeleteConfigMap(ctx, user.Namespace, group.ConfigMapName, gracePeriod); err != nil {
+		c.l.Errorf("error deleting config map %s: %v", group.ConfigMapName, err)
+		return err
+	}
+	if err := c.ServiceManager.DeleteSecret(ctx, user.Namespace, group.SecretName, gracePeriod); err != nil {
+		c.l.Errorf("error deleting secret %s: %v", group.SecretName, err)
+		return err
+	}
+	if _, err := c.EnvGroupRepo.DeleteByID(ctx, group.ID); err != nil {
+		c.l.Errorf("error deleting env group %s: %v", group.ID.Hex(), err)
+		return err
+	}
+	c.l.Infof("env group %s of user %s deleted", group.Name, user.Code)
+	return nil
+}
+
+func (c *Controller) AttachEnvGroup(ctx context.Context, app *model.Application, user *model.User, group *model.EnvGroup) error {
+	for _, id := range app.EnvGroups {
+		if id == group.ID {
+			return ErrEnvGroupAlreadyAttached
+		}
+	}
+	app.EnvGroups = append(app.EnvGroups, group.ID)
+	return c.applyApplicationEnvSources(ctx, app, user)
+}
+
+func (c *Controller) DetachEnvGroup(ctx context.Context, app *model.Application, user *model.User, group *model.EnvGroup) error {
+	found := false
+	for i, id := range app.EnvGroups {
+		if id == group.ID {
+			app.EnvGroups = append(app.EnvGroups[:i], app.EnvGroups[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrEnvGroupNotFound
+	}
+	return c.applyApplicationEnvSources(ctx, app, user)
+}
+
+// saves the env sources of the application and updates the deployment to load them,
+// nothing is changed in the cluster if the application was changed since it was read
+func (c *Controller) applyApplicationEnvSources(ctx context.Context, app *model.Application, user *model.User) error {
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+	if err := c.updateApplication(ctx, app); err != nil {
+		return err
+	}
+	return c.syncApplicationEnvSources(ctx, app, user)
+}
+
+// updates the deployment with the env sources of the saved application and restarts it,
+// applications without a deployment load them on creation
+func (c *Controller) syncApplicationEnvSources(ctx context.Context, app *model.Application, user *model.User) error {
+	if app.Service == nil || app.Service.Deployment == nil {
+		return nil
+	}
+
+	envSources, err := c.deploymentEnvSources(ctx, app)
+	if err != nil {
+		return err
+	}
+	updatedDeployment, err := c.ServiceManager.UpdateDeployment(
+		ctx,
+		user.Namespace,
+		app.Service.Deployment.Name,
+		app.Service.Deployment.ImageRegistry,
+		app.Service.Deployment.Replicas,
+		app.Service.Deployment.Port,
+		app.Service.Deployment.Labels,
+		envSources,
+		app.HealthChecks)
+	if err != nil {
+		c.l.Errorf("error updating deployment %s: %v", app.Service.Deployment.Name, err)
+		return convertServiceManagerError(err)
+	}
+	updatedDeployment.ConfigMap = app.Service.Deployment.ConfigMap
+	updatedDeployment.Secret = app.Service.Deployment.Secret
+	updatedDeployment.Volume = app.Service.Deployment.Volume
+	app.Service.Deployment = updatedDeployment
+
+	return c.RedeployApplication(ctx, user, app)
+}
@@ -23,6 +23,82 @@ var (
 	ErrLastVersionAlreadyDeployed      = errors.New("last version already deployed")
 	ErrInvalidOperationWithCurrentKind = errors.New("invalid operation with current kind")
 	ErrInexistingRootDir               = errors.New("inxisting root dir provided")
+	ErrInvalidHealthCheck              = errors.New("invalid health check")
+	ErrApplicationChanged              = errors.New("application changed concurrently")
+	ErrInvalidStateTransition          = errors.New("invalid state transition")
+
+	// quota
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// secrets
+	ErrInvalidSecret  = errors.New("invalid secret")
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// env groups
+	ErrInvalidEnvGroupName      = errors.New("invalid env group name")
+	ErrEnvGroupNameNotAvailable = errors.New("env group name not available")
+	ErrEnvGroupNotFound         = errors.New("env group not found")
+	ErrEnvGroupInUse            = errors.New("env group attached to applications")
+	ErrEnvGroupAlreadyAttached  = errors.New("env group already attached")
+
+	// links
+	ErrApplicationNotLinkable    = errors.New("application not linkable")
+	ErrApplicationsAlreadyLinked = errors.New("applications already linked")
+	ErrApplicationsNotLinked     = errors.New("applications not linked")
+	ErrInvalidLinkEnv            = errors.New("invalid link env")
+	ErrIncompatibleTarget        = errors.New("storage not compatible with the managment template")
+
+	// volumes
+	ErrInvalidVolume          = errors.New("invalid volume")
+	ErrInvalidVolumeSize      = errors.New("invalid volume size")
+	ErrVolumeNotFound         = errors.New("volume not found")
+	ErrVolumeNameNotAvailable = errors.New("volume name or mount path not available")
+
+	// backups
+	ErrBackupsNotConfigured   = errors.New("backups not configured")
+	ErrBackupsNotSupported    = errors.New("backups not supported by the template")
+	ErrInvalidBackupSchedule  = errors.New("invalid backup schedule")
+	ErrBackupNotFound         = errors.New("backup not found")
+	ErrBackupNotRestorable    = errors.New("backup not restorable")
+	ErrBackupTemplateMismatch = errors.New("backup template mismatch")
+
+	// scheduled jobs and tasks
+	ErrInvalidJobName            = errors.New("invalid job name")
+	ErrInvalidJobSchedule        = errors.New("invalid job schedule")
+	ErrInvalidJobCommand         = errors.New("invalid job command")
+	ErrJobNameNotAvailable       = errors.New("job name not available")
+	ErrScheduledJobNotFound      = errors.New("scheduled job not found")
+	ErrJobRunNotFound            = errors.New("job run not found")
+	ErrScheduledJobsLimitReached = errors.New("scheduled jobs limit reached")
+
+	// exec
+	ErrExecDisabled   = errors.New("exec disabled")
+	ErrExecNotAllowed = errors.New("exec not allowed")
+
+	// tunnels
+	ErrTunnelDisabled     = errors.New("tunnel disabled")
+	ErrTunnelNotSupported = errors.New("tunnel not supported by the application kind")
+
+	// metrics
+	ErrMetricsNotAvailable = errors.New("metrics not available")
+	ErrInvalidMetricsRange = errors.New("invalid metrics range")
+
+	// notifications
+	ErrNotificationsDisabled            = errors.New("notifications disabled")
+	ErrNotificationChannelNotSupported  = errors.New("notification channel not supported")
+	ErrInvalidNotificationTarget        = errors.New("invalid notification target")
+	ErrInvalidNotificationEvent         = errors.New("invalid notification event")
+	ErrNotificationChannelNotFound      = errors.New("notification channel not found")
+	ErrNotificationChannelsLimitReached = errors.New("notification channels limit reached")
+	ErrNotificationFailed               = errors.New("notification failed")
+
+	// stacks
+	ErrStackTemplateNotFound  = errors.New("stack template not found")
+	ErrInvalidStackTemplate   = errors.New("invalid stack template")
+	ErrInvalidStackName       = errors.New("invalid stack name")
+	ErrStackNotFound          = errors.New("stack not found")
+	ErrStackComponentFailed   = errors.New("stack component failed to start")
+	ErrApplicationPartOfStack = errors.New("application is part of a stack")
 
 	// build
 	ErrInvalidBuildPlan      = errors.New("invalid build plan")
@@ -32,6 +108,12 @@ var (
 
 	//templates errors
 	ErrMissingRequiredEnvForTemplate = errors.New("missing required env for template")
+	ErrInvalidGeneratedEnv           = errors.New("invalid generated env")
+	ErrTemplateNotFound              = errors.New("template not found")
+	ErrInvalidTemplate               = errors.New("invalid template")
+	ErrTemplateCodeNotAvailable      = errors.New("template code not available")
+	ErrTemplateDeprecated            = errors.New("template deprecated")
+	ErrTemplateVersionNotNewer       = errors.New("template version not newer than the current one")
 
 	//image builder errors
 	ErrInvalidOperationInCurrentState = errors.New("invalid operation in current state")
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// tries bash before falling back to sh since not every image ships it
+var execShellCommand = []string{"/bin/sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
+
+// checks if the user can open a shell in the application, only the owner
+// and the admins can, the application must have a running pod
+func (c *Controller) CanExecApplication(app *model.Application, user *model.User) error {
+	if !c.config.Exec.Enabled {
+		return ErrExecDisabled
+	}
+	if app.Owner != user.Code && user.Role != model.RoleAdmin {
+		return ErrExecNotAllowed
+	}
+	if app.State != model.ApplicationStateRunning {
+		return ErrInvalidOperationInCurrentState
+	}
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.CurrentPodName == "" {
+		return ErrInvalidOperationInCurrentState
+	}
+	return nil
+}
+
+// opens an interactive shell in the current pod of the application attaching
+// the streams, blocks until the shell exits, the context is cancelled or the
+// session lasts more than the configured max duration.
+// The start and the end of the session are recorded in the audit log
+func (c *Controller) ExecApplication(ctx context.Context, app *model.Application, user *model.User, streams model.ExecStreams) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "ExecApplication",
+	}
+
+	if err := c.CanExecApplication(app, user); err != nil {
+		return err
+	}
+
+	//when an admin opens the shell the pod is in the namespace of the owner
+	owner := user
+	if app.Owner != user.Code {
+		var err error
+		owner, err = c.UserRepo.FindByCode(ctx, app.Owner)
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error finding owner %s: %v", app.Owner, err)
+			return err
+		}
+	}
+
+	podName := app.Service.Deployment.CurrentPodName
+	ctx, cancel := context.WithTimeout(ctx, c.config.Exec.MaxDuration)
+	defer cancel()
+
+	c.recordAuditEvent(ctx, user, app, model.AuditActionExecSessionStarted, podName)
+	c.l.WithFields(fields).Infof("exec session in pod %s of application %s started", podName, app.Name)
+
+	start := time.Now()
+	streams.Tty = true
+	err := c.ServiceManager.ExecInPod(ctx, owner.Namespace, podName, "", execShellCommand, streams)
+
+	details := fmt.Sprintf("duration: %s", time.Since(start).Round(time.Second))
+	if err != nil {
+		details += fmt.Sprintf(", error: %v", err)
+	}
+	//the request context might be already done when the client disconnects
+	c.insertAuditEvent(context.Background(), &model.AuditEvent{
+		CreatedAt:     time.Now(),
+		UserCode:      user.Code,
+		ApplicationID: app.ID,
+		Action:        model.AuditActionExecSessionEnded,
+		Target:        podName,
+		Details:       details,
+	})
+
+	if err != nil {
+		c.l.WithFields(fields).Errorf("exec session in pod %s ended with error: %v", podName, err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("exec session in pod %s of application %s ended", podName, app.Name)
+	return nil
+}
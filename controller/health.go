@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// default probes only check that the listening port accepts tcp connections,
+// the startup probe gives the application up to 2 minutes to start listening.
+// Applications without a listening port (ex: workers) have no default probes
+func defaultHealthChecks(listeningPort string) *model.HealthChecks {
+	if listeningPort == "" {
+		return &model.HealthChecks{}
+	}
+	return &model.HealthChecks{
+		Startup: &model.Probe{
+			Kind:             model.ProbeKindTCP,
+			PeriodSeconds:    5,
+			TimeoutSeconds:   1,
+			FailureThreshold: 24,
+		},
+		Readiness: &model.Probe{
+			Kind:             model.ProbeKindTCP,
+			PeriodSeconds:    10,
+			TimeoutSeconds:   1,
+			FailureThreshold: 3,
+		},
+		Liveness: &model.Probe{
+			Kind:             model.ProbeKindTCP,
+			PeriodSeconds:    20,
+			TimeoutSeconds:   1,
+			FailureThreshold: 3,
+		},
+	}
+}
+
+func validateProbe(probe *model.Probe) error {
+	if probe == nil {
+		return nil
+	}
+	switch probe.Kind {
+	case model.ProbeKindHTTP, model.ProbeKindTCP:
+		if probe.Port != "" {
+			port, err := strconv.Atoi(probe.Port)
+			if err != nil || port < 0 || port > 65535 {
+				return ErrInvalidHealthCheck
+			}
+		}
+	case model.ProbeKindExec:
+		if len(probe.Command) == 0 {
+			return ErrInvalidHealthCheck
+		}
+	default:
+		return ErrInvalidHealthCheck
+	}
+	if probe.InitialDelaySeconds < 0 ||
+		probe.PeriodSeconds < 0 ||
+		probe.TimeoutSeconds < 0 ||
+		probe.FailureThreshold < 0 {
+		return ErrInvalidHealthCheck
+	}
+	return nil
+}
+
+func validateHealthChecks(healthChecks *model.HealthChecks) error {
+	if healthChecks == nil {
+		return nil
+	}
+	for _, probe := range []*model.Probe{healthChecks.Liveness, healthChecks.Readiness, healthChecks.Startup} {
+		if err := validateProbe(probe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workers don't listen on a port, so http and tcp probes must set one
+func validateWorkerHealthChecks(healthChecks *model.HealthChecks) error {
+	if err := validateHealthChecks(healthChecks); err != nil {
+		return err
+	}
+	for _, probe := range []*model.Probe{healthChecks.Liveness, healthChecks.Readiness, healthChecks.Startup} {
+		if probe != nil && probe.Kind != model.ProbeKindExec && probe.Port == "" {
+			return ErrInvalidHealthCheck
+		}
+	}
+	return nil
+}
+
+func (c *Controller) UpdateApplicationHealthChecks(ctx context.Context, app *model.Application, user *model.User, healthChecks *model.HealthChecks) error {
+	if app.State != model.ApplicationStateRunning &&
+		app.State != model.ApplicationStateFailed &&
+		app.State != model.ApplicationStateCrashed {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	if app.Service == nil || app.Service.Deployment == nil {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	fields := make(logrus.Fields)
+	fields["applicationID"] = app.ID.Hex()
+	fields["userID"] = user.Code
+	fields["action"] = "UpdateApplicationHealthChecks"
+
+	if healthChecks == nil {
+		healthChecks = defaultHealthChecks(app.ListeningPort)
+	}
+	validate := validateHealthChecks
+	if app.Kind == model.ApplicationKindWorker {
+		validate = validateWorkerHealthChecks
+	}
+	if err := validate(healthChecks); err != nil {
+		return err
+	}
+	app.HealthChecks = healthChecks
+
+	updatedDeployment, err := c.ServiceManager.UpdateDeployment(
+		ctx,
+		user.Namespace,
+		app.Service.Deployment.Name,
+		app.Service.Deployment.ImageRegistry,
+		app.Service.Deployment.Replicas,
+		app.Service.Deployment.Port,
+		app.Service.Deployment.Labels,
+		nil,
+		app.HealthChecks)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error updating deployment %s: %v", app.Service.Deployment.Name, err)
+		return convertServiceManagerError(err)
+	}
+	updatedDeployment.ConfigMap = app.Service.Deployment.ConfigMap
+	updatedDeployment.Secret = app.Service.Deployment.Secret
+	updatedDeployment.Volume = app.Service.Deployment.Volume
+	//the new probes change the pod template, the deployment is already rolling
+	//to new pods and the current one is chosen again by the container events
+	updatedDeployment.CurrentPodName = ""
+	app.Service.Deployment = updatedDeployment
+	app.Health = model.ApplicationHealthUnknown
+	if err := c.updateApplication(ctx, app); err != nil {
+		c.l.WithFields(fields).Errorf("error saving health checks: %v", err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("application health checks updated succesfully")
+	return nil
+}
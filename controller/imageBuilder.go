@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
 )
 
 // send request to image builder, to build a specific commit use the commit hash, leave blank
@@ -29,13 +30,14 @@ func (c *Controller) BuildImage(ctx context.Context, app *model.Application, com
 
 	c.l.Debugf("sending to rmq: %+v ", request)
 	if err := c.imageBuilder.BuildImage(ctx, request); err != nil {
+		metrics.BuildRequests.WithLabelValues(metrics.BuildRequestFailed).Inc()
 		c.l.Errorf("error sending image to image builder: %v", err)
-		app.State = model.ApplicationStateFailed
-		if err := c.updateApplication(ctx, app); err != nil {
+		if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, "unable to send the build request", model.StateTransitionActorImageBuilder); err != nil {
 			c.l.Errorf("error updating application state: %v", err)
 			return err
 		}
 		return err
 	}
+	metrics.BuildRequests.WithLabelValues(metrics.BuildRequestSent).Inc()
 	return nil
 }
@@ -0,0 +1,397 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	"github.com/sirupsen/logrus"
+)
+
+// returns the in cluster dns name of the application service
+func serviceDNSName(app *model.Application, user *model.User) string {
+	serviceName := app.Name
+	if app.Service != nil {
+		serviceName = app.Service.Name
+	}
+	return fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, user.Namespace)
+}
+
+func linkSecretName(app, storage *model.Application) string {
+	return fmt.Sprintf("link-%s-%s", app.Name, storage.ID.Hex())
+}
+
+// builds the connection url of a storage application from the envs of its template,
+// the host is the in cluster dns name of the service so it's only reachable by
+// applications in the same cluster
+func (c *Controller) storageConnectionURL(ctx context.Context, storage *model.Application, user *model.User) (string, error) {
+	template, err := c.TemplateRepo.FindByCodeAndVersion(ctx, storage.BasedOn, storage.TemplateVersion)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return "", ErrApplicationNotLinkable
+		}
+		c.l.Errorf("error finding template %s: %v", storage.BasedOn, err)
+		return "", err
+	}
+	if template.Link == nil {
+		return "", ErrApplicationNotLinkable
+	}
+
+	secrets, err := c.decryptSecrets(storage.Secrets)
+	if err != nil {
+		return "", err
+	}
+	envs := convertModelKeyValueToMap(append(append([]model.KeyValue{}, storage.Envs...), secrets...))
+
+	u := &url.URL{
+		Scheme: template.Link.Scheme,
+		Host:   fmt.Sprintf("%s:%s", serviceDNSName(storage, user), storage.ListeningPort),
+	}
+
+	username := template.Link.DefaultUser
+	if template.Link.UserEnv != "" && envs[template.Link.UserEnv] != "" {
+		username = envs[template.Link.UserEnv]
+	}
+	password := ""
+	if template.Link.PasswordEnv != "" {
+		password = envs[template.Link.PasswordEnv]
+	}
+	switch {
+	case username != "" && password != "":
+		u.User = url.UserPassword(username, password)
+	case username != "":
+		u.User = url.User(username)
+	case password != "":
+		//redis like storages only use a password
+		u.User = url.UserPassword("", password)
+	}
+	if template.Link.DatabaseEnv != "" && envs[template.Link.DatabaseEnv] != "" {
+		u.Path = "/" + envs[template.Link.DatabaseEnv]
+	}
+	return u.String(), nil
+}
+
+// returns the default env name of the link, it's defined by the template of the storage
+func (c *Controller) defaultLinkEnvName(ctx context.Context, storage *model.Application) (string, error) {
+	template, err := c.TemplateRepo.FindByCodeAndVersion(ctx, storage.BasedOn, storage.TemplateVersion)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return "", ErrApplicationNotLinkable
+		}
+		c.l.Errorf("error finding template %s: %v", storage.BasedOn, err)
+		return "", err
+	}
+	if template.Link == nil {
+		return "", ErrApplicationNotLinkable
+	}
+	return template.Link.EnvName, nil
+}
+
+// links a storage application to a web or worker application, the connection url is stored
+// in a secret loaded by the web application as the env envName (if empty the
+// default one of the storage template is used)
+func (c *Controller) LinkApplications(ctx context.Context, user *model.User, app, storage *model.Application, envName string) error {
+	if !app.Kind.IsBuiltFromRepo() {
+		return ErrInvalidOperationWithCurrentKind
+	}
+	return c.linkApplications(ctx, user, app, storage, envName)
+}
+
+// links any application to a storage one, the applications of a stack can be
+// managment ones (ex: a dashboard of the stack database)
+func (c *Controller) linkApplications(ctx context.Context, user *model.User, app, storage *model.Application, envName string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"storageID":     storage.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "LinkApplications",
+	}
+
+	if storage.Kind != model.ApplicationKindStorage {
+		return ErrInvalidOperationWithCurrentKind
+	}
+	if app.State == model.ApplicationStateDeleting || storage.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+	for _, link := range app.Links {
+		if link.ApplicationID == storage.ID {
+			return ErrApplicationsAlreadyLinked
+		}
+	}
+
+	if envName == "" {
+		var err error
+		envName, err = c.defaultLinkEnvName(ctx, storage)
+		if err != nil {
+			return err
+		}
+	}
+	if !secretKeyRegex.MatchString(envName) {
+		return ErrInvalidLinkEnv
+	}
+	for _, link := range app.Links {
+		if link.EnvName == envName {
+			return ErrInvalidLinkEnv
+		}
+	}
+
+	connectionURL, err := c.storageConnectionURL(ctx, storage, user)
+	if err != nil {
+		return err
+	}
+
+	secretName := linkSecretName(app, storage)
+	if err := c.createOrUpdateLinkSecret(ctx, user, app, secretName, []model.KeyValue{{Key: envName, Value: connectionURL}}); err != nil {
+		c.l.WithFields(fields).Errorf("error creating link secret %s: %v", secretName, err)
+		return err
+	}
+
+	now := time.Now()
+	if err := c.modifyApplication(ctx, storage, func(storage *model.Application) error {
+		if storage.State == model.ApplicationStateDeleting {
+			return ErrInvalidOperationInCurrentState
+		}
+		removeLinkTo(storage, app)
+		storage.LinkedBy = append(storage.LinkedBy, model.ApplicationLink{
+			ApplicationID:   app.ID,
+			ApplicationName: app.Name,
+			EnvName:         envName,
+			SecretName:      secretName,
+			CreatedAt:       now,
+		})
+		return nil
+	}); err != nil {
+		c.deleteLinkSecret(ctx, user, secretName)
+		return err
+	}
+
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		if app.State == model.ApplicationStateDeleting {
+			return ErrInvalidOperationInCurrentState
+		}
+		for _, link := range app.Links {
+			if link.ApplicationID == storage.ID {
+				return ErrApplicationsAlreadyLinked
+			}
+			if link.EnvName == envName {
+				return ErrInvalidLinkEnv
+			}
+		}
+		app.Links = append(app.Links, model.ApplicationLink{
+			ApplicationID:   storage.ID,
+			ApplicationName: storage.Name,
+			EnvName:         envName,
+			SecretName:      secretName,
+			CreatedAt:       now,
+		})
+		return nil
+	}); err != nil {
+		//the applications were linked by a concurrent request using the same secret
+		if err == ErrApplicationsAlreadyLinked {
+			return err
+		}
+		//the deployment doesn't reference the secret yet
+		if err := c.modifyApplication(ctx, storage, func(storage *model.Application) error {
+			removeLinkTo(storage, app)
+			return nil
+		}); err != nil {
+			c.l.WithFields(fields).Errorf("error removing link from storage: %v", err)
+		}
+		c.deleteLinkSecret(ctx, user, secretName)
+		return err
+	}
+
+	if err := c.syncApplicationEnvSources(ctx, app, user); err != nil {
+		c.l.WithFields(fields).Errorf("error applying link to application, removing it: %v", err)
+		if err := c.UnlinkApplications(ctx, user, app, storage); err != nil {
+			c.l.WithFields(fields).Errorf("error removing link: %v", err)
+		}
+		return err
+	}
+	c.l.WithFields(fields).Infof("application %s linked to storage %s as %s", app.Name, storage.Name, envName)
+	return nil
+}
+
+// the secret of a link that failed to be saved is reused, so that the
+// applications can be linked again
+func (c *Controller) createOrUpdateLinkSecret(ctx context.Context, user *model.User, app *model.Application, secretName string, data []model.KeyValue) error {
+	_, err := c.ServiceManager.GetSecret(ctx, user.Namespace, secretName)
+	if err == nil {
+		_, err = c.ServiceManager.UpdateSecret(ctx, user.Namespace, secretName, data)
+		return err
+	}
+	if !errors.Is(err, serviceManager.ErrResourceNotFound) {
+		return err
+	}
+	_, err = c.ServiceManager.CreateNewSecret(ctx, user.Namespace, secretName, data, c.filledDefaultLabels(user, app, secretName))
+	return err
+}
+
+// failing to delete the secret of a link that was not saved is only logged,
+// it's reused if the applications are linked again
+func (c *Controller) deleteLinkSecret(ctx context.Context, user *model.User, secretName string) {
+	if err := c.ServiceManager.DeleteSecret(ctx, user.Namespace, secretName, gracePeriod); err != nil {
+		c.l.Errorf("error deleting link secret %s: %v", secretName, err)
+	}
+}
+
+// the link is removed from the application first and from the storage once the
+// deployment stopped loading its secret, so that a failed unlink can be run again
+func (c *Controller) UnlinkApplications(ctx context.Context, user *model.User, app, storage *model.Application) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"storageID":     storage.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "UnlinkApplications",
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+	secretName := ""
+	for _, link := range app.Links {
+		if link.ApplicationID == storage.ID {
+			secretName = link.SecretName
+			break
+		}
+	}
+	//a failed unlink may have already removed the link from the application
+	if secretName == "" {
+		for _, link := range storage.LinkedBy {
+			if link.ApplicationID == app.ID {
+				secretName = link.SecretName
+				break
+			}
+		}
+	}
+	if secretName == "" {
+		return ErrApplicationsNotLinked
+	}
+
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		removeLinkTo(app, storage)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := c.syncApplicationEnvSources(ctx, app, user); err != nil {
+		c.l.WithFields(fields).Errorf("error removing link from application: %v", err)
+		return err
+	}
+	if err := c.modifyApplication(ctx, storage, func(storage *model.Application) error {
+		removeLinkTo(storage, app)
+		return nil
+	}); err != nil {
+		c.l.WithFields(fields).Errorf("error removing link from storage: %v", err)
+		return err
+	}
+	//the secret is deleted after the deployment stopped referencing it
+	if err := c.ServiceManager.DeleteSecret(ctx, user.Namespace, secretName, gracePeriod); err != nil {
+		c.l.WithFields(fields).Errorf("error deleting link secret %s: %v", secretName, err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("application %s unlinked from storage %s", app.Name, storage.Name)
+	return nil
+}
+
+func removeLinkTo(app, other *model.Application) {
+	for i := range app.LinkedBy {
+		if app.LinkedBy[i].ApplicationID == other.ID {
+			app.LinkedBy = append(app.LinkedBy[:i], app.LinkedBy[i+1:]...)
+			break
+		}
+	}
+	for i := range app.Links {
+		if app.Links[i].ApplicationID == other.ID {
+			app.Links = append(app.Links[:i], app.Links[i+1:]...)
+			break
+		}
+	}
+}
+
+// regenerates the connection url of every application linked to the storage and
+// restarts them to load it. A failing application does not stop the others
+func (c *Controller) syncStorageLinks(ctx context.Context, user *model.User, storage *model.Application) {
+	if len(storage.LinkedBy) == 0 {
+		return
+	}
+	connectionURL, err := c.storageConnectionURL(ctx, storage, user)
+	if err != nil {
+		c.l.Errorf("error building connection url of storage %s: %v", storage.ID.Hex(), err)
+		return
+	}
+	for _, link := range storage.LinkedBy {
+		if _, err := c.ServiceManager.UpdateSecret(ctx, user.Namespace, link.SecretName, []model.KeyValue{{Key: link.EnvName, Value: connectionURL}}); err != nil {
+			c.l.Errorf("error updating link secret %s: %v", link.SecretName, err)
+			continue
+		}
+		app, err := c.ApplicationRepo.FindByID(ctx, link.ApplicationID)
+		if err != nil {
+			c.l.Errorf("error finding linked application %s: %v", link.ApplicationID.Hex(), err)
+			continue
+		}
+		if app.Service == nil || app.Service.Deployment == nil ||
+			app.State == model.ApplicationStateDeleting {
+			continue
+		}
+		c.l.Infof("restarting application %s to apply changes of linked storage %s", app.Name, storage.Name)
+		if err := c.RedeployApplication(ctx, user, app); err != nil {
+			c.l.Errorf("error restarting application %s after storage update: %v", app.ID.Hex(), err)
+		}
+	}
+}
+
+// removes the links of an application that is being deleted from both sides,
+// the applications using a deleted storage are updated to stop loading its secret
+func (c *Controller) removeApplicationLinks(ctx context.Context, user *model.User, app *model.Application) error {
+	for _, link := range app.Links {
+		storage, err := c.ApplicationRepo.FindByID(ctx, link.ApplicationID)
+		if err != nil {
+			if err == repo.ErrNotFound {
+				continue
+			}
+			c.l.Errorf("error finding linked storage %s: %v", link.ApplicationID.Hex(), err)
+			return err
+		}
+		removeLinkTo(storage, app)
+		if err := c.updateApplication(ctx, storage); err != nil {
+			return err
+		}
+	}
+
+	for _, link := range app.LinkedBy {
+		linked, err := c.ApplicationRepo.FindByID(ctx, link.ApplicationID)
+		if err != nil {
+			if err == repo.ErrNotFound {
+				continue
+			}
+			c.l.Errorf("error finding linked application %s: %v", link.ApplicationID.Hex(), err)
+			return err
+		}
+		removeLinkTo(linked, app)
+		if linked.State == model.ApplicationStateDeleting {
+			if err := c.updateApplication(ctx, linked); err != nil {
+				return err
+			}
+		} else if err := c.applyApplicationEnvSources(ctx, linked, user); err != nil {
+			c.l.Errorf("error removing link from application %s: %v", linked.ID.Hex(), err)
+			return err
+		}
+	}
+
+	links := append(append([]model.ApplicationLink{}, app.Links...), app.LinkedBy...)
+	for _, link := range links {
+		if err := c.ServiceManager.DeleteSecret(ctx, user.Namespace, link.SecretName, gracePeriod); err != nil {
+			c.l.Errorf("error deleting link secret %s: %v", link.SecretName, err)
+			return err
+		}
+	}
+	app.Links = nil
+	app.LinkedBy = nil
+	return nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// the only component the envs of a template target can reference
+const targetReferenceComponent = "target"
+
+// creates a managment application bound to a storage one, the envs of the template
+// target are filled with the service host and credentials of the storage. The
+// application is private unless public is set and it's deleted with the storage
+func (c *Controller) CreateManagmentApplication(ctx context.Context, userCode, name string, template *model.Template, envs []model.KeyValue, target *model.Application, public bool) (*model.Application, []model.KeyValue, error) {
+	fields := logrus.Fields{
+		"userID":   userCode,
+		"targetID": target.ID.Hex(),
+		"action":   "CreateManagmentApplication",
+		"template": template.Code,
+	}
+
+	if template.Kind != model.ApplicationKindManagment || template.Target == nil ||
+		target.Kind != model.ApplicationKindStorage {
+		return nil, nil, ErrInvalidOperationWithCurrentKind
+	}
+	if template.Deprecated {
+		return nil, nil, ErrTemplateDeprecated
+	}
+	if target.State != model.ApplicationStateRunning {
+		return nil, nil, ErrInvalidOperationInCurrentState
+	}
+	compatible := false
+	for _, code := range template.Target.Templates {
+		if code == target.BasedOn {
+			compatible = true
+			break
+		}
+	}
+	if !compatible {
+		return nil, nil, ErrIncompatibleTarget
+	}
+
+	user, err := c.UserRepo.FindByCode(ctx, userCode)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error finding user by code: %v", err)
+		return nil, nil, err
+	}
+	if err := c.checkApplicationQuota(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	values, err := c.applicationReferenceValues(target, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	//the user envs take precedence over the ones of the target
+	targetEnvs := resolveEnvReferences(template.Target.Envs, map[string]map[string]string{targetReferenceComponent: values})
+	app, generated, err := c.createTemplateApplication(ctx, user, name, template, mergeEnvs(targetEnvs, envs), templateApplicationOptions{
+		TargetID: target.ID,
+		Ingress:  public,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	c.l.WithFields(fields).Infof("managment application %s bound to storage %s", app.Name, target.Name)
+	return app, generated, nil
+}
+
+// deletes the managment applications bound to a storage that is being deleted
+func (c *Controller) deleteTargetingApplications(ctx context.Context, user *model.User, target *model.Application) error {
+	if target.Kind != model.ApplicationKindStorage {
+		return nil
+	}
+	apps, err := c.ApplicationRepo.FindByTargetID(ctx, target.ID)
+	if err != nil {
+		c.l.Errorf("error finding applications bound to %s: %v", target.ID.Hex(), err)
+		return err
+	}
+	for _, app := range apps {
+		if app.State == model.ApplicationStateDeleting {
+			continue
+		}
+		c.l.Infof("deleting managment application %s bound to deleted storage %s", app.Name, target.Name)
+		if err := c.deleteApplication(ctx, app, user); err != nil {
+			c.l.Errorf("error deleting managment application %s: %v", app.ID.Hex(), err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// returns the time series of the application between from and to, the
+// step can't be less than a second and the series can't have more than
+// the configured max points
+func (c *Controller) GetApplicationStats(ctx context.Context, app *model.Application, user *model.User, from, to time.Time, step time.Duration) (*model.ApplicationMetrics, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "GetApplicationStats",
+	}
+
+	if c.metricsProvider == nil {
+		return nil, ErrMetricsNotAvailable
+	}
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.Name == "" {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+	if !from.Before(to) || step < time.Second || int(to.Sub(from)/step) > c.config.MetricsProvider.MaxPoints {
+		return nil, ErrInvalidMetricsRange
+	}
+
+	target := model.MetricsTarget{
+		Namespace:      user.Namespace,
+		DeploymentName: app.Service.Deployment.Name,
+		ServiceName:    app.Service.Name,
+	}
+	metrics, err := c.metricsProvider.GetMetrics(ctx, target, from, to, step)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error getting metrics: %v", err)
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// must be called only the first time the application reaches the running
+// state, later deployments are not measured from the creation
+func observeApplicationStartup(app *model.Application) {
+	metrics.ApplicationStartupSeconds.WithLabelValues(app.Kind.String()).Observe(time.Since(app.CreatedAt).Seconds())
+}
+
+func (c *Controller) CountApplications(ctx context.Context) ([]*model.ApplicationCount, error) {
+	counts, err := c.ApplicationRepo.CountByKindAndState(ctx)
+	if err != nil {
+		c.l.Errorf("error counting applications: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
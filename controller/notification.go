@@ -0,0 +1,388 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/ipaas-org/ipaas-backend/services/notifier/webhook"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the entries of the limiter not used for this long are evicted, with the
+// count of the notifications suppressed since the last one
+const notificationLimiterRetention = 24 * time.Hour
+
+// keeps in memory when the last notification of every channel and event was
+// sent, the ones in between are counted and reported with the next one
+type notificationLimiter struct {
+	mu         sync.Mutex
+	interval   time.Duration
+	retention  time.Duration
+	swept      time.Time
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newNotificationLimiter(interval time.Duration) *notificationLimiter {
+	retention := notificationLimiterRetention
+	if interval > retention {
+		retention = interval
+	}
+	return &notificationLimiter{
+		interval:   interval,
+		retention:  retention,
+		swept:      time.Now(),
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+func notificationLimiterKey(channelID primitive.ObjectID, event model.NotificationEvent) string {
+	return channelID.Hex() + "/" + event.String()
+}
+
+// returns if the notification can be sent and how many were suppressed since the last one
+func (l *notificationLimiter) allow(key string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	if last, ok := l.last[key]; ok && now.Sub(last) < l.interval {
+		l.suppressed[key]++
+		return false, 0
+	}
+	suppressed := l.suppressed[key]
+	l.last[key] = now
+	delete(l.suppressed, key)
+	return true, suppressed
+}
+
+// removes the entries of the deleted channel
+func (l *notificationLimiter) forget(channelID primitive.ObjectID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prefix := channelID.Hex() + "/"
+	for key := range l.last {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.last, key)
+			delete(l.suppressed, key)
+		}
+	}
+}
+
+// evicts the entries older than the retention, at most once every retention
+func (l *notificationLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.retention {
+		return
+	}
+	l.swept = now
+	for key, last := range l.last {
+		if now.Sub(last) >= l.retention {
+			delete(l.last, key)
+			delete(l.suppressed, key)
+		}
+	}
+}
+
+func validNotificationEvent(event model.NotificationEvent) bool {
+	switch event {
+	case model.NotificationEventApplicationCrashed,
+		model.NotificationEventBuildFailed,
+		model.NotificationEventRolloutFailed:
+		return true
+	}
+	return false
+}
+
+func validateNotificationEvents(events []model.NotificationEvent) error {
+	if len(events) == 0 {
+		return ErrInvalidNotificationEvent
+	}
+	for _, event := range events {
+		if !validNotificationEvent(event) {
+			return ErrInvalidNotificationEvent
+		}
+	}
+	return nil
+}
+
+// email channels need a plain address, the webhooks a public https url
+// (http is allowed only for the generic webhook)
+func validateNotificationTarget(kind model.NotificationChannelKind, target string) error {
+	if kind == model.NotificationChannelKindEmail {
+		address, err := mail.ParseAddress(target)
+		if err != nil || address.Address != target {
+			return ErrInvalidNotificationTarget
+		}
+		return nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return ErrInvalidNotificationTarget
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && kind == model.NotificationChannelKindWebhook) {
+		return ErrInvalidNotificationTarget
+	}
+	//the host names are checked on the resolved address when the notification is sent
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidNotificationTarget
+	}
+	if ip := net.ParseIP(host); ip != nil && !webhook.IsPublicIP(ip) {
+		return ErrInvalidNotificationTarget
+	}
+	return nil
+}
+
+// the target is shown masked since webhook urls contain their credentials
+func maskNotificationTarget(kind model.NotificationChannelKind, target string) string {
+	if kind == model.NotificationChannelKindEmail {
+		local, domain, _ := strings.Cut(target, "@")
+		if len(local) > 1 {
+			local = local[:1] + "***"
+		}
+		return local + "@" + domain
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "***"
+	}
+	return fmt.Sprintf("%s://%s/***", u.Scheme, u.Host)
+}
+
+func (c *Controller) ListNotificationChannels(ctx context.Context, app *model.Application) ([]*model.NotificationChannel, error) {
+	channels, err := c.NotificationChannelRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding notification channels of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (c *Controller) GetNotificationChannel(ctx context.Context, app *model.Application, channelID primitive.ObjectID) (*model.NotificationChannel, error) {
+	channel, err := c.NotificationChannelRepo.FindByID(ctx, channelID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrNotificationChannelNotFound
+		}
+		c.l.Errorf("error finding notification channel %s: %v", channelID.Hex(), err)
+		return nil, err
+	}
+	if channel.ApplicationID != app.ID {
+		return nil, ErrNotificationChannelNotFound
+	}
+	return channel, nil
+}
+
+func (c *Controller) CreateNotificationChannel(ctx context.Context, app *model.Application, user *model.User, kind model.NotificationChannelKind, target string, events []model.NotificationEvent) (*model.NotificationChannel, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "CreateNotificationChannel",
+	}
+
+	if !c.config.Notifications.Enabled {
+		return nil, ErrNotificationsDisabled
+	}
+	if _, ok := c.notifiers[kind]; !ok {
+		return nil, ErrNotificationChannelNotSupported
+	}
+	if err := validateNotificationTarget(kind, target); err != nil {
+		return nil, err
+	}
+	if err := validateNotificationEvents(events); err != nil {
+		return nil, err
+	}
+
+	channels, err := c.NotificationChannelRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error finding notification channels: %v", err)
+		return nil, err
+	}
+	if len(channels) >= c.config.Notifications.MaxChannelsPerApplication {
+		return nil, ErrNotificationChannelsLimitReached
+	}
+
+	encrypted, err := c.encrypter.Encrypt(target)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error encrypting notification target: %v", err)
+		return nil, err
+	}
+	channel := &model.NotificationChannel{
+		ID:            primitive.NewObjectID(),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		ApplicationID: app.ID,
+		Owner:         user.Code,
+		Kind:          kind,
+		Target:        encrypted,
+		MaskedTarget:  maskNotificationTarget(kind, target),
+		Events:        events,
+		Enabled:       true,
+	}
+	if _, err := c.NotificationChannelRepo.InsertOne(ctx, channel); err != nil {
+		c.l.WithFields(fields).Errorf("error inserting notification channel: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("%s notification channel added to application %s", kind, app.Name)
+	return channel, nil
+}
+
+// empty target and events are not updated
+func (c *Controller) UpdateNotificationChannel(ctx context.Context, app *model.Application, user *model.User, channel *model.NotificationChannel, target string, events []model.NotificationEvent, enabled *bool) (*model.NotificationChannel, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "UpdateNotificationChannel",
+		"channelID":     channel.ID.Hex(),
+	}
+
+	if target == "" && events == nil && enabled == nil {
+		return nil, ErrNoChanges
+	}
+	if target != "" {
+		if err := validateNotificationTarget(channel.Kind, target); err != nil {
+			return nil, err
+		}
+		encrypted, err := c.encrypter.Encrypt(target)
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error encrypting notification target: %v", err)
+			return nil, err
+		}
+		channel.Target = encrypted
+		channel.MaskedTarget = maskNotificationTarget(channel.Kind, target)
+	}
+	if events != nil {
+		if err := validateNotificationEvents(events); err != nil {
+			return nil, err
+		}
+		channel.Events = events
+	}
+	if enabled != nil {
+		channel.Enabled = *enabled
+	}
+
+	channel.UpdatedAt = time.Now()
+	if _, err := c.NotificationChannelRepo.UpdateByID(ctx, channel, channel.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error updating notification channel: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("notification channel of application %s updated", app.Name)
+	return channel, nil
+}
+
+func (c *Controller) DeleteNotificationChannel(ctx context.Context, app *model.Application, user *model.User, channel *model.NotificationChannel) error {
+	if _, err := c.NotificationChannelRepo.DeleteByID(ctx, channel.ID); err != nil {
+		c.l.Errorf("error deleting notification channel %s: %v", channel.ID.Hex(), err)
+		return err
+	}
+	c.notificationLimiter.forget(channel.ID)
+	c.l.Infof("notification channel %s of application %s deleted by %s", channel.ID.Hex(), app.Name, user.Code)
+	return nil
+}
+
+// sends a test notification ignoring the rate limit, the error of the channel is returned
+func (c *Controller) TestNotificationChannel(ctx context.Context, app *model.Application, channel *model.NotificationChannel) error {
+	if !c.config.Notifications.Enabled {
+		return ErrNotificationsDisabled
+	}
+	notification := &model.Notification{
+		Event:           model.NotificationEventTest,
+		ApplicationID:   app.ID,
+		ApplicationName: app.Name,
+		Message:         "the notification channel is working",
+		CreatedAt:       time.Now(),
+	}
+	if err := c.sendNotification(ctx, channel, notification); err != nil {
+		c.l.Errorf("error sending test notification on channel %s: %v", channel.ID.Hex(), err)
+		return ErrNotificationFailed
+	}
+	return nil
+}
+
+func (c *Controller) sendNotification(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error {
+	notifier, ok := c.notifiers[channel.Kind]
+	if !ok {
+		return ErrNotificationChannelNotSupported
+	}
+	target, err := c.encrypter.Decrypt(channel.Target)
+	if err != nil {
+		return fmt.Errorf("error decrypting target: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.Notifications.Timeout)
+	defer cancel()
+	return notifier.Notify(ctx, target, notification)
+}
+
+// sends the event to the enabled channels of the application subscribed to
+// it, the notifications are sent in background so the caller is not slowed
+// down by the channels
+func (c *Controller) NotifyApplicationEvent(app *model.Application, event model.NotificationEvent, message string) {
+	if !c.config.Notifications.Enabled {
+		return
+	}
+	go c.notifyApplicationEvent(context.Background(), app.ID, app.Name, event, message)
+}
+
+func (c *Controller) notifyApplicationEvent(ctx context.Context, appID primitive.ObjectID, appName string, event model.NotificationEvent, message string) {
+	fields := logrus.Fields{
+		"applicationID": appID.Hex(),
+		"action":        "NotifyApplicationEvent",
+		"event":         event,
+	}
+
+	channels, err := c.NotificationChannelRepo.FindByApplicationID(ctx, appID)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error finding notification channels: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, channel := range channels {
+		if !channel.Enabled || !channel.HasEvent(event) {
+			continue
+		}
+		ok, suppressed := c.notificationLimiter.allow(notificationLimiterKey(channel.ID, event), now)
+		if !ok {
+			c.l.WithFields(fields).Debugf("notification on channel %s rate limited", channel.ID.Hex())
+			continue
+		}
+		notification := &model.Notification{
+			Event:           event,
+			ApplicationID:   appID,
+			ApplicationName: appName,
+			Message:         message,
+			Suppressed:      suppressed,
+			CreatedAt:       now,
+		}
+		if err := c.sendNotification(ctx, channel, notification); err != nil {
+			c.l.WithFields(fields).Errorf("error sending notification on %s channel %s: %v", channel.Kind, channel.ID.Hex(), err)
+			continue
+		}
+		c.l.WithFields(fields).Infof("notification sent on %s channel %s", channel.Kind, channel.ID.Hex())
+	}
+}
+
+func (c *Controller) deleteApplicationNotificationChannels(ctx context.Context, app *model.Application) error {
+	channels, err := c.NotificationChannelRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding notification channels of application %s: %v", app.ID.Hex(), err)
+		return err
+	}
+	for _, channel := range channels {
+		if _, err := c.NotificationChannelRepo.DeleteByID(ctx, channel.ID); err != nil {
+			c.l.Errorf("error deleting notification channel %s: %v", channel.ID.Hex(), err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipaas-org/ipaas-backend/config"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+)
+
+// returns the quota tier of the user, users without a tier (ex: created before
+// quotas were introduced) fall back to the default one
+func (c *Controller) getUserQuotaTier(user *model.User) (string, config.QuotaTier) {
+	tierName := user.QuotaTier
+	tier, ok := c.config.Quota.Tiers[tierName]
+	if !ok {
+		if tierName != "" {
+			c.l.Warnf("user %s has unknown quota tier %q, using default tier", user.Code, tierName)
+		}
+		tierName = c.config.Quota.DefaultTier
+		tier = c.config.Quota.Tiers[tierName]
+	}
+	return tierName, tier
+}
+
+// creates the resource quota and the limit range in the user namespace
+func (c *Controller) createUserQuota(ctx context.Context, user *model.User) error {
+	tierName, tier := c.getUserQuotaTier(user)
+	c.l.Debugf("creating quota with tier %s for user %s", tierName, user.Code)
+
+	hard := model.QuotaResources{
+		CPU:          tier.CPU,
+		Memory:       tier.Memory,
+		Storage:      tier.Storage,
+		Pods:         tier.Pods,
+		Applications: tier.Applications,
+	}
+	quotaLabels := c.getDefaultLabels(user.Code, staticTempEnvironment, "", "", staticResourceQuotaName)
+	if _, err := c.ServiceManager.CreateNewResourceQuota(ctx, user.Namespace, staticResourceQuotaName, hard, quotaLabels); err != nil {
+		c.l.Errorf("error creating resource quota for user %s: %v", user.Code, err)
+		return err
+	}
+
+	limitRangeLabels := c.getDefaultLabels(user.Code, staticTempEnvironment, "", "", staticLimitRangeName)
+	if _, err := c.ServiceManager.CreateNewLimitRange(ctx, user.Namespace, staticLimitRangeName,
+		c.config.K8s.CPUResource, c.config.K8s.MemoryResource,
+		tier.ContainerCPU, tier.ContainerMemory,
+		limitRangeLabels); err != nil {
+		c.l.Errorf("error creating limit range for user %s: %v", user.Code, err)
+		return err
+	}
+	return nil
+}
+
+// checks if the user can create a new application without exceeding the
+// applications limit of his tier
+func (c *Controller) checkApplicationQuota(ctx context.Context, user *model.User) error {
+	return c.checkApplicationsQuota(ctx, user, 1)
+}
+
+// same as checkApplicationQuota for count new applications (ex: the components of a stack)
+func (c *Controller) checkApplicationsQuota(ctx context.Context, user *model.User, count int) error {
+	_, tier := c.getUserQuotaTier(user)
+	if tier.Applications <= 0 {
+		return nil
+	}
+	apps, err := c.ApplicationRepo.FindByOwner(ctx, user.Code)
+	if err != nil {
+		c.l.Errorf("error finding applications of user %s: %v", user.Code, err)
+		return err
+	}
+	if int64(len(apps)+count) > tier.Applications {
+		c.l.Infof("user %s reached the applications limit (%d)", user.Code, tier.Applications)
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (c *Controller) GetUserQuotaUsage(ctx context.Context, user *model.User) (*model.QuotaUsage, error) {
+	tierName, tier := c.getUserQuotaTier(user)
+
+	//reading the usage must not change the namespace, users created before
+	//quotas have none and the limits of their tier are returned without usage
+	hard := model.QuotaResources{
+		CPU:     tier.CPU,
+		Memory:  tier.Memory,
+		Storage: tier.Storage,
+		Pods:    tier.Pods,
+	}
+	var used model.QuotaResources
+	quota, err := c.ServiceManager.GetResourceQuota(ctx, user.Namespace, staticResourceQuotaName)
+	if err != nil {
+		if !errors.Is(err, serviceManager.ErrResourceNotFound) {
+			c.l.Errorf("error getting resource quota of user %s: %v", user.Code, err)
+			return nil, err
+		}
+		c.l.Warnf("user %s has no resource quota", user.Code)
+	} else {
+		hard = quota.Hard
+		used = quota.Used
+	}
+
+	apps, err := c.ApplicationRepo.FindByOwner(ctx, user.Code)
+	if err != nil {
+		c.l.Errorf("error finding applications of user %s: %v", user.Code, err)
+		return nil, err
+	}
+
+	usage := &model.QuotaUsage{
+		Tier: tierName,
+		Hard: hard,
+		Used: used,
+	}
+	//the quota only counts deployments, the applications in the db are the source of truth
+	usage.Hard.Applications = tier.Applications
+	usage.Used.Applications = int64(len(apps))
+	return usage, nil
+}
+
+// converts service manager errors that have a meaning for the user to controller errors
+func convertServiceManagerError(err error) error {
+	if errors.Is(err, serviceManager.ErrQuotaExceeded) {
+		return ErrQuotaExceeded
+	}
+	return err
+}
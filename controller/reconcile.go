@@ -0,0 +1,391 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// state of a single reconciliation, the owners and the templates are cached
+// as most of them have more than one application
+type reconciliation struct {
+	c           *Controller
+	report      *model.ReconcileReport
+	staleBefore time.Time
+	owners      map[string]*model.User
+	templates   map[string]*model.Template
+}
+
+// compares the applications in the database with their resources in the cluster.
+// Resources of applications not in the database are deleted, the missing resources
+// of the deployed applications are recreated from the database and the applications
+// stuck in starting or deleting are failed or deleted, the rollouts left without a
+// watcher are resumed and the plain envs their template marks as secrets are
+// encrypted. Persistent volumes are never deleted nor recreated, they are only reported.
+// With dryRun nothing is changed and the report contains what would have been done
+func (c *Controller) Reconcile(ctx context.Context, dryRun bool) (*model.ReconcileReport, error) {
+	fields := logrus.Fields{
+		"action": "Reconcile",
+		"dryRun": dryRun,
+	}
+
+	r := &reconciliation{
+		c: c,
+		report: &model.ReconcileReport{
+			DryRun:    dryRun,
+			StartedAt: time.Now(),
+			Actions:   []*model.ReconcileAction{},
+		},
+		staleBefore: time.Now().Add(-c.config.Reconciler.StaleAfter),
+		owners:      make(map[string]*model.User),
+		templates:   make(map[string]*model.Template),
+	}
+
+	apps, err := c.ApplicationRepo.FindAll(ctx)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error listing applications: %v", err)
+		return nil, err
+	}
+	resources, err := c.ServiceManager.ListManagedResources(ctx)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error listing managed resources: %v", err)
+		return nil, err
+	}
+	r.report.Applications = len(apps)
+	r.report.Resources = len(resources)
+
+	resourcesByApp := make(map[string][]*model.ManagedResource)
+	for _, resource := range resources {
+		resourcesByApp[resource.ApplicationID] = append(resourcesByApp[resource.ApplicationID], resource)
+	}
+
+	for _, app := range apps {
+		r.reconcileApplication(ctx, app, resourcesByApp[app.ID.Hex()])
+		delete(resourcesByApp, app.ID.Hex())
+	}
+	for _, orphans := range resourcesByApp {
+		r.reconcileOrphans(ctx, orphans)
+	}
+
+	r.report.FinishedAt = time.Now()
+	c.l.WithFields(fields).Infof("reconciled %d applications and %d resources, %d actions", r.report.Applications, r.report.Resources, len(r.report.Actions))
+	return r.report, nil
+}
+
+// records the action in the report and applies the fix if it's not a dry run,
+// actions without a fix are only reported
+func (r *reconciliation) apply(action *model.ReconcileAction, fix func() error) {
+	r.report.Actions = append(r.report.Actions, action)
+	fields := logrus.Fields{
+		"applicationID": action.ApplicationID,
+		"action":        "Reconcile",
+		"kind":          action.Kind,
+	}
+
+	if r.report.DryRun || fix == nil {
+		r.c.l.WithFields(fields).Infof("not applied: %s", action.Reason)
+		return
+	}
+	if err := fix(); err != nil {
+		r.c.l.WithFields(fields).Errorf("error applying reconciliation (%s): %v", action.Reason, err)
+		action.Error = err.Error()
+		return
+	}
+	action.Applied = true
+	r.c.l.WithFields(fields).Infof("applied: %s", action.Reason)
+}
+
+func (r *reconciliation) owner(ctx context.Context, code string) (*model.User, error) {
+	if user, ok := r.owners[code]; ok {
+		return user, nil
+	}
+	user, err := r.c.UserRepo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	r.owners[code] = user
+	return user, nil
+}
+
+func (r *reconciliation) template(ctx context.Context, code string, version int) (*model.Template, error) {
+	key := fmt.Sprintf("%s/%d", code, version)
+	if template, ok := r.templates[key]; ok {
+		return template, nil
+	}
+	template, err := r.c.TemplateRepo.FindByCodeAndVersion(ctx, code, version)
+	if err != nil {
+		return nil, err
+	}
+	r.templates[key] = template
+	return template, nil
+}
+
+func (r *reconciliation) reconcileOrphans(ctx context.Context, orphans []*model.ManagedResource) {
+	for _, resource := range orphans {
+		//young resources may belong to an application being created
+		if resource.Terminating || resource.CreatedAt.After(r.staleBefore) {
+			continue
+		}
+		action := &model.ReconcileAction{
+			Kind:          model.ReconcileActionDeleteOrphan,
+			ApplicationID: resource.ApplicationID,
+			Resource:      resource,
+			Reason:        fmt.Sprintf("%s %s/%s belongs to an application not in the database", resource.Kind, resource.Namespace, resource.Name),
+		}
+		if resource.Kind == model.ManagedResourceKindPVC {
+			action.Reason += ", volumes are never deleted automatically"
+			r.apply(action, nil)
+			continue
+		}
+		r.apply(action, func() error {
+			return r.c.ServiceManager.DeleteManagedResource(ctx, resource, gracePeriod)
+		})
+	}
+}
+
+func (r *reconciliation) reconcileApplication(ctx context.Context, app *model.Application, resources []*model.ManagedResource) {
+	//the rollout has its own deadline, the application is updated by the
+	//container events while rolling out so it may never look stale
+	if app.State == model.ApplicationStateRollingOut {
+		r.reconcileRollout(ctx, app)
+		return
+	}
+
+	//recently changed applications may still be handled by a running operation
+	if app.UpdatedAt.After(r.staleBefore) {
+		return
+	}
+
+	switch app.State {
+	case model.ApplicationStatePending, model.ApplicationStateBuilding:
+		//the build is handled by the image builder
+		return
+	case model.ApplicationStateDeleting:
+		r.reconcileDeletingApplication(ctx, app, resources)
+		return
+	}
+
+	user, err := r.owner(ctx, app.Owner)
+	if err != nil {
+		r.c.l.Errorf("error getting owner %s of application %s while reconciling: %v", app.Owner, app.ID.Hex(), err)
+		return
+	}
+	if app.Service == nil {
+		if app.State == model.ApplicationStateStarting {
+			r.reconcileStuckApplication(ctx, app, user, resources)
+		}
+		return
+	}
+	r.reconcileApplicationResources(ctx, app, user, resources)
+	r.reconcileTemplateSecretEnvs(ctx, app, user)
+}
+
+// the watcher of the rollout was lost (restart, lost leadership) before the end of
+// the rollout, it's watched again to complete it or roll it back. Once the deadline is
+// past the deployment already has its result so the watcher returns almost immediately
+func (r *reconciliation) reconcileRollout(ctx context.Context, app *model.Application) {
+	if app.LastRollout == nil || app.LastRollout.Status != model.RolloutStatusProgressing {
+		return
+	}
+	if time.Since(app.LastRollout.StartedAt) < r.c.config.Rollout.Deadline+rolloutWaitMargin {
+		return
+	}
+	user, err := r.owner(ctx, app.Owner)
+	if err != nil {
+		r.c.l.Errorf("error getting owner %s of application %s while reconciling: %v", app.Owner, app.ID.Hex(), err)
+		return
+	}
+	r.apply(&model.ReconcileAction{
+		Kind:          model.ReconcileActionResumeRollout,
+		ApplicationID: app.ID.Hex(),
+		Reason:        fmt.Sprintf("rollout of commit %s of application %s started at %s was never completed", app.LastRollout.Commit, app.Name, app.LastRollout.StartedAt.Format(time.RFC3339)),
+	}, func() error {
+		go r.c.watchRollout(ctx, app.ID, user.Namespace)
+		return nil
+	})
+}
+
+// applications created before their template marked some envs as secrets (ex: the
+// passwords) keep them in plain text, they are moved to the secrets of the application
+func (r *reconciliation) reconcileTemplateSecretEnvs(ctx context.Context, app *model.Application, user *model.User) {
+	if app.BasedOn == "" {
+		return
+	}
+	template, err := r.template(ctx, app.BasedOn, app.TemplateVersion)
+	if err != nil {
+		r.c.l.Errorf("error getting template %s of application %s while reconciling: %v", app.BasedOn, app.ID.Hex(), err)
+		return
+	}
+	_, secrets := splitTemplateSecretEnvs(template, app.Envs)
+	if len(secrets) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, secret.Key)
+	}
+	r.apply(&model.ReconcileAction{
+		Kind:          model.ReconcileActionSecureEnvs,
+		ApplicationID: app.ID.Hex(),
+		Reason:        fmt.Sprintf("envs %s of application %s are stored in plain text", strings.Join(keys, ", "), app.Name),
+	}, func() error {
+		return r.c.migrateTemplateSecretEnvs(ctx, app, user, template)
+	})
+}
+
+// the deletion was interrupted before the pod was removed, the resources left are
+// deleted and once none is left the application is removed from the database
+func (r *reconciliation) reconcileDeletingApplication(ctx context.Context, app *model.Application, resources []*model.ManagedResource) {
+	if len(resources) == 0 {
+		r.apply(&model.ReconcileAction{
+			Kind:          model.ReconcileActionRemoveApplication,
+			ApplicationID: app.ID.Hex(),
+			Reason:        fmt.Sprintf("application %s is deleting and has no resources left", app.Name),
+		}, func() error {
+			if err := r.c.deleteApplicationNotificationChannels(ctx, app); err != nil {
+				return err
+			}
+			_, err := r.c.ApplicationRepo.DeleteByID(ctx, app.ID)
+			return err
+		})
+		return
+	}
+
+	for _, resource := range resources {
+		if resource.Terminating {
+			continue
+		}
+		r.apply(&model.ReconcileAction{
+			Kind:          model.ReconcileActionResumeDeletion,
+			ApplicationID: app.ID.Hex(),
+			Resource:      resource,
+			Reason:        fmt.Sprintf("application %s is deleting but %s %s/%s is left", app.Name, resource.Kind, resource.Namespace, resource.Name),
+		}, func() error {
+			return r.c.ServiceManager.DeleteManagedResource(ctx, resource, gracePeriod)
+		})
+	}
+}
+
+// the creation of the resources was interrupted before they were saved in the
+// application, the partial resources are deleted and the application is failed
+// so that it can be deployed again. The volumes already saved are kept
+func (r *reconciliation) reconcileStuckApplication(ctx context.Context, app *model.Application, user *model.User, resources []*model.ManagedResource) {
+	savedVolumes := make(map[string]bool)
+	for _, volume := range app.Volumes {
+		if volume.PersistantVolumeClaim != nil {
+			savedVolumes[volume.PersistantVolumeClaim.Name] = true
+		}
+	}
+
+	var partial []*model.ManagedResource
+	for _, resource := range resources {
+		if resource.Terminating || (resource.Kind == model.ManagedResourceKindPVC && savedVolumes[resource.Name]) {
+			continue
+		}
+		partial = append(partial, resource)
+	}
+
+	action := &model.ReconcileAction{
+		Kind:          model.ReconcileActionMarkFailed,
+		ApplicationID: app.ID.Hex(),
+		Reason:        fmt.Sprintf("application %s is starting since %s with %d partial resources", app.Name, app.UpdatedAt.Format(time.RFC3339), len(partial)),
+	}
+	r.apply(action, func() error {
+		for _, resource := range partial {
+			if err := r.c.ServiceManager.DeleteManagedResource(ctx, resource, gracePeriod); err != nil {
+				return err
+			}
+		}
+		app.Health = model.ApplicationHealthNotReady
+		//rejected if a request changed the application while reconciling,
+		//it's left to the next reconciliation
+		return r.c.TransitionApplication(ctx, app, model.ApplicationStateFailed, action.Reason, model.StateTransitionActorReconciler)
+	})
+}
+
+// recreates the resources saved in the application and missing in the cluster,
+// a missing deployment can't be recreated without a new deploy and the
+// application is failed
+func (r *reconciliation) reconcileApplicationResources(ctx context.Context, app *model.Application, user *model.User, resources []*model.ManagedResource) {
+	present := make(map[string]bool)
+	for _, resource := range resources {
+		present[resource.Kind.String()+"/"+resource.Name] = true
+	}
+	missing := func(kind model.ManagedResourceKind, name string) bool {
+		return !present[kind.String()+"/"+name]
+	}
+	recreate := func(kind model.ManagedResourceKind, name string, fix func() error) {
+		r.apply(&model.ReconcileAction{
+			Kind:          model.ReconcileActionRecreateResource,
+			ApplicationID: app.ID.Hex(),
+			Resource: &model.ManagedResource{
+				Kind:          kind,
+				Name:          name,
+				Namespace:     user.Namespace,
+				ApplicationID: app.ID.Hex(),
+			},
+			Reason: fmt.Sprintf("%s %s/%s of application %s is missing", kind, user.Namespace, name, app.Name),
+		}, fix)
+	}
+
+	deployment := app.Service.Deployment
+	if deployment != nil && missing(model.ManagedResourceKindDeployment, deployment.Name) {
+		if app.State == model.ApplicationStateFailed {
+			return
+		}
+		action := &model.ReconcileAction{
+			Kind:          model.ReconcileActionMarkFailed,
+			ApplicationID: app.ID.Hex(),
+			Reason:        fmt.Sprintf("deployment %s/%s of application %s is missing, it must be deployed again", user.Namespace, deployment.Name, app.Name),
+		}
+		r.apply(action, func() error {
+			app.Health = model.ApplicationHealthNotReady
+			//rejected if a request changed the application while reconciling,
+			//it's left to the next reconciliation
+			return r.c.TransitionApplication(ctx, app, model.ApplicationStateFailed, action.Reason, model.StateTransitionActorReconciler)
+		})
+		return
+	}
+
+	//workers have no service nor ingress route
+	if app.Service.Name != "" && missing(model.ManagedResourceKindService, app.Service.Name) {
+		recreate(model.ManagedResourceKindService, app.Service.Name, func() error {
+			selector := fmt.Sprintf("%s-%s", app.Name, app.ID.Hex())
+			_, err := r.c.ServiceManager.CreateNewService(ctx, user.Namespace, app.Service.Name, selector, app.Service.Port, r.c.filledDefaultLabels(user, app, app.Service.Name))
+			return err
+		})
+	}
+
+	if ingressRoute := app.Service.IngressRoute; ingressRoute != nil && missing(model.ManagedResourceKindIngressRoute, ingressRoute.Name) {
+		recreate(model.ManagedResourceKindIngressRoute, ingressRoute.Name, func() error {
+			_, err := r.c.ServiceManager.CreateNewIngressRoute(ctx, user.Namespace, ingressRoute.Name, ingressRoute.Match, app.Service.Name, app.Service.Port, r.c.filledDefaultLabels(user, app, ingressRoute.Name))
+			return err
+		})
+	}
+
+	if deployment == nil {
+		return
+	}
+
+	if configMap := deployment.ConfigMap; configMap != nil && missing(model.ManagedResourceKindConfigMap, configMap.Name) {
+		recreate(model.ManagedResourceKindConfigMap, configMap.Name, func() error {
+			_, err := r.c.ServiceManager.CreateNewConfigMap(ctx, user.Namespace, configMap.Name, app.Envs, r.c.filledDefaultLabels(user, app, configMap.Name))
+			return err
+		})
+	}
+
+	if secret := deployment.Secret; secret != nil && missing(model.ManagedResourceKindSecret, secret.Name) {
+		recreate(model.ManagedResourceKindSecret, secret.Name, func() error {
+			data, err := r.c.decryptSecrets(app.Secrets)
+			if err != nil {
+				return err
+			}
+			_, err = r.c.ServiceManager.CreateNewSecret(ctx, user.Namespace, secret.Name, data, r.c.filledDefaultLabels(user, app, secret.Name))
+			return err
+		})
+	}
+}
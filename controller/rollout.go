@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// time given to the rollout on top of the progress deadline of the deployment,
+// kubernetes marks the rollout as failed on its own once the deadline is reached
+const rolloutWaitMargin = time.Minute
+
+// waits for the rollout of the application deployment to complete, if the new
+// version never becomes available the deployment is reverted to the previous image
+func (c *Controller) watchRollout(ctx context.Context, appID primitive.ObjectID, namespace string) {
+	app, err := c.ApplicationRepo.FindByID(ctx, appID)
+	if err != nil {
+		c.l.Errorf("error getting application %s while watching rollout: %v", appID.Hex(), err)
+		return
+	}
+	if app.Service == nil || app.Service.Deployment == nil || app.LastRollout == nil {
+		c.l.Errorf("application %s has no deployment or rollout to watch", appID.Hex())
+		return
+	}
+	if !rolloutInProgress(app) {
+		c.l.Infof("rollout of application %s already completed", app.Name)
+		return
+	}
+	deploymentName := app.Service.Deployment.Name
+	timeout := c.config.Rollout.Deadline + rolloutWaitMargin
+
+	rolloutErr := c.ServiceManager.WaitDeploymentRollout(ctx, namespace, deploymentName, timeout)
+	if ctx.Err() != nil {
+		c.l.Warnf("context cancelled while watching rollout of %s, no further changes will be done", app.Name)
+		return
+	}
+
+	//the application may have changed while waiting
+	app, err = c.ApplicationRepo.FindByID(ctx, appID)
+	if err != nil {
+		c.l.Errorf("error getting application %s after rollout: %v", appID.Hex(), err)
+		return
+	}
+	if app.State == model.ApplicationStateDeleting {
+		c.l.Infof("application %s is being deleted, ignoring rollout result", app.Name)
+		return
+	}
+	//a rollout resumed by the reconciler may be completed by another watcher
+	if !rolloutInProgress(app) {
+		c.l.Infof("rollout of application %s completed by another watcher, ignoring result", app.Name)
+		return
+	}
+
+	if rolloutErr == nil {
+		c.l.Infof("rollout of application %s completed", app.Name)
+		if err := c.transitionApplicationLatest(ctx, app, model.ApplicationStateRunning, fmt.Sprintf("rollout of commit %s completed", app.LastRollout.Commit), model.StateTransitionActorRolloutWatcher, func(app *model.Application) {
+			app.LastRollout.Status = model.RolloutStatusSucceeded
+			app.LastRollout.FinishedAt = time.Now()
+			c.setNewestReadyPod(ctx, app, namespace)
+		}); err != nil {
+			c.l.Errorf("error updating application after rollout: %v", err)
+		}
+		c.refreshScheduledJobs(ctx, app, namespace)
+		return
+	}
+
+	c.l.Errorf("rollout of application %s failed: %v", app.Name, rolloutErr)
+	c.NotifyApplicationEvent(app, model.NotificationEventRolloutFailed, rolloutErr.Error())
+	failRollout := func(reason string) {
+		if err := c.transitionApplicationLatest(ctx, app, model.ApplicationStateFailed, reason, model.StateTransitionActorRolloutWatcher, func(app *model.Application) {
+			app.LastRollout.Reason = rolloutErr.Error()
+			app.LastRollout.Status = model.RolloutStatusFailed
+			app.LastRollout.FinishedAt = time.Now()
+		}); err != nil {
+			c.l.Errorf("error updating application after rollout: %v", err)
+		}
+	}
+	if app.LastRollout.PreviousImage == "" {
+		failRollout(fmt.Sprintf("rollout of commit %s failed: %v", app.LastRollout.Commit, rolloutErr))
+		return
+	}
+
+	c.l.Infof("rolling back application %s to image %s", app.Name, app.LastRollout.PreviousImage)
+	deployment := app.Service.Deployment
+	if _, err := c.ServiceManager.UpdateDeployment(ctx, namespace, deployment.Name, app.LastRollout.PreviousImage, deployment.Replicas, deployment.Port, deployment.Labels, nil, app.HealthChecks); err != nil {
+		c.l.Errorf("error rolling back deployment %s: %v", deployment.Name, err)
+		failRollout(fmt.Sprintf("rollout of commit %s failed and the rollback could not be started", app.LastRollout.Commit))
+		return
+	}
+	rolledBack := func(app *model.Application) {
+		app.Service.Deployment.ImageRegistry = app.LastRollout.PreviousImage
+		app.BuiltCommit = app.LastRollout.PreviousCommit
+		app.LastRollout.Reason = rolloutErr.Error()
+		app.LastRollout.FinishedAt = time.Now()
+	}
+
+	if err := c.ServiceManager.WaitDeploymentRollout(ctx, namespace, deployment.Name, timeout); err != nil {
+		c.l.Errorf("error waiting rollback of deployment %s: %v", deployment.Name, err)
+		err = c.transitionApplicationLatest(ctx, app, model.ApplicationStateFailed, fmt.Sprintf("rollout of commit %s failed and the rollback did not complete", app.LastRollout.Commit), model.StateTransitionActorRolloutWatcher, func(app *model.Application) {
+			rolledBack(app)
+			app.LastRollout.Status = model.RolloutStatusFailed
+		})
+		if err != nil {
+			c.l.Errorf("error updating application after rollback: %v", err)
+		}
+		return
+	}
+	if err := c.transitionApplicationLatest(ctx, app, model.ApplicationStateRunning, fmt.Sprintf("rollout of commit %s failed, rolled back to commit %s", app.LastRollout.Commit, app.LastRollout.PreviousCommit), model.StateTransitionActorRolloutWatcher, func(app *model.Application) {
+		rolledBack(app)
+		app.LastRollout.Status = model.RolloutStatusRolledBack
+		c.setNewestReadyPod(ctx, app, namespace)
+	}); err != nil {
+		c.l.Errorf("error updating application after rollback: %v", err)
+	}
+	c.refreshScheduledJobs(ctx, app, namespace)
+}
+
+// the rollout is saved as progressing until a watcher completes it, if the watcher
+// is lost the application stays rolling out and the reconciler resumes it
+func rolloutInProgress(app *model.Application) bool {
+	return app.State == model.ApplicationStateRollingOut && app.LastRollout != nil && app.LastRollout.Status == model.RolloutStatusProgressing
+}
+
+// sets the current pod of the application to the newest ready pod of the deployment,
+// the old pods are terminated by the rollout so they can not be watched anymore
+func (c *Controller) setNewestReadyPod(ctx context.Context, app *model.Application, namespace string) {
+	pods, err := c.ServiceManager.ListDeploymentPods(ctx, namespace, app.Service.Deployment.Name)
+	if err != nil {
+		c.l.Errorf("error listing pods of deployment %s: %v", app.Service.Deployment.Name, err)
+		return
+	}
+	for _, pod := range pods {
+		if pod.Ready && !pod.Terminating {
+			app.Service.Deployment.CurrentPodName = pod.Name
+			app.Health = model.ApplicationHealthReady
+			return
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxJobCommandLength = 4096
+
+var scheduledJobNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,30}[a-z0-9])?$`)
+
+func scheduledJobCronJobName(job *model.ScheduledJob) string {
+	return "cj-" + job.ID.Hex()
+}
+
+func taskJobName(app *model.Application) string {
+	return fmt.Sprintf("task-%s-%d", app.ID.Hex(), time.Now().Unix())
+}
+
+func validateJobCommand(command string) error {
+	if strings.TrimSpace(command) == "" || len(command) > maxJobCommandLength {
+		return ErrInvalidJobCommand
+	}
+	return nil
+}
+
+// the jobs use the image, the envs and the volumes of the deployment of the application,
+// so it must have been deployed at least once. Volumes that can only be mounted by one
+// node keep the pod pending until it's scheduled on the node of the application
+func (c *Controller) applicationJobSpec(ctx context.Context, app *model.Application, command string) (model.JobSpec, error) {
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.ImageRegistry == "" {
+		return model.JobSpec{}, ErrInvalidOperationInCurrentState
+	}
+	envSources, err := c.deploymentEnvSources(ctx, app)
+	if err != nil {
+		return model.JobSpec{}, err
+	}
+	return model.JobSpec{
+		Containers: []model.JobContainer{{
+			Name:       model.JobRunContainerName,
+			Image:      app.Service.Deployment.ImageRegistry,
+			Command:    []string{"sh", "-c", command},
+			EnvSources: envSources,
+		}},
+		Volumes:      applicationVolumes(app),
+		BackoffLimit: 0,
+	}, nil
+}
+
+func (c *Controller) applicationJobLabels(user *model.User, app *model.Application, resourceName string) []model.KeyValue {
+	labels := c.filledDefaultLabels(user, app, resourceName)
+	return append(labels, model.KeyValue{Key: model.JobOfLabel, Value: app.ID.Hex()})
+}
+
+func (c *Controller) ListScheduledJobs(ctx context.Context, app *model.Application) ([]*model.ScheduledJob, error) {
+	jobs, err := c.ScheduledJobRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding scheduled jobs of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (c *Controller) GetScheduledJob(ctx context.Context, app *model.Application, jobID primitive.ObjectID) (*model.ScheduledJob, error) {
+	job, err := c.ScheduledJobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrScheduledJobNotFound
+		}
+		c.l.Errorf("error finding scheduled job %s: %v", jobID.Hex(), err)
+		return nil, err
+	}
+	if job.ApplicationID != app.ID {
+		return nil, ErrScheduledJobNotFound
+	}
+	return job, nil
+}
+
+// creates a cron job running the command in the image of the application
+func (c *Controller) CreateScheduledJob(ctx context.Context, app *model.Application, user *model.User, name, schedule, command string) (*model.ScheduledJob, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "CreateScheduledJob",
+		"name":          name,
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+	if !scheduledJobNameRegex.MatchString(name) {
+		return nil, ErrInvalidJobName
+	}
+	schedule = strings.TrimSpace(schedule)
+	if !validateCronSchedule(schedule) {
+		return nil, ErrInvalidJobSchedule
+	}
+	if err := validateJobCommand(command); err != nil {
+		return nil, err
+	}
+
+	jobs, err := c.ScheduledJobRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error finding scheduled jobs: %v", err)
+		return nil, err
+	}
+	if len(jobs) >= c.config.Jobs.MaxPerApplication {
+		return nil, ErrScheduledJobsLimitReached
+	}
+	for _, j := range jobs {
+		if j.Name == name {
+			return nil, ErrJobNameNotAvailable
+		}
+	}
+
+	spec, err := c.applicationJobSpec(ctx, app, command)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.ScheduledJob{
+		ID:            primitive.NewObjectID(),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		ApplicationID: app.ID,
+		Owner:         user.Code,
+		Name:          name,
+		Schedule:      schedule,
+		Command:       command,
+	}
+	job.CronJobName = scheduledJobCronJobName(job)
+	labels := append(c.applicationJobLabels(user, app, job.CronJobName), model.KeyValue{Key: model.ScheduledJobLabel, Value: job.ID.Hex()})
+	if _, err := c.ServiceManager.CreateNewCronJob(ctx, user.Namespace, job.CronJobName, schedule, spec, c.config.Jobs.History, labels); err != nil {
+		c.l.WithFields(fields).Errorf("error creating cron job: %v", err)
+		return nil, convertServiceManagerError(err)
+	}
+	if _, err := c.ScheduledJobRepo.InsertOne(ctx, job); err != nil {
+		c.l.WithFields(fields).Errorf("error inserting scheduled job: %v", err)
+		if err := c.ServiceManager.DeleteCronJob(ctx, user.Namespace, job.CronJobName, 0); err != nil {
+			c.l.WithFields(fields).Errorf("error deleting cron job after failed insert: %v", err)
+		}
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("scheduled job %s of application %s created", job.Name, app.Name)
+	return job, nil
+}
+
+// updates the schedule and the command of the job, empty values are not updated
+func (c *Controller) UpdateScheduledJob(ctx context.Context, app *model.Application, user *model.User, job *model.ScheduledJob, schedule, command string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "UpdateScheduledJob",
+		"jobID":         job.ID.Hex(),
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+	if schedule = strings.TrimSpace(schedule); schedule != "" {
+		if !validateCronSchedule(schedule) {
+			return ErrInvalidJobSchedule
+		}
+		job.Schedule = schedule
+	}
+	if command != "" {
+		if err := validateJobCommand(command); err != nil {
+			return err
+		}
+		job.Command = command
+	}
+
+	spec, err := c.applicationJobSpec(ctx, app, job.Command)
+	if err != nil {
+		return err
+	}
+	if _, err := c.ServiceManager.UpdateCronJob(ctx, user.Namespace, job.CronJobName, job.Schedule, spec); err != nil {
+		c.l.WithFields(fields).Errorf("error updating cron job: %v", err)
+		return err
+	}
+	job.UpdatedAt = time.Now()
+	if _, err := c.ScheduledJobRepo.UpdateByID(ctx, job, job.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error updating scheduled job: %v", err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("scheduled job %s of application %s updated", job.Name, app.Name)
+	return nil
+}
+
+// deletes the cron job, the runs already done are kept in the history
+func (c *Controller) DeleteScheduledJob(ctx context.Context, app *model.Application, user *model.User, job *model.ScheduledJob) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "DeleteScheduledJob",
+		"jobID":         job.ID.Hex(),
+	}
+
+	if err := c.ServiceManager.DeleteCronJob(ctx, user.Namespace, job.CronJobName, 0); err != nil {
+		c.l.WithFields(fields).Errorf("error deleting cron job: %v", err)
+		return err
+	}
+	if _, err := c.ScheduledJobRepo.DeleteByID(ctx, job.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error deleting scheduled job: %v", err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("scheduled job %s of application %s deleted", job.Name, app.Name)
+	return nil
+}
+
+// runs the command once in the image of the application (ex: migrations)
+func (c *Controller) RunApplicationTask(ctx context.Context, app *model.Application, user *model.User, command string) (*model.JobRun, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "RunApplicationTask",
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+	if err := validateJobCommand(command); err != nil {
+		return nil, err
+	}
+	spec, err := c.applicationJobSpec(ctx, app, command)
+	if err != nil {
+		return nil, err
+	}
+	//the finished job is deleted after the TTL, a run not synced before that has no logs
+	//and is marked as failed
+	spec.TTLSecondsAfterFinished = c.config.Jobs.TaskTTL
+
+	jobName := taskJobName(app)
+	if _, err := c.ServiceManager.CreateNewJob(ctx, user.Namespace, jobName, spec, c.applicationJobLabels(user, app, jobName)); err != nil {
+		c.l.WithFields(fields).Errorf("error creating task job: %v", err)
+		return nil, convertServiceManagerError(err)
+	}
+
+	run := &model.JobRun{
+		ID:            primitive.NewObjectID(),
+		CreatedAt:     time.Now(),
+		ApplicationID: app.ID,
+		Owner:         user.Code,
+		Kind:          model.JobRunKindTask,
+		Command:       command,
+		Status:        model.JobStatusPending,
+		JobName:       jobName,
+	}
+	if _, err := c.JobRunRepo.InsertOne(ctx, run); err != nil {
+		c.l.WithFields(fields).Errorf("error inserting job run: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("task %s of application %s started", jobName, app.Name)
+	return run, nil
+}
+
+// updates the runs of the application with the status of their jobs, the jobs created
+// by the cron jobs are recorded the first time they are seen. The logs of a run are
+// stored once it's finished, the unfinished runs whose job is gone are marked as failed
+func (c *Controller) syncApplicationJobRuns(ctx context.Context, app *model.Application, user *model.User) error {
+	listedAt := time.Now()
+	jobs, err := c.ServiceManager.ListJobs(ctx, user.Namespace, []model.KeyValue{{Key: model.JobOfLabel, Value: app.ID.Hex()}})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		seen[job.Name] = true
+		run, err := c.JobRunRepo.FindByJobName(ctx, job.Name)
+		if err != nil && err != repo.ErrNotFound {
+			return err
+		}
+		if err == repo.ErrNotFound {
+			if job.CronJobName == "" {
+				continue
+			}
+			run = &model.JobRun{
+				ID:            primitive.NewObjectID(),
+				CreatedAt:     job.StartedAt,
+				ApplicationID: app.ID,
+				Owner:         app.Owner,
+				Kind:          model.JobRunKindScheduled,
+				Status:        model.JobStatusPending,
+				JobName:       job.Name,
+			}
+			if run.CreatedAt.IsZero() {
+				run.CreatedAt = time.Now()
+			}
+			if scheduledJobID, err := primitive.ObjectIDFromHex(convertModelKeyValueToMap(job.Labels)[model.ScheduledJobLabel]); err == nil {
+				run.ScheduledJobID = scheduledJobID
+				if scheduledJob, err := c.ScheduledJobRepo.FindByID(ctx, scheduledJobID); err == nil {
+					run.Command = scheduledJob.Command
+				}
+			}
+			if _, err := c.JobRunRepo.InsertOne(ctx, run); err != nil {
+				return err
+			}
+		}
+		if run.Status.IsFinished() || run.Status == job.Status {
+			continue
+		}
+		run.Status = job.Status
+		run.FinishedAt = job.FinishedAt
+		if run.Status.IsFinished() {
+			logs, err := c.ServiceManager.GetJobLogs(ctx, user.Namespace, job.Name, model.JobRunContainerName, c.config.Jobs.LogLines)
+			if err != nil {
+				c.l.Warnf("error getting logs of job %s: %v", job.Name, err)
+			}
+			run.Logs = logs
+		}
+		if _, err := c.JobRunRepo.UpdateByID(ctx, run, run.ID); err != nil {
+			return err
+		}
+	}
+
+	runs, err := c.JobRunRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		//a run inserted after the listing can have a job not listed yet
+		if run.Status.IsFinished() || seen[run.JobName] || run.CreatedAt.After(listedAt) {
+			continue
+		}
+		run.Status = model.JobStatusFailed
+		run.FinishedAt = time.Now()
+		if _, err := c.JobRunRepo.UpdateByID(ctx, run, run.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the runs of the scheduled jobs and tasks of the application, newest first.
+// If the status can not be read from the cluster the last known one is returned
+func (c *Controller) ListApplicationJobRuns(ctx context.Context, app *model.Application, user *model.User) ([]*model.JobRun, error) {
+	if err := c.syncApplicationJobRuns(ctx, app, user); err != nil {
+		c.l.Warnf("error syncing job runs of application %s: %v", app.ID.Hex(), err)
+	}
+	runs, err := c.JobRunRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding job runs of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	return runs, nil
+}
+
+// returns the run and its logs, the logs of a run still in progress are read
+// from the cluster
+func (c *Controller) GetApplicationJobRun(ctx context.Context, app *model.Application, user *model.User, runID primitive.ObjectID) (*model.JobRun, string, error) {
+	if err := c.syncApplicationJobRuns(ctx, app, user); err != nil {
+		c.l.Warnf("error syncing job runs of application %s: %v", app.ID.Hex(), err)
+	}
+	run, err := c.JobRunRepo.FindByID(ctx, runID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, "", ErrJobRunNotFound
+		}
+		c.l.Errorf("error finding job run %s: %v", runID.Hex(), err)
+		return nil, "", err
+	}
+	if run.ApplicationID != app.ID {
+		return nil, "", ErrJobRunNotFound
+	}
+	if run.Status.IsFinished() {
+		return run, run.Logs, nil
+	}
+
+	logs, err := c.ServiceManager.GetJobLogs(ctx, user.Namespace, run.JobName, model.JobRunContainerName, c.config.Jobs.LogLines)
+	if err != nil && !errors.Is(err, serviceManager.ErrResourceNotFound) {
+		c.l.Warnf("error getting logs of job %s: %v", run.JobName, err)
+	}
+	return run, logs, nil
+}
+
+// updates the cron jobs of the application with its current image, envs and volumes,
+// errors are only logged since the next update will try again
+func (c *Controller) refreshScheduledJobs(ctx context.Context, app *model.Application, namespace string) {
+	jobs, err := c.ScheduledJobRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding scheduled jobs of application %s: %v", app.ID.Hex(), err)
+		return
+	}
+	for _, job := range jobs {
+		spec, err := c.applicationJobSpec(ctx, app, job.Command)
+		if err != nil {
+			c.l.Errorf("error creating spec of scheduled job %s: %v", job.ID.Hex(), err)
+			continue
+		}
+		if _, err := c.ServiceManager.UpdateCronJob(ctx, namespace, job.CronJobName, job.Schedule, spec); err != nil {
+			c.l.Errorf("error refreshing cron job %s: %v", job.CronJobName, err)
+		}
+	}
+}
+
+// deletes the cron jobs and the jobs still in the cluster, the runs are kept
+func (c *Controller) deleteApplicationJobs(ctx context.Context, app *model.Application, user *model.User) error {
+	jobs, err := c.ScheduledJobRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding scheduled jobs of application %s: %v", app.ID.Hex(), err)
+		return err
+	}
+	for _, job := range jobs {
+		c.l.Debugf("deleting scheduled job %s of application %s", job.Name, app.Name)
+		if err := c.ServiceManager.DeleteCronJob(ctx, user.Namespace, job.CronJobName, gracePeriod); err != nil {
+			c.l.Errorf("error deleting cron job %s: %v", job.CronJobName, err)
+			return err
+		}
+		if _, err := c.ScheduledJobRepo.DeleteByID(ctx, job.ID); err != nil {
+			c.l.Errorf("error deleting scheduled job %s: %v", job.ID.Hex(), err)
+			return err
+		}
+	}
+
+	k8sJobs, err := c.ServiceManager.ListJobs(ctx, user.Namespace, []model.KeyValue{{Key: model.JobOfLabel, Value: app.ID.Hex()}})
+	if err != nil {
+		c.l.Errorf("error listing jobs of application %s: %v", app.ID.Hex(), err)
+		return err
+	}
+	for _, job := range k8sJobs {
+		if err := c.ServiceManager.DeleteJob(ctx, user.Namespace, job.Name, gracePeriod); err != nil {
+			c.l.Errorf("error deleting job %s: %v", job.Name, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// secrets are loaded as env variables so the key must be a valid env name
+var secretKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateSecret(secret model.KeyValue) error {
+	if !secretKeyRegex.MatchString(secret.Key) || secret.Value == "" {
+		return ErrInvalidSecret
+	}
+	return nil
+}
+
+// validates and encrypts the plain secrets so they can be stored in the database
+func (c *Controller) encryptSecrets(secrets []model.KeyValue) ([]model.SecretEnv, error) {
+	encrypted := make([]model.SecretEnv, 0, len(secrets))
+	seen := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		if err := validateSecret(secret); err != nil {
+			return nil, err
+		}
+		if seen[secret.Key] {
+			return nil, ErrInvalidSecret
+		}
+		seen[secret.Key] = true
+
+		value, err := c.encrypter.Encrypt(secret.Value)
+		if err != nil {
+			c.l.Errorf("error encrypting secret %s: %v", secret.Key, err)
+			return nil, err
+		}
+		encrypted = append(encrypted, model.SecretEnv{
+			Key:       secret.Key,
+			Value:     value,
+			UpdatedAt: time.Now(),
+		})
+	}
+	return encrypted, nil
+}
+
+// decrypts the secrets of the application, the plain values must only be sent to the cluster
+func (c *Controller) decryptSecrets(secrets []model.SecretEnv) ([]model.KeyValue, error) {
+	plain := make([]model.KeyValue, 0, len(secrets))
+	for _, secret := range secrets {
+		value, err := c.encrypter.Decrypt(secret.Value)
+		if err != nil {
+			c.l.Errorf("error decrypting secret %s: %v", secret.Key, err)
+			return nil, err
+		}
+		plain = append(plain, model.KeyValue{
+			Key:   secret.Key,
+			Value: value,
+		})
+	}
+	return plain, nil
+}
+
+// creates the kubernetes secret of the application if it has any secret,
+// returns nil if the application has no secrets
+func (c *Controller) createApplicationSecret(ctx context.Context, app *model.Application, user *model.User) (*model.Secret, error) {
+	if len(app.Secrets) == 0 {
+		return nil, nil
+	}
+	data, err := c.decryptSecrets(app.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	return c.createSecret(ctx, app, user, data)
+}
+
+func (c *Controller) recordAuditEvent(ctx context.Context, user *model.User, app *model.Application, action model.AuditAction, target string) {
+	c.insertAuditEvent(ctx, &model.AuditEvent{
+		CreatedAt:     time.Now(),
+		UserCode:      user.Code,
+		ApplicationID: app.ID,
+		Action:        action,
+		Target:        target,
+	})
+}
+
+// failing to record an event must not fail the operation, the error is only logged
+func (c *Controller) insertAuditEvent(ctx context.Context, event *model.AuditEvent) {
+	if _, err := c.AuditRepo.InsertOne(ctx, event); err != nil {
+		c.l.WithFields(logrus.Fields{
+			"applicationID": event.ApplicationID.Hex(),
+			"userID":        event.UserCode,
+			"action":        event.Action,
+			"target":        event.Target,
+		}).Errorf("error recording audit event: %v", err)
+	}
+}
+
+func (c *Controller) ListApplicationAuditEvents(ctx context.Context, app *model.Application) ([]*model.AuditEvent, error) {
+	events, err := c.AuditRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding audit events of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// creates or updates a secret of the application, if a plain env with the same
+// key exists it's removed so the value is only stored as a secret
+func (c *Controller) SetApplicationSecret(ctx context.Context, app *model.Application, user *model.User, key, value string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "SetApplicationSecret",
+		"key":           key,
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	encrypted, err := c.encryptSecrets([]model.KeyValue{{Key: key, Value: value}})
+	if err != nil {
+		return err
+	}
+
+	var action model.AuditAction
+	if err := c.applyApplicationSecrets(ctx, app, user, func(app *model.Application) (bool, error) {
+		if app.State == model.ApplicationStateDeleting {
+			return false, ErrInvalidOperationInCurrentState
+		}
+		action = model.AuditActionSecretCreated
+		found := false
+		for i := range app.Secrets {
+			if app.Secrets[i].Key == key {
+				app.Secrets[i] = encrypted[0]
+				found = true
+				action = model.AuditActionSecretUpdated
+				break
+			}
+		}
+		if !found {
+			app.Secrets = append(app.Secrets, encrypted[0])
+		}
+
+		for i, env := range app.Envs {
+			if env.Key == key {
+				c.l.WithFields(fields).Infof("plain env %s replaced by a secret", key)
+				app.Envs = append(app.Envs[:i], app.Envs[i+1:]...)
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		c.l.WithFields(fields).Errorf("error applying secrets: %v", err)
+		return err
+	}
+	c.recordAuditEvent(ctx, user, app, action, key)
+	c.syncStorageLinks(ctx, user, app)
+	c.l.WithFields(fields).Infof("secret %s of application %s set", key, app.Name)
+	return nil
+}
+
+func (c *Controller) DeleteApplicationSecret(ctx context.Context, app *model.Application, user *model.User, key string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "DeleteApplicationSecret",
+		"key":           key,
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	if err := c.applyApplicationSecrets(ctx, app, user, func(app *model.Application) (bool, error) {
+		if app.State == model.ApplicationStateDeleting {
+			return false, ErrInvalidOperationInCurrentState
+		}
+		for i := range app.Secrets {
+			if app.Secrets[i].Key == key {
+				app.Secrets = append(app.Secrets[:i], app.Secrets[i+1:]...)
+				return false, nil
+			}
+		}
+		return false, ErrSecretNotFound
+	}); err != nil {
+		if err != ErrSecretNotFound {
+			c.l.WithFields(fields).Errorf("error applying secrets: %v", err)
+		}
+		return err
+	}
+	c.recordAuditEvent(ctx, user, app, model.AuditActionSecretDeleted, key)
+	c.syncStorageLinks(ctx, user, app)
+	c.l.WithFields(fields).Infof("secret %s of application %s deleted", key, app.Name)
+	return nil
+}
+
+// saves the secrets changed by modify and syncs them with the cluster, restarting the
+// application to load them. The application is saved first so the cluster never holds
+// values the database doesn't know about, if it was changed in the meantime modify is
+// applied again to its latest version. modify returns whether the plain envs changed.
+// Applications without a deployment get the secrets on creation
+func (c *Controller) applyApplicationSecrets(ctx context.Context, app *model.Application, user *model.User, modify func(app *model.Application) (bool, error)) error {
+	envsChanged := false
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		changed, err := modify(app)
+		envsChanged = changed
+		return err
+	}); err != nil {
+		return err
+	}
+	if app.Service == nil || app.Service.Deployment == nil {
+		return nil
+	}
+
+	data, err := c.decryptSecrets(app.Secrets)
+	if err != nil {
+		return err
+	}
+
+	if envsChanged && app.Service.Deployment.ConfigMap != nil {
+		updatedConfigMap, err := c.ServiceManager.UpdateConfigMap(ctx, user.Namespace, app.Service.Deployment.ConfigMap.Name, app.Envs)
+		if err != nil {
+			c.l.Errorf("error updating config map %s: %v", app.Service.Deployment.ConfigMap.Name, err)
+			return err
+		}
+		app.Service.Deployment.ConfigMap = updatedConfigMap
+	}
+
+	if app.Service.Deployment.Secret != nil {
+		updatedSecret, err := c.ServiceManager.UpdateSecret(ctx, user.Namespace, app.Service.Deployment.Secret.Name, data)
+		if err != nil {
+			c.l.Errorf("error updating secret %s: %v", app.Service.Deployment.Secret.Name, err)
+			return err
+		}
+		app.Service.Deployment.Secret = updatedSecret
+		//envs loaded from a secret are only read when the container starts
+		return c.RedeployApplication(ctx, user, app)
+	}
+
+	secret, err := c.createSecret(ctx, app, user, data)
+	if err != nil {
+		return err
+	}
+	app.Service.Deployment.Secret = secret
+	envSources, err := c.deploymentEnvSources(ctx, app)
+	if err != nil {
+		return err
+	}
+	if _, err := c.ServiceManager.UpdateDeployment(
+		ctx,
+		user.Namespace,
+		app.Service.Deployment.Name,
+		app.Service.Deployment.ImageRegistry,
+		app.Service.Deployment.Replicas,
+		app.Service.Deployment.Port,
+		app.Service.Deployment.Labels,
+		envSources,
+		app.HealthChecks); err != nil {
+		c.l.Errorf("error updating deployment %s: %v", app.Service.Deployment.Name, err)
+		return convertServiceManagerError(err)
+	}
+	//the new env source changes the pod template, the deployment is already rolling
+	//to new pods and the current one is chosen again by the container events
+	return c.modifyApplication(ctx, app, func(app *model.Application) error {
+		if app.Service == nil || app.Service.Deployment == nil {
+			return ErrInvalidOperationInCurrentState
+		}
+		app.Service.Deployment.Secret = secret
+		app.Service.Deployment.CurrentPodName = ""
+		app.Health = model.ApplicationHealthUnknown
+		return nil
+	})
+}
+
+// moves the plain envs the template of the application marks as secrets to the
+// secrets of the application, applications created before the template marked them
+// keep them in plain text. A plain env already stored as a secret is dropped
+func (c *Controller) migrateTemplateSecretEnvs(ctx context.Context, app *model.Application, user *model.User, template *model.Template) error {
+	if _, secrets := splitTemplateSecretEnvs(template, app.Envs); len(secrets) == 0 {
+		return nil
+	}
+
+	return c.applyApplicationSecrets(ctx, app, user, func(app *model.Application) (bool, error) {
+		plain, secrets := splitTemplateSecretEnvs(template, app.Envs)
+		if len(secrets) == 0 {
+			return false, nil
+		}
+		stored := make(map[string]bool, len(app.Secrets))
+		for _, secret := range app.Secrets {
+			stored[secret.Key] = true
+		}
+		var moved []model.KeyValue
+		for _, secret := range secrets {
+			if !stored[secret.Key] {
+				moved = append(moved, secret)
+			}
+		}
+		encrypted, err := c.encryptSecrets(moved)
+		if err != nil {
+			return false, err
+		}
+		app.Envs = plain
+		app.Secrets = append(app.Secrets, encrypted...)
+		return true, nil
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 const (
 	gracePeriod = 120
+	gibibyte    = int64(1024 * 1024 * 1024)
 )
 
 func (c *Controller) getDefaultLabels(owner, environment, app, appID, resourceName string) []model.KeyValue {
@@ -46,7 +47,20 @@ func (c *Controller) createConfigMap(ctx context.Context, app *model.Application
 	return configMap, nil
 }
 
-func (c *Controller) createDeployment(ctx context.Context, app *model.Application, user *model.User, registryImage, configMapName string, volume *model.Volume) (*model.Deployment, error) {
+func (c *Controller) createSecret(ctx context.Context, app *model.Application, user *model.User, data []model.KeyValue) (*model.Secret, error) {
+	c.l.Debugf("creating secret for application %s", app.Name)
+	resourceName := fmt.Sprintf("secret-%s-%s", app.Name, app.ID.Hex())
+	secretLabels := c.filledDefaultLabels(user, app, resourceName)
+	secret, err := c.ServiceManager.CreateNewSecret(ctx, user.Namespace, resourceName, data, secretLabels)
+	if err != nil {
+		c.l.Errorf("error creating secret: %v", err)
+		return nil, err
+	}
+	c.l.Debugf("created secret for %s in namespace: %s with name: %s", app.Name, secret.Namespace, secret.Name)
+	return secret, nil
+}
+
+func (c *Controller) createDeployment(ctx context.Context, app *model.Application, user *model.User, registryImage, configMapName, secretName string, volumes []*model.Volume, resources *model.ContainerResources) (*model.Deployment, error) {
 	c.l.Debugf("creating deployment for application %s", app.Name)
 	appName := fmt.Sprintf("%s-%s", app.Name, app.ID.Hex())
 	resourceName := fmt.Sprintf("deploy-%s", appName)
@@ -60,16 +74,27 @@ func (c *Controller) createDeployment(ctx context.Context, app *model.Applicatio
 				Key:   model.PortLabel,
 				Value: app.ListeningPort,
 			}}...)
-	p, err := strconv.Atoi(app.ListeningPort)
+	//workers have no port, the container is created without one
+	intPort := int32(0)
+	if app.Kind != model.ApplicationKindWorker {
+		p, err := strconv.Atoi(app.ListeningPort)
+		if err != nil {
+			c.l.Errorf("error converting port to int: %v", err)
+			return nil, err
+		}
+		intPort = int32(p)
+	}
+	if app.HealthChecks == nil {
+		app.HealthChecks = defaultHealthChecks(app.ListeningPort)
+	}
+	envSources, err := c.applicationEnvSources(ctx, app, configMapName, secretName)
 	if err != nil {
-		c.l.Errorf("error converting port to int: %v", err)
 		return nil, err
 	}
-	intPort := int32(p)
-	deployment, err := c.ServiceManager.CreateNewDeployment(ctx, user.Namespace, resourceName, appName, registryImage, 1, intPort, deploymentLabels, configMapName, volume)
+	deployment, err := c.ServiceManager.CreateNewDeployment(ctx, user.Namespace, resourceName, appName, registryImage, 1, intPort, deploymentLabels, envSources, volumes, app.HealthChecks, resources)
 	if err != nil {
 		c.l.Errorf("error creating deployment: %v", err)
-		return nil, err
+		return nil, convertServiceManagerError(err)
 	}
 	c.l.Debugf("created deployment for %s in namespace: %s with name: %s", app.Name, deployment.Namespace, deployment.Name)
 	return deployment, nil
@@ -111,14 +136,14 @@ func (c *Controller) createIngressRoute(ctx context.Context, app *model.Applicat
 	return ingressRoute, nil
 }
 
-func (c *Controller) createPersistantVolumeClaim(ctx context.Context, app *model.Application, user *model.User, storageClass string, GiSize int64) (*model.PersistentVolumeClaim, error) {
+// the size is in Gi
+func (c *Controller) createPersistantVolumeClaim(ctx context.Context, app *model.Application, user *model.User, pvcName, storageClass string, size int64) (*model.PersistentVolumeClaim, error) {
 	c.l.Debugf("creating persistant volume claim for application %s", app.Name)
-	pvcName := fmt.Sprintf("pvc-%s-%s", app.Name, app.ID.Hex())
 	pvcLabels := c.filledDefaultLabels(user, app, pvcName)
-	pvc, err := c.ServiceManager.CreateNewPersistentVolumeClaim(ctx, user.Namespace, pvcName, storageClass, GiSize, pvcLabels)
+	pvc, err := c.ServiceManager.CreateNewPersistentVolumeClaim(ctx, user.Namespace, pvcName, storageClass, size*gibibyte, pvcLabels)
 	if err != nil {
 		c.l.Errorf("error creating persistant volume claim: %v", err)
-		return nil, err
+		return nil, convertServiceManagerError(err)
 	}
 	c.l.Debugf("created persistant volume claim for %s in namespace: %s with name: %s", app.Name, pvc.Namespace, pvc.Name)
 	return pvc, nil
@@ -139,7 +164,8 @@ func (c *Controller) deleteDeployment(ctx context.Context, app *model.Applicatio
 }
 
 func (c *Controller) deleteService(ctx context.Context, app *model.Application, user *model.User) error {
-	if app.Service == nil {
+	//workers only have the deployment in the service
+	if app.Service == nil || app.Service.Name == "" {
 		c.l.Debug("trying to delete service from application without service")
 		return nil
 	}
@@ -181,15 +207,31 @@ func (c *Controller) deleteConfigMap(ctx context.Context, app *model.Application
 }
 
 func (c *Controller) deletePersistantVolumeClmain(ctx context.Context, app *model.Application, user *model.User) error {
-	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.Volume == nil {
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.Volume == nil ||
+		app.Service.Deployment.Volume.PersistantVolumeClaim == nil {
 		c.l.Debugf("trying to delete PVC from application without a PVC")
 		return nil
 	}
-	c.l.Debugf("deleting PVC %s", app.Service.Deployment.ConfigMap.Name)
-	if err := c.ServiceManager.DeletePersistantVolumeClmain(ctx, user.Namespace, app.Service.Deployment.ConfigMap.Name, gracePeriod); err != nil {
-		c.l.Errorf("error deleting PVC %s: %v", app.Service.Deployment.ConfigMap.Name, err)
+	pvcName := app.Service.Deployment.Volume.PersistantVolumeClaim.Name
+	c.l.Debugf("deleting PVC %s", pvcName)
+	if err := c.ServiceManager.DeletePersistantVolumeClmain(ctx, user.Namespace, pvcName, gracePeriod); err != nil {
+		c.l.Errorf("error deleting PVC %s: %v", pvcName, err)
+		return err
+	}
+	c.l.Debugf("PVC %s delete succesfully", pvcName)
+	return nil
+}
+
+func (c *Controller) deleteSecret(ctx context.Context, app *model.Application, user *model.User) error {
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.Secret == nil {
+		c.l.Debugf("trying to delete secret from application without a secret")
+		return nil
+	}
+	c.l.Debugf("deleting secret %s", app.Service.Deployment.Secret.Name)
+	if err := c.ServiceManager.DeleteSecret(ctx, user.Namespace, app.Service.Deployment.Secret.Name, gracePeriod); err != nil {
+		c.l.Errorf("error deleting secret %s: %v", app.Service.Deployment.Secret.Name, err)
 		return err
 	}
-	c.l.Debugf("PVC %s delete succesfully", app.Service.Deployment.ConfigMap.Name)
+	c.l.Debugf("secret %s delete succesfully", app.Service.Deployment.Secret.Name)
 	return nil
 }
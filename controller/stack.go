@@ -0,0 +1,374 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// the applications are named <stack>-<component> so both must be short dns labels
+	stackNameRegex          = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,22}[a-z0-9])?$`)
+	stackComponentNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,14}[a-z0-9])?$`)
+	// {{component.host}}, {{component.port}} or {{component.env.KEY}}, the bound
+	// managment applications use target as component
+	envReferenceRegex = regexp.MustCompile(`\{\{\s*([a-z0-9-]+)\.(host|port|env\.[A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+func stackApplicationName(stackName, component string) string {
+	return fmt.Sprintf("%s-%s", stackName, component)
+}
+
+// merges the env lists, when a key is repeated the last value is kept
+func mergeEnvs(lists ...[]model.KeyValue) []model.KeyValue {
+	index := make(map[string]int)
+	var merged []model.KeyValue
+	for _, envs := range lists {
+		for _, env := range envs {
+			if i, ok := index[env.Key]; ok {
+				merged[i].Value = env.Value
+				continue
+			}
+			index[env.Key] = len(merged)
+			merged = append(merged, env)
+		}
+	}
+	return merged
+}
+
+// replaces the references to the applications already deployed, values contains
+// host, port and env.KEY of every application by component name
+func resolveEnvReferences(envs []model.KeyValue, values map[string]map[string]string) []model.KeyValue {
+	resolved := make([]model.KeyValue, 0, len(envs))
+	for _, env := range envs {
+		value := envReferenceRegex.ReplaceAllStringFunc(env.Value, func(ref string) string {
+			match := envReferenceRegex.FindStringSubmatch(ref)
+			return values[match[1]][match[2]]
+		})
+		resolved = append(resolved, model.KeyValue{Key: env.Key, Value: value})
+	}
+	return resolved
+}
+
+// returns the values the envs can reference of a deployed application
+func (c *Controller) applicationReferenceValues(app *model.Application, user *model.User) (map[string]string, error) {
+	secrets, err := c.decryptSecrets(app.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{
+		"host": serviceDNSName(app, user),
+		"port": app.ListeningPort,
+	}
+	for _, env := range append(append([]model.KeyValue{}, app.Envs...), secrets...) {
+		values["env."+env.Key] = env.Value
+	}
+	return values, nil
+}
+
+// checks the stack template and returns the templates of the components by name
+func (c *Controller) validateStackTemplate(ctx context.Context, stackTemplate *model.StackTemplate) (map[string]*model.Template, error) {
+	if len(stackTemplate.Components) == 0 {
+		return nil, ErrInvalidStackTemplate
+	}
+	templates := make(map[string]*model.Template, len(stackTemplate.Components))
+	for _, component := range stackTemplate.Components {
+		if !stackComponentNameRegex.MatchString(component.Name) {
+			return nil, ErrInvalidStackTemplate
+		}
+		if _, ok := templates[component.Name]; ok {
+			return nil, ErrInvalidStackTemplate
+		}
+		//only the components deployed before can be referenced
+		for _, env := range component.Envs {
+			for _, match := range envReferenceRegex.FindAllStringSubmatch(env.Value, -1) {
+				if _, ok := templates[match[1]]; !ok {
+					return nil, ErrInvalidStackTemplate
+				}
+			}
+		}
+		template, err := c.TemplateRepo.FindByCode(ctx, component.TemplateCode)
+		if err != nil {
+			if err == repo.ErrNotFound {
+				c.l.Errorf("template %s of stack component %s not found", component.TemplateCode, component.Name)
+				return nil, ErrInvalidStackTemplate
+			}
+			c.l.Errorf("error finding template %s: %v", component.TemplateCode, err)
+			return nil, err
+		}
+		if template.Deprecated {
+			return nil, ErrTemplateDeprecated
+		}
+		if component.Ingress && template.Kind != model.ApplicationKindManagment {
+			return nil, ErrInvalidStackTemplate
+		}
+		templates[component.Name] = template
+	}
+	for _, link := range stackTemplate.Links {
+		if _, ok := templates[link.From]; !ok {
+			return nil, ErrInvalidStackTemplate
+		}
+		to, ok := templates[link.To]
+		if !ok || link.From == link.To || to.Kind != model.ApplicationKindStorage || to.Link == nil {
+			return nil, ErrInvalidStackTemplate
+		}
+	}
+	return templates, nil
+}
+
+func (c *Controller) ListStackTemplates(ctx context.Context) ([]*model.StackTemplate, error) {
+	stackTemplates, err := c.StackTemplateRepo.FindAllAvailable(ctx)
+	if err != nil {
+		c.l.Errorf("error finding stack templates: %v", err)
+		return nil, err
+	}
+	return stackTemplates, nil
+}
+
+func (c *Controller) GetStackTemplateByCode(ctx context.Context, code string) (*model.StackTemplate, error) {
+	stackTemplate, err := c.StackTemplateRepo.FindByCode(ctx, code)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrStackTemplateNotFound
+		}
+		c.l.Errorf("error finding stack template by code %s: %v", code, err)
+		return nil, err
+	}
+	if !stackTemplate.Available {
+		return nil, ErrStackTemplateNotFound
+	}
+	return stackTemplate, nil
+}
+
+// deploys the components of the stack in order and then links them, if a component
+// fails the ones already created are deleted. envs contains the user envs of every
+// component, sharedEnvs are set in all of them. The generated envs are returned
+// by component name
+func (c *Controller) CreateStack(ctx context.Context, user *model.User, name string, stackTemplate *model.StackTemplate, envs map[string][]model.KeyValue, sharedEnvs []model.KeyValue) (*model.Stack, map[string][]model.KeyValue, error) {
+	fields := logrus.Fields{
+		"userID": user.Code,
+		"action": "CreateStack",
+		"stack":  stackTemplate.Code,
+	}
+
+	if !stackNameRegex.MatchString(name) {
+		return nil, nil, ErrInvalidStackName
+	}
+	templates, err := c.validateStackTemplate(ctx, stackTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateEnvs(sharedEnvs); err != nil {
+		return nil, nil, err
+	}
+	for component, componentEnvs := range envs {
+		if _, ok := templates[component]; !ok {
+			return nil, nil, ErrInvalidEnv
+		}
+		if err := validateEnvs(componentEnvs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := c.StackRepo.FindByNameAndOwner(ctx, name, user.Code); err == nil {
+		return nil, nil, ErrApplicationNameNotAvailable
+	} else if err != repo.ErrNotFound {
+		c.l.WithFields(fields).Errorf("error finding stack by name: %v", err)
+		return nil, nil, err
+	}
+	for _, component := range stackTemplate.Components {
+		appName := stackApplicationName(name, component.Name)
+		if !c.IsNameAvailableUserWide(ctx, appName, user.Code) ||
+			(component.Ingress && !c.IsNameAvailableSystemWide(ctx, appName)) {
+			return nil, nil, ErrApplicationNameNotAvailable
+		}
+	}
+	if err := c.checkApplicationsQuota(ctx, user, len(stackTemplate.Components)); err != nil {
+		return nil, nil, err
+	}
+
+	stack := &model.Stack{
+		Name:    name,
+		Owner:   user.Code,
+		BasedOn: stackTemplate.Code,
+		State:   model.ApplicationStateStarting,
+	}
+	if _, err := c.StackRepo.InsertOne(ctx, stack); err != nil {
+		c.l.WithFields(fields).Errorf("error inserting stack: %v", err)
+		return nil, nil, err
+	}
+	fields["stackID"] = stack.ID.Hex()
+
+	apps := make(map[string]*model.Application, len(stackTemplate.Components))
+	values := make(map[string]map[string]string, len(stackTemplate.Components))
+	generated := make(map[string][]model.KeyValue)
+	for _, component := range stackTemplate.Components {
+		appName := stackApplicationName(name, component.Name)
+		componentEnvs := mergeEnvs(stackTemplate.SharedEnvs, sharedEnvs, resolveEnvReferences(component.Envs, values), envs[component.Name])
+
+		app, componentGenerated, err := c.createTemplateApplication(ctx, user, appName, templates[component.Name], componentEnvs, templateApplicationOptions{
+			StackID: stack.ID,
+			Ingress: component.Ingress,
+		})
+		if err == nil && app.State != model.ApplicationStateRunning {
+			err = ErrStackComponentFailed
+		}
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error creating component %s: %v", component.Name, err)
+			c.rollbackStack(ctx, user, stack, appName)
+			return nil, nil, err
+		}
+		stack.Components = append(stack.Components, model.StackApplication{
+			Component:       component.Name,
+			ApplicationID:   app.ID,
+			ApplicationName: app.Name,
+		})
+		if _, err := c.StackRepo.UpdateByID(ctx, stack, stack.ID); err != nil {
+			c.l.WithFields(fields).Errorf("error updating stack: %v", err)
+		}
+		apps[component.Name] = app
+		if len(componentGenerated) > 0 {
+			generated[component.Name] = componentGenerated
+		}
+
+		values[component.Name], err = c.applicationReferenceValues(app, user)
+		if err != nil {
+			c.rollbackStack(ctx, user, stack, "")
+			return nil, nil, err
+		}
+	}
+
+	for _, link := range stackTemplate.Links {
+		if err := c.linkApplications(ctx, user, apps[link.From], apps[link.To], link.EnvName); err != nil {
+			c.l.WithFields(fields).Errorf("error linking component %s to %s: %v", link.From, link.To, err)
+			c.rollbackStack(ctx, user, stack, "")
+			return nil, nil, err
+		}
+	}
+
+	stack.State = model.ApplicationStateRunning
+	if _, err := c.StackRepo.UpdateByID(ctx, stack, stack.ID); err != nil {
+		c.l.WithFields(fields).Errorf("error updating stack: %v", err)
+		return nil, nil, err
+	}
+	c.l.WithFields(fields).Infof("stack %s created with %d components", stack.Name, len(stack.Components))
+	return stack, generated, nil
+}
+
+// deletes the applications created for a stack that failed to deploy, failedName
+// is the application that failed as it may have been inserted before failing
+func (c *Controller) rollbackStack(ctx context.Context, user *model.User, stack *model.Stack, failedName string) {
+	var apps []*model.Application
+	if failedName != "" {
+		app, err := c.ApplicationRepo.FindByNameAndOwner(ctx, failedName, user.Code)
+		if err == nil && app.StackID == stack.ID {
+			apps = append(apps, app)
+		}
+	}
+	for i := len(stack.Components) - 1; i >= 0; i-- {
+		app, err := c.ApplicationRepo.FindByID(ctx, stack.Components[i].ApplicationID)
+		if err != nil {
+			c.l.Errorf("error finding application %s of stack %s: %v", stack.Components[i].ApplicationID.Hex(), stack.ID.Hex(), err)
+			continue
+		}
+		apps = append(apps, app)
+	}
+	for _, app := range apps {
+		//the template applications are deployed synchronously, so they are not starting anymore
+		if app.State == model.ApplicationStateStarting {
+			if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, fmt.Sprintf("deployment of stack %s failed", stack.Name), model.StateTransitionActorSystem); err != nil {
+				c.l.Errorf("error failing application %s of failed stack %s: %v", app.ID.Hex(), stack.ID.Hex(), err)
+			}
+		}
+		if err := c.deleteApplication(ctx, app, user); err != nil {
+			c.l.Errorf("error deleting application %s of failed stack %s: %v", app.ID.Hex(), stack.ID.Hex(), err)
+		}
+	}
+	if _, err := c.StackRepo.DeleteByID(ctx, stack.ID); err != nil {
+		c.l.Errorf("error deleting failed stack %s: %v", stack.ID.Hex(), err)
+	}
+}
+
+func (c *Controller) ListStacks(ctx context.Context, user *model.User) ([]*model.Stack, error) {
+	stacks, err := c.StackRepo.FindByOwner(ctx, user.Code)
+	if err != nil {
+		c.l.Errorf("error finding stacks of user %s: %v", user.Code, err)
+		return nil, err
+	}
+	return stacks, nil
+}
+
+// returns the stack only if owned by the user
+func (c *Controller) GetStack(ctx context.Context, user *model.User, id primitive.ObjectID) (*model.Stack, error) {
+	stack, err := c.StackRepo.FindByID(ctx, id)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrStackNotFound
+		}
+		c.l.Errorf("error finding stack %s: %v", id.Hex(), err)
+		return nil, err
+	}
+	if stack.Owner != user.Code {
+		return nil, ErrStackNotFound
+	}
+	return stack, nil
+}
+
+// returns the applications of the stack in the order they were deployed
+func (c *Controller) GetStackApplications(ctx context.Context, stack *model.Stack) ([]*model.Application, error) {
+	apps := make([]*model.Application, 0, len(stack.Components))
+	for _, component := range stack.Components {
+		app, err := c.ApplicationRepo.FindByID(ctx, component.ApplicationID)
+		if err != nil {
+			if err == repo.ErrNotFound {
+				continue
+			}
+			c.l.Errorf("error finding application %s of stack %s: %v", component.ApplicationID.Hex(), stack.ID.Hex(), err)
+			return nil, err
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// deletes all the applications of the stack, starting from the last deployed one
+func (c *Controller) DeleteStack(ctx context.Context, user *model.User, stack *model.Stack) error {
+	if stack.State == model.ApplicationStateStarting {
+		return ErrInvalidOperationInCurrentState
+	}
+	apps, err := c.GetStackApplications(ctx, stack)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if app.State == model.ApplicationStateStarting {
+			return ErrInvalidOperationInCurrentState
+		}
+	}
+
+	stack.State = model.ApplicationStateDeleting
+	if _, err := c.StackRepo.UpdateByID(ctx, stack, stack.ID); err != nil {
+		c.l.Errorf("error updating stack %s: %v", stack.ID.Hex(), err)
+		return err
+	}
+	for i := len(apps) - 1; i >= 0; i-- {
+		if apps[i].State == model.ApplicationStateDeleting {
+			continue
+		}
+		if err := c.deleteApplication(ctx, apps[i], user); err != nil {
+			c.l.Errorf("error deleting application %s of stack %s: %v", apps[i].ID.Hex(), stack.ID.Hex(), err)
+			return err
+		}
+	}
+	if _, err := c.StackRepo.DeleteByID(ctx, stack.ID); err != nil {
+		c.l.Errorf("error deleting stack %s: %v", stack.ID.Hex(), err)
+		return err
+	}
+	c.l.Infof("stack %s of user %s deleted", stack.Name, user.Code)
+	return nil
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// moves the application to the given state and saves it together with the other
+// changes made to it, illegal transitions are rejected without saving anything.
+// The transition is recorded in the history of the application once saved
+func (c *Controller) TransitionApplication(ctx context.Context, app *model.Application, to model.ApplicationState, reason, actor string) error {
+	from := app.State
+	if !from.CanTransitionTo(to) {
+		c.l.WithFields(logrus.Fields{
+			"applicationID": app.ID.Hex(),
+			"action":        "TransitionApplication",
+			"actor":         actor,
+		}).Warnf("illegal transition from %s to %s: %s", from, to, reason)
+		return ErrInvalidStateTransition
+	}
+
+	app.State = to
+	if err := c.updateApplication(ctx, app); err != nil {
+		app.State = from
+		return err
+	}
+	c.recordStateTransition(ctx, app, from, to, reason, actor)
+	return nil
+}
+
+// like TransitionApplication but the changes are made by modify, if the application
+// was changed concurrently they are applied again to its latest version. It's used
+// by the long operations whose result must not be lost
+func (c *Controller) transitionApplicationLatest(ctx context.Context, app *model.Application, to model.ApplicationState, reason, actor string, modify func(app *model.Application)) error {
+	return c.transitionApplicationLatestFrom(ctx, app, "", to, reason, actor, modify)
+}
+
+// like transitionApplicationLatest but the state is changed only if the latest version
+// of the application is still in the expected state, otherwise only the changes made by
+// modify are saved (ex: the container events already moved the application to running).
+// An empty expected state accepts any state
+func (c *Controller) transitionApplicationLatestFrom(ctx context.Context, app *model.Application, expected, to model.ApplicationState, reason, actor string, modify func(app *model.Application)) error {
+	var from model.ApplicationState
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		from = app.State
+		if expected != "" && app.State != expected {
+			modify(app)
+			return nil
+		}
+		if !app.State.CanTransitionTo(to) {
+			c.l.WithFields(logrus.Fields{
+				"applicationID": app.ID.Hex(),
+				"action":        "TransitionApplication",
+				"actor":         actor,
+			}).Warnf("illegal transition from %s to %s: %s", app.State, to, reason)
+			return ErrInvalidStateTransition
+		}
+		modify(app)
+		app.State = to
+		return nil
+	}); err != nil {
+		return err
+	}
+	c.recordStateTransition(ctx, app, from, app.State, reason, actor)
+	return nil
+}
+
+// like TransitionApplication but only the state is saved without checking the version,
+// the transition wins over any concurrent update of the application (ex: the deletion)
+func (c *Controller) forceTransitionApplication(ctx context.Context, app *model.Application, to model.ApplicationState, reason, actor string) error {
+	from := app.State
+	if !from.CanTransitionTo(to) {
+		c.l.WithFields(logrus.Fields{
+			"applicationID": app.ID.Hex(),
+			"action":        "TransitionApplication",
+			"actor":         actor,
+		}).Warnf("illegal transition from %s to %s: %s", from, to, reason)
+		return ErrInvalidStateTransition
+	}
+
+	if err := c.ApplicationRepo.UpdateState(ctx, app.ID, to); err != nil {
+		c.l.Errorf("error updating application state: %v", err)
+		return err
+	}
+	app.State = to
+	c.recordStateTransition(ctx, app, from, to, reason, actor)
+	return nil
+}
+
+// saves the new application in the state it's created in, the creation is the
+// first transition in the history of the application
+func (c *Controller) insertNewApplication(ctx context.Context, app *model.Application, state model.ApplicationState, reason, actor string) error {
+	if !model.ApplicationState("").CanTransitionTo(state) {
+		c.l.WithFields(logrus.Fields{
+			"action": "insertNewApplication",
+			"actor":  actor,
+		}).Warnf("applications can not be created in state %s", state)
+		return ErrInvalidStateTransition
+	}
+
+	app.State = state
+	if err := c.InsertApplication(ctx, app); err != nil {
+		return err
+	}
+	c.recordStateTransition(ctx, app, "", state, reason, actor)
+	return nil
+}
+
+// failing to record a transition must not fail the operation, the error is only logged
+func (c *Controller) recordStateTransition(ctx context.Context, app *model.Application, from, to model.ApplicationState, reason, actor string) {
+	if from == to {
+		return
+	}
+	transition := &model.StateTransition{
+		CreatedAt:     time.Now(),
+		ApplicationID: app.ID,
+		From:          from,
+		To:            to,
+		Reason:        reason,
+		Actor:         actor,
+	}
+	if _, err := c.StateHistoryRepo.InsertOne(ctx, transition); err != nil {
+		c.l.WithFields(logrus.Fields{
+			"applicationID": app.ID.Hex(),
+			"from":          from,
+			"to":            to,
+			"actor":         actor,
+		}).Errorf("error recording state transition: %v", err)
+	}
+}
+
+// returns the state transitions of the application from the oldest to the newest
+func (c *Controller) ListApplicationStateHistory(ctx context.Context, app *model.Application) ([]*model.StateTransition, error) {
+	transitions, err := c.StateHistoryRepo.FindByApplicationID(ctx, app.ID)
+	if err != nil {
+		c.l.Errorf("error finding state history of application %s: %v", app.ID.Hex(), err)
+		return nil, err
+	}
+	return transitions, nil
+}
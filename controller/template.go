@@ -7,6 +7,7 @@ import (
 
 	"github.com/ipaas-org/ipaas-backend/model"
 	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // todo: use this function to check if the name is available for a database
@@ -17,18 +18,52 @@ func (c *Controller) IsNameAvailableUserWide(ctx context.Context, name, userCode
 	return available
 }
 
-func (c *Controller) CreateNewApplicationBasedOnTemplate(ctx context.Context, userCode, name string, template *model.Template, envs []model.KeyValue) (*model.Application, error) {
+// the envs the template can generate are created if not set, the generated values
+// are returned so they can be shown to the user, they are not retrievable later
+func (c *Controller) CreateNewApplicationBasedOnTemplate(ctx context.Context, userCode, name string, template *model.Template, envs []model.KeyValue) (*model.Application, []model.KeyValue, error) {
 	c.l.Debugf("creating a new application for %s based on template %s", userCode, template.Code)
+	if template.Deprecated {
+		return nil, nil, ErrTemplateDeprecated
+	}
+	user, err := c.UserRepo.FindByCode(ctx, userCode)
+	if err != nil {
+		c.l.Errorf("error finding user by code: %v", err)
+		return nil, nil, err
+	}
+	if err := c.checkApplicationQuota(ctx, user); err != nil {
+		return nil, nil, err
+	}
+	return c.createTemplateApplication(ctx, user, name, template, envs, templateApplicationOptions{Ingress: true})
+}
+
+type templateApplicationOptions struct {
+	StackID  primitive.ObjectID //stack the application is part of
+	TargetID primitive.ObjectID //storage application a managment one is bound to
+	Ingress  bool               //managment applications without ingress are only reachable inside the namespace
+}
+
+// creates the application and deploys it, the quota must be checked by the caller
+func (c *Controller) createTemplateApplication(ctx context.Context, user *model.User, name string, template *model.Template, envs []model.KeyValue, opts templateApplicationOptions) (*model.Application, []model.KeyValue, error) {
 	app := new(model.Application)
 	app.Name = name
 	app.Kind = template.Kind
-	app.State = model.ApplicationStateStarting
 	app.CreatedAt = time.Now()
-	app.Owner = userCode
+	app.Owner = user.Code
 	app.IsUpdatable = false
 	app.ListeningPort = template.ListeningPort
-	app.Envs = envs
 	app.BasedOn = template.Code
+	app.TemplateVersion = template.Version
+	app.StackID = opts.StackID
+	app.TargetID = opts.TargetID
+	app.Health = model.ApplicationHealthUnknown
+
+	generated, err := generateTemplateEnvs(template, envs)
+	if err != nil {
+		c.l.Errorf("error generating envs of template %s: %v", template.Code, err)
+		return nil, nil, err
+	}
+	envs = append(dropGeneratedEmptyEnvs(envs, generated), generated...)
+	app.Envs = envs
 
 	c.l.Debugf("default envs: %v", template.DefaultEnvs)
 	if template.DefaultEnvs != nil {
@@ -38,39 +73,63 @@ func (c *Controller) CreateNewApplicationBasedOnTemplate(ctx context.Context, us
 	for _, te := range template.RequiredEnvs {
 		var found bool
 		for _, e := range envs {
-			if e.Key == te.Key {
+			if e.Key == te.Key && e.Value != "" {
 				found = true
 				break
 			}
 		}
 		if !found {
-			return nil, ErrMissingRequiredEnvForTemplate
+			return nil, nil, ErrMissingRequiredEnvForTemplate
 		}
 	}
 
-	user, err := c.UserRepo.FindByCode(ctx, userCode)
+	//sensitive envs like passwords are never stored in plain text
+	var secrets []model.KeyValue
+	app.Envs, secrets = splitTemplateSecretEnvs(template, app.Envs)
+	app.Secrets, err = c.encryptSecrets(secrets)
 	if err != nil {
-		c.l.Errorf("error finding user by code: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch template.Kind {
 	case model.ApplicationKindStorage:
 		if err := c.createNewStorageKindService(ctx, template, app, user); err != nil {
 			c.l.Errorf("error creating storage service: %v", err)
-			return nil, err
+			return nil, nil, err
 		}
 
 	case model.ApplicationKindManagment:
-		if err := c.createNewManagmentKindService(ctx, template, app, user); err != nil {
+		if err := c.createNewManagmentKindService(ctx, template, app, user, opts.Ingress); err != nil {
 			c.l.Errorf("error creating managment service: %v", err)
-			return nil, err
+			return nil, nil, err
 		}
 	default:
-		return nil, ErrUnsupportedApplicationKind
+		return nil, nil, ErrUnsupportedApplicationKind
 	}
 
-	return app, nil
+	return app, generated, nil
+}
+
+// splits the envs in plain envs and the ones the template marks as secrets
+func splitTemplateSecretEnvs(template *model.Template, envs []model.KeyValue) ([]model.KeyValue, []model.KeyValue) {
+	secretKeys := make(map[string]bool, len(template.SecretEnvs))
+	for _, key := range template.SecretEnvs {
+		secretKeys[key] = true
+	}
+	for _, rule := range template.GeneratedEnvs {
+		if rule.Kind == model.GeneratedEnvKindPassword {
+			secretKeys[rule.Key] = true
+		}
+	}
+	var plain, secrets []model.KeyValue
+	for _, env := range envs {
+		if secretKeys[env.Key] {
+			secrets = append(secrets, env)
+		} else {
+			plain = append(plain, env)
+		}
+	}
+	return plain, secrets
 }
 
 func (c *Controller) GetTemplateByCode(ctx context.Context, code string) (*model.Template, error) {
@@ -98,7 +157,7 @@ func (c *Controller) createNewStorageKindService(ctx context.Context, template *
 	app.DnsName = app.Name
 	app.Visiblity = model.ApplicationVisiblityPrivate
 
-	if err := c.InsertApplication(ctx, app); err != nil {
+	if err := c.insertNewApplication(ctx, app, model.ApplicationStateStarting, fmt.Sprintf("application created from template %s", template.Code), user.Code); err != nil {
 		c.l.Errorf("error inserting application: %v", err)
 		return err
 	}
@@ -109,22 +168,45 @@ func (c *Controller) createNewStorageKindService(ctx context.Context, template *
 		c.l.Errorf("error creating config map for service %v:", err)
 	}
 
-	GiSize := int64(1 * 1024 * 1024 * 1024) // 1Gi
-	storageClass := "longhorn-test"
-	pvc, err := c.createPersistantVolumeClaim(ctx, app, user, storageClass, GiSize)
+	secret, err := c.createApplicationSecret(ctx, app, user)
+	if err != nil {
+		c.l.Errorf("error creating secret for service %v:", err)
+		if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, "unable to create the secret of the application", model.StateTransitionActorSystem); err != nil {
+			c.l.Errorf("error updating application: %v", err)
+		}
+		return err
+	}
+	secretName := ""
+	if secret != nil {
+		secretName = secret.Name
+	}
+
+	pvcName := fmt.Sprintf("pvc-%s-%s", app.Name, app.ID.Hex())
+	pvc, err := c.createPersistantVolumeClaim(ctx, app, user, pvcName, c.config.Volumes.DefaultStorageClass, c.config.Volumes.TemplateSize)
 	if err != nil {
 		c.l.Errorf("error creating PVC for service %v:", err)
+		if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, "unable to create the volume claim of the application", model.StateTransitionActorSystem); err != nil {
+			c.l.Errorf("error updating application: %v", err)
+		}
+		return err
 	}
 	volume := new(model.Volume)
 	volume.Name = fmt.Sprintf("vol-%s", app.Name)
 	volume.MountPath = template.PersistancePath
 	volume.PersistantVolumeClaim = pvc
+	volume.Size = c.config.Volumes.TemplateSize
 
-	deployment, err := c.createDeployment(ctx, app, user, template.ImageName, configMap.Name, volume)
+	deployment, err := c.createDeployment(ctx, app, user, template.ImageName, configMap.Name, secretName, []*model.Volume{volume}, template.Resources)
 	if err != nil {
 		c.l.Errorf("error creating deployment for service %v:", err)
+		if err := c.TransitionApplication(ctx, app, model.ApplicationStateFailed, "unable to create the deployment of the application", model.StateTransitionActorSystem); err != nil {
+			c.l.Errorf("error updating application: %v", err)
+		}
+		return err
 	}
 	deployment.ConfigMap = configMap
+	deployment.Secret = secret
+	deployment.Volume = volume
 
 	//watch for deployment ready state, it's non blocking so we just check at the end
 	done, errChan := c.ServiceManager.WaitDeploymentReadyState(ctx, user.Namespace, deployment.Name)
@@ -158,17 +240,10 @@ loop:
 	}
 	service.Deployment = deployment
 
-	if errWhileWaiting != nil {
-		//todo: handle waiting error, it's probably because it reached a timeout
-		//in this case it probably means that we reached a cpu/mem cap and we should
-		//expand the infra, it should really not happen
-		c.l.Errorf("internal error reached, this should not happen, check infrastructure resources left")
-		app.State = model.ApplicationStateFailed
-	} else {
-		app.State = model.ApplicationStateRunning
-		app.Service = service
-	}
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
+	//todo: handle waiting error, it's probably because it reached a timeout
+	//in this case it probably means that we reached a cpu/mem cap and we should
+	//expand the infra, it should really not happen
+	if err := c.completeApplicationStartup(ctx, app, service, app.DnsName, errWhileWaiting); err != nil {
 		c.l.Errorf("error updating application: %v", err)
 	}
 	// }()
@@ -176,16 +251,24 @@ loop:
 	return nil
 }
 
-func (c *Controller) createNewManagmentKindService(ctx context.Context, template *model.Template, app *model.Application, user *model.User) error {
-	if !c.IsNameAvailableSystemWide(ctx, app.Name) {
-		return ErrApplicationNameNotAvailable
+func (c *Controller) createNewManagmentKindService(ctx context.Context, template *model.Template, app *model.Application, user *model.User, ingress bool) error {
+	if ingress {
+		if !c.IsNameAvailableSystemWide(ctx, app.Name) {
+			return ErrApplicationNameNotAvailable
+		}
+		//todo: could also be a random string but for now lets just use the name
+		host := fmt.Sprintf("%s.%s", app.Name, c.app.BaseDefaultDomain)
+		app.DnsName = host
+		app.Visiblity = model.ApplicationVisiblityPublic
+	} else {
+		if !c.IsNameAvailableUserWide(ctx, app.Name, user.Code) {
+			return ErrApplicationNameNotAvailable
+		}
+		app.DnsName = app.Name
+		app.Visiblity = model.ApplicationVisiblityPrivate
 	}
-	//todo: could also be a random string but for now lets just use the name
-	host := fmt.Sprintf("%s.%s", app.Name, c.app.BaseDefaultDomain)
-	app.DnsName = host
-	app.Visiblity = model.ApplicationVisiblityPublic
 
-	if err := c.InsertApplication(ctx, app); err != nil {
+	if err := c.insertNewApplication(ctx, app, model.ApplicationStateStarting, fmt.Sprintf("application created from template %s", template.Code), user.Code); err != nil {
 		c.l.Errorf("error inserting application: %v", err)
 		return err
 	}
@@ -195,11 +278,21 @@ func (c *Controller) createNewManagmentKindService(ctx context.Context, template
 		return err
 	}
 
-	deployment, err := c.createDeployment(ctx, app, user, template.ImageName, configMap.Name, nil)
+	secret, err := c.createApplicationSecret(ctx, app, user)
+	if err != nil {
+		return err
+	}
+	secretName := ""
+	if secret != nil {
+		secretName = secret.Name
+	}
+
+	deployment, err := c.createDeployment(ctx, app, user, template.ImageName, configMap.Name, secretName, nil, template.Resources)
 	if err != nil {
 		return err
 	}
 	deployment.ConfigMap = configMap
+	deployment.Secret = secret
 
 	//watch for deployment ready state, it's non blocking so we just check at the end
 	done, errChan := c.ServiceManager.WaitDeploymentReadyState(ctx, user.Namespace, deployment.Name)
@@ -233,25 +326,16 @@ loop:
 	}
 	service.Deployment = deployment
 
-	ingressRoute, err := c.createIngressRoute(ctx, app, user, app.DnsName, service.Name, service.Port)
-	if err != nil {
-		return err
+	if ingress {
+		ingressRoute, err := c.createIngressRoute(ctx, app, user, app.DnsName, service.Name, service.Port)
+		if err != nil {
+			return err
+		}
+		service.IngressRoute = ingressRoute
 	}
-	service.IngressRoute = ingressRoute
 
-	if errWhileWaiting != nil {
-		//todo: handle waiting error, it's probably because it reached a timeout
-		//in this case it probably means that we reached a cpu/mem cap and we should
-		//expand the infra, it should really not happen
-		c.l.Errorf("internal error reached, this should not happen, check infrastructure resources left")
-		app.State = model.ApplicationStateFailed
-	} else {
-		app.State = model.ApplicationStateRunning
-		app.Service = service
-	}
-	if _, err := c.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-		c.l.Errorf("error updating application: %v", err)
-		return err
-	}
-	return nil
+	//todo: handle waiting error, it's probably because it reached a timeout
+	//in this case it probably means that we reached a cpu/mem cap and we should
+	//expand the infra, it should really not happen
+	return c.completeApplicationStartup(ctx, app, service, app.DnsName, errWhileWaiting)
 }
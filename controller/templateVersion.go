@@ -0,0 +1,362 @@
+package controller
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/sirupsen/logrus"
+)
+
+var templateCodeRegex = regexp.MustCompile(`^[A-Z0-9]{3,16}$`)
+
+// checks the fields needed to deploy an application based on the template
+func validateTemplate(template *model.Template) error {
+	if template.Name == "" || template.ImageName == "" {
+		return ErrInvalidTemplate
+	}
+	port, err := strconv.Atoi(template.ListeningPort)
+	if err != nil || port <= 0 || port > 65535 {
+		return ErrInvalidTemplate
+	}
+	switch template.Kind {
+	case model.ApplicationKindStorage:
+		if !path.IsAbs(template.PersistancePath) {
+			return ErrInvalidTemplate
+		}
+	case model.ApplicationKindManagment:
+	default:
+		return ErrInvalidTemplate
+	}
+	if template.Target != nil {
+		if template.Kind != model.ApplicationKindManagment || len(template.Target.Templates) == 0 {
+			return ErrInvalidTemplate
+		}
+		for _, env := range template.Target.Envs {
+			for _, match := range envReferenceRegex.FindAllStringSubmatch(env.Value, -1) {
+				if match[1] != targetReferenceComponent {
+					return ErrInvalidTemplate
+				}
+			}
+		}
+	}
+	for _, rule := range template.GeneratedEnvs {
+		if rule.Key == "" {
+			return ErrInvalidTemplate
+		}
+		if _, err := generateEnvValue(rule); err != nil {
+			return ErrInvalidTemplate
+		}
+	}
+	return nil
+}
+
+func (c *Controller) findLatestTemplate(ctx context.Context, code string) (*model.Template, error) {
+	template, err := c.TemplateRepo.FindByCode(ctx, code)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrTemplateNotFound
+		}
+		c.l.Errorf("error finding template by code %s: %v", code, err)
+		return nil, err
+	}
+	return template, nil
+}
+
+// returns the latest version of every template, deprecated and unavailable ones included
+func (c *Controller) AdminListTemplates(ctx context.Context) ([]*model.Template, error) {
+	templates, err := c.TemplateRepo.FindAll(ctx)
+	if err != nil {
+		c.l.Errorf("error finding templates: %v", err)
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (c *Controller) ListTemplateVersions(ctx context.Context, code string) ([]*model.Template, error) {
+	versions, err := c.TemplateRepo.FindVersionsByCode(ctx, code)
+	if err != nil {
+		c.l.Errorf("error finding versions of template %s: %v", code, err)
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	return versions, nil
+}
+
+// creates the first version of a new template
+func (c *Controller) CreateTemplate(ctx context.Context, template *model.Template) (*model.Template, error) {
+	if !templateCodeRegex.MatchString(template.Code) {
+		return nil, ErrInvalidTemplate
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	_, err := c.TemplateRepo.FindByCode(ctx, template.Code)
+	if err == nil {
+		return nil, ErrTemplateCodeNotAvailable
+	}
+	if err != repo.ErrNotFound {
+		c.l.Errorf("error finding template by code %s: %v", template.Code, err)
+		return nil, err
+	}
+
+	template.Version = 1
+	template.Deprecated = false
+	if _, err := c.TemplateRepo.InsertOne(ctx, template); err != nil {
+		c.l.Errorf("error inserting template %s: %v", template.Code, err)
+		return nil, err
+	}
+	c.l.Infof("template %s created", template.Code)
+	return template, nil
+}
+
+// creates a new version of the template, the existing applications keep using the
+// version they are pinned to until they are upgraded. The kind can't be changed
+func (c *Controller) CreateTemplateVersion(ctx context.Context, code string, template *model.Template) (*model.Template, error) {
+	latest, err := c.findLatestTemplate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if template.Kind != latest.Kind {
+		return nil, ErrInvalidTemplate
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	template.Code = code
+	template.Version = latest.Version + 1
+	template.Deprecated = latest.Deprecated
+	if _, err := c.TemplateRepo.InsertOne(ctx, template); err != nil {
+		c.l.Errorf("error inserting version %d of template %s: %v", template.Version, code, err)
+		return nil, err
+	}
+	c.l.Infof("version %d of template %s created", template.Version, code)
+	return template, nil
+}
+
+// updates the descriptive fields of the latest version of the template, empty values
+// are ignored. Changes to the deployed application require a new version
+func (c *Controller) UpdateTemplate(ctx context.Context, code, name, description, documentation string, available *bool) (*model.Template, error) {
+	template, err := c.findLatestTemplate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		template.Name = name
+	}
+	if description != "" {
+		template.Description = description
+	}
+	if documentation != "" {
+		template.Documentation = documentation
+	}
+	if available != nil {
+		template.Available = *available
+	}
+	if _, err := c.TemplateRepo.UpdateByCodeAndVersion(ctx, template, code, template.Version); err != nil {
+		c.l.Errorf("error updating template %s: %v", code, err)
+		return nil, err
+	}
+	return template, nil
+}
+
+// deprecated templates are hidden from the catalog and can't be used for new
+// applications, the existing ones keep working and can still be upgraded
+func (c *Controller) DeprecateTemplate(ctx context.Context, code string, deprecated bool) error {
+	versions, err := c.ListTemplateVersions(ctx, code)
+	if err != nil {
+		return err
+	}
+	for _, template := range versions {
+		if template.Deprecated == deprecated {
+			continue
+		}
+		template.Deprecated = deprecated
+		if _, err := c.TemplateRepo.UpdateByCodeAndVersion(ctx, template, code, template.Version); err != nil {
+			c.l.Errorf("error updating version %d of template %s: %v", template.Version, code, err)
+			return err
+		}
+	}
+	c.l.Infof("template %s deprecated=%t", code, deprecated)
+	return nil
+}
+
+// upgrades a storage application to a newer version of its template, version 0
+// means the latest one. The envs added by the new version are created (generated
+// when possible) and the generated values are returned, the data volume is kept
+func (c *Controller) UpgradeApplicationTemplate(ctx context.Context, app *model.Application, user *model.User, version int) ([]model.KeyValue, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "UpgradeApplicationTemplate",
+		"template":      app.BasedOn,
+		"fromVersion":   app.TemplateVersion,
+	}
+
+	if app.Kind != model.ApplicationKindStorage {
+		return nil, ErrInvalidOperationWithCurrentKind
+	}
+	if app.State != model.ApplicationStateRunning || app.Service == nil || app.Service.Deployment == nil {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+
+	var target *model.Template
+	var err error
+	if version == 0 {
+		target, err = c.TemplateRepo.FindByCode(ctx, app.BasedOn)
+	} else {
+		target, err = c.TemplateRepo.FindByCodeAndVersion(ctx, app.BasedOn, version)
+	}
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, ErrTemplateNotFound
+		}
+		c.l.WithFields(fields).Errorf("error finding template: %v", err)
+		return nil, err
+	}
+	if target.Version <= app.TemplateVersion {
+		return nil, ErrTemplateVersionNotNewer
+	}
+	fields["toVersion"] = target.Version
+
+	//only the envs missing in the application are added, the user values are kept
+	secrets, err := c.decryptSecrets(app.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	current := append(append([]model.KeyValue{}, app.Envs...), secrets...)
+	present := make(map[string]bool, len(current))
+	for _, env := range current {
+		present[env.Key] = true
+	}
+	var added []model.KeyValue
+	for _, env := range target.DefaultEnvs {
+		if !present[env.Key] {
+			added = append(added, env)
+			present[env.Key] = true
+		}
+	}
+	generated, err := generateTemplateEnvs(target, append(current, added...))
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range generated {
+		present[env.Key] = true
+	}
+	added = append(added, generated...)
+	for _, env := range target.RequiredEnvs {
+		if !present[env.Key] {
+			return nil, ErrMissingRequiredEnvForTemplate
+		}
+	}
+	plain, newSecrets := splitTemplateSecretEnvs(target, added)
+	encrypted, err := c.encryptSecrets(newSecrets)
+	if err != nil {
+		return nil, err
+	}
+	app.Envs = append(dropGeneratedEmptyEnvs(app.Envs, generated), plain...)
+	app.Secrets = append(app.Secrets, encrypted...)
+
+	deployment := app.Service.Deployment
+	if len(plain) > 0 {
+		if deployment.ConfigMap != nil {
+			updatedConfigMap, err := c.ServiceManager.UpdateConfigMap(ctx, user.Namespace, deployment.ConfigMap.Name, app.Envs)
+			if err != nil {
+				c.l.WithFields(fields).Errorf("error updating config map %s: %v", deployment.ConfigMap.Name, err)
+				return nil, err
+			}
+			deployment.ConfigMap = updatedConfigMap
+		} else {
+			configMap, err := c.createConfigMap(ctx, app, user, app.Envs)
+			if err != nil {
+				return nil, err
+			}
+			deployment.ConfigMap = configMap
+		}
+	}
+	if len(encrypted) > 0 {
+		data, err := c.decryptSecrets(app.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		if deployment.Secret != nil {
+			updatedSecret, err := c.ServiceManager.UpdateSecret(ctx, user.Namespace, deployment.Secret.Name, data)
+			if err != nil {
+				c.l.WithFields(fields).Errorf("error updating secret %s: %v", deployment.Secret.Name, err)
+				return nil, err
+			}
+			deployment.Secret = updatedSecret
+		} else {
+			secret, err := c.createSecret(ctx, app, user, data)
+			if err != nil {
+				return nil, err
+			}
+			deployment.Secret = secret
+		}
+	}
+	envSources, err := c.deploymentEnvSources(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(target.ListeningPort)
+	if err != nil {
+		return nil, ErrInvalidTemplate
+	}
+	if target.ListeningPort != app.ListeningPort {
+		updatedService, err := c.ServiceManager.UpdateService(ctx, user.Namespace, app.Service.Name, int32(port))
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error updating service: %v", err)
+			return nil, err
+		}
+		updatedService.Deployment = deployment
+		updatedService.IngressRoute = app.Service.IngressRoute
+		app.Service = updatedService
+		app.ListeningPort = target.ListeningPort
+	}
+	labels := make([]model.KeyValue, len(deployment.Labels))
+	copy(labels, deployment.Labels)
+	for i := range labels {
+		if labels[i].Key == model.PortLabel {
+			labels[i].Value = target.ListeningPort
+		}
+	}
+
+	updatedDeployment, err := c.ServiceManager.UpdateDeployment(ctx, user.Namespace, deployment.Name, target.ImageName, deployment.Replicas, int32(port), labels, envSources, app.HealthChecks)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error updating deployment %s: %v", deployment.Name, err)
+		return nil, convertServiceManagerError(err)
+	}
+	updatedDeployment, err = c.ServiceManager.UpdateDeploymentResources(ctx, user.Namespace, deployment.Name, target.Resources)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error updating resources of deployment %s: %v", deployment.Name, err)
+		return nil, convertServiceManagerError(err)
+	}
+	if deployment.Volume != nil && deployment.Volume.MountPath != target.PersistancePath {
+		deployment.Volume.MountPath = target.PersistancePath
+		updatedDeployment, err = c.ServiceManager.UpdateDeploymentVolumes(ctx, user.Namespace, deployment.Name, applicationVolumes(app))
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error updating volumes of deployment %s: %v", deployment.Name, err)
+			return nil, convertServiceManagerError(err)
+		}
+	}
+	updatedDeployment.ConfigMap = deployment.ConfigMap
+	updatedDeployment.Secret = deployment.Secret
+	updatedDeployment.Volume = deployment.Volume
+	app.Service.Deployment = updatedDeployment
+	app.TemplateVersion = target.Version
+
+	//the values of config maps and secrets are only read when the container starts
+	if err := c.saveAndRedeployApplication(ctx, user, app); err != nil {
+		return nil, err
+	}
+	c.syncStorageLinks(ctx, user, app)
+	c.l.WithFields(fields).Infof("application %s upgraded to version %d of template %s", app.Name, target.Version, target.Code)
+	return generated, nil
+}
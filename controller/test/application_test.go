@@ -25,6 +25,13 @@ func ConnectToRepository(conf *config.Config, l *logrus.Logger, c *controller.Co
 		c.TokenRepo = mock.NewTokenRepoer()
 		c.StateRepo = mock.NewStateRepoer()
 		c.ApplicationRepo = mock.NewApplicationRepoer()
+		c.TemplateRepo = mock.NewTemplateRepoer()
+		c.AuditRepo = mock.NewAuditRepoer()
+		c.StateHistoryRepo = mock.NewStateHistoryRepoer()
+		c.EnvGroupRepo = mock.NewEnvGroupRepoer()
+		c.ScheduledJobRepo = mock.NewScheduledJobRepoer()
+		c.NotificationChannelRepo = mock.NewNotificationChannelRepoer()
+		c.JobRunRepo = mock.NewJobRunRepoer()
 
 	case "mongo":
 		l.Info("using mongo database")
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func deploymentReplicas(t *testing.T, clientset *fake.Clientset, app *model.Application) int32 {
+	deployment, err := clientset.AppsV1().Deployments(testNamespace).Get(context.Background(), app.Service.Deployment.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting deployment: %v", err)
+	}
+	return *deployment.Spec.Replicas
+}
+
+func findApplication(t *testing.T, c *controller.Controller, app *model.Application) *model.Application {
+	stored, err := c.ApplicationRepo.FindByID(context.Background(), app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+	return stored
+}
+
+func TestCrashLoopThreshold(t *testing.T) {
+	c, conf, clientset := newFakeController(t)
+	ctx := context.Background()
+	threshold := conf.CrashLoop.RestartThreshold
+	if threshold < 2 {
+		t.Skipf("the restart threshold %d is too low to test crashes below it", threshold)
+	}
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	t.Run("crash below the threshold marks the application as crashed", func(t *testing.T) {
+		if err := c.RecordApplicationCrash(ctx, app, testNamespace, "test-app-pod", threshold-1, 1, "Error"); err != nil {
+			t.Fatalf("error recording crash: %v", err)
+		}
+		stored := findApplication(t, c, app)
+		if stored.State != model.ApplicationStateCrashed {
+			t.Errorf("application should be crashed, got %s", stored.State)
+		}
+		if stored.CrashLoop == nil || stored.CrashLoop.RestartCount != threshold-1 || stored.CrashLoop.LastExitCode != 1 {
+			t.Errorf("crash should be recorded, got %+v", stored.CrashLoop)
+		}
+		if deploymentReplicas(t, clientset, app) != 1 {
+			t.Errorf("deployment should not be scaled below the threshold")
+		}
+	})
+
+	t.Run("the same crash seen again is ignored", func(t *testing.T) {
+		version := app.Version
+		if err := c.RecordApplicationCrash(ctx, app, testNamespace, "test-app-pod", threshold-1, 1, "Error"); err != nil {
+			t.Fatalf("error recording crash again: %v", err)
+		}
+		if app.Version != version {
+			t.Errorf("the same crash should not update the application")
+		}
+	})
+
+	t.Run("stale application reaching the threshold is stopped", func(t *testing.T) {
+		//the container events changed the application after it was read
+		if err := c.ApplicationRepo.UpdateCurrentPod(ctx, app.ID, "test-app-pod-2"); err != nil {
+			t.Fatalf("error updating current pod: %v", err)
+		}
+		if err := c.RecordApplicationCrash(ctx, app, testNamespace, "test-app-pod-2", threshold, 1, "Error"); err != nil {
+			t.Fatalf("error recording crash at threshold: %v", err)
+		}
+		stored := findApplication(t, c, app)
+		if stored.State != model.ApplicationStateStopped || !stored.CrashLoop.Stopped {
+			t.Errorf("application should be stopped, got %s %+v", stored.State, stored.CrashLoop)
+		}
+		if deploymentReplicas(t, clientset, app) != 0 {
+			t.Errorf("deployment should be scaled to 0 at the threshold")
+		}
+		if stored.Service.Deployment.Replicas != 1 {
+			t.Errorf("replicas of the application should be kept to resume it, got %d", stored.Service.Deployment.Replicas)
+		}
+	})
+
+	t.Run("resuming restores the replicas and resets the counter", func(t *testing.T) {
+		if err := c.ResumeApplication(ctx, user, app); err != nil {
+			t.Fatalf("error resuming application: %v", err)
+		}
+		stored := findApplication(t, c, app)
+		if stored.State != model.ApplicationStateStarting || stored.CrashLoop.RestartCount != 0 {
+			t.Errorf("application should be starting with no restarts, got %s %+v", stored.State, stored.CrashLoop)
+		}
+		if deploymentReplicas(t, clientset, app) != 1 {
+			t.Errorf("deployment should be scaled back to 1")
+		}
+	})
+}
+
+func TestCrashLoopDuringRollout(t *testing.T) {
+	c, conf, clientset := newFakeController(t)
+	ctx := context.Background()
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "rollout-app")
+
+	//the rollout started after the application was read
+	if err := c.ApplicationRepo.UpdateState(ctx, app.ID, model.ApplicationStateRollingOut); err != nil {
+		t.Fatalf("error updating state: %v", err)
+	}
+	if err := c.RecordApplicationCrash(ctx, app, testNamespace, "rollout-app-pod", conf.CrashLoop.RestartThreshold, 1, "Error"); err != nil {
+		t.Fatalf("error recording crash: %v", err)
+	}
+
+	stored := findApplication(t, c, app)
+	if stored.State != model.ApplicationStateRollingOut {
+		t.Errorf("application should still be rolling out, got %s", stored.State)
+	}
+	if stored.CrashLoop == nil || stored.CrashLoop.Stopped {
+		t.Errorf("crash should be recorded without stopping the application, got %+v", stored.CrashLoop)
+	}
+	if deploymentReplicas(t, clientset, app) != 1 {
+		t.Errorf("deployment should not be scaled during the rollout")
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/config"
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/logger"
+	k8smanager "github.com/ipaas-org/ipaas-backend/services/serviceManager/k8s"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "test-namespace"
+
+// the config of the tests, only the sections used by the controller are set so
+// no config file or env variable is needed
+func newTestConfig() *config.Config {
+	conf := new(config.Config)
+	conf.App = config.App{Name: "ipaas-test", Version: "0.1.0", Deployment: "test", BaseDefaultDomain: "apps.test.local"}
+	conf.Database.Driver = "mock"
+	conf.K8s.CPUResource = "200m"
+	conf.K8s.MemoryResource = "512Mi"
+	conf.Rollout = config.Rollout{MaxSurge: "1", MaxUnavailable: "0", Deadline: 5 * time.Minute}
+	conf.Secrets.EncryptionKey = "test-encryption-key"
+	conf.Quota = config.Quota{
+		DefaultTier: "free",
+		Tiers: map[string]config.QuotaTier{
+			"free": {CPU: "2", Memory: "4Gi", Storage: "5Gi", Applications: 5, Pods: 10, ContainerCPU: "500m", ContainerMemory: "1Gi"},
+		},
+	}
+	conf.Volumes = config.Volumes{DefaultStorageClass: "standard", TemplateSize: 1, MaxSize: 10, MaxPerApplication: 5}
+	conf.Jobs = config.Jobs{MaxPerApplication: 5, History: 3, LogLines: 500, TaskTTL: 3600}
+	conf.Notifications = config.Notifications{RateLimit: 15 * time.Minute, Timeout: 10 * time.Second, MaxChannelsPerApplication: 5}
+	conf.CrashLoop = config.CrashLoop{RestartThreshold: 5, LogLines: 50}
+	return conf
+}
+
+// the controller is created on a fake clientset, the resources created by the
+// controller can be read from the returned clientset
+func newFakeController(t *testing.T) (*controller.Controller, *config.Config, *fake.Clientset) {
+	conf := newTestConfig()
+	l := logger.NewLogger("debug", "text")
+
+	clientset := fake.NewSimpleClientset()
+	manager, err := k8smanager.NewK8sOrchestratedServiceManagerForClientset(clientset, conf.K8s.CPUResource, conf.K8s.MemoryResource, conf.Rollout.MaxSurge, conf.Rollout.MaxUnavailable, conf.Rollout.Deadline)
+	if err != nil {
+		t.Fatalf("error creating fake k8s manager: %v", err)
+	}
+	c, err := controller.NewControllerWithServiceManager(conf, l, k8smanager.NewInstrumentedK8sOrchestratedServiceManager(manager))
+	if err != nil {
+		t.Fatalf("error creating controller: %v", err)
+	}
+	ConnectToRepository(conf, l, c)
+	return c, conf, clientset
+}
+
+func newTestUser(t *testing.T, c *controller.Controller) *model.User {
+	user := &model.User{
+		Code:      "test-user",
+		Namespace: testNamespace,
+	}
+	if _, err := c.UserRepo.InsertOne(context.Background(), user); err != nil {
+		t.Fatalf("error inserting user: %v", err)
+	}
+	return user
+}
+
+// inserts a running web application with its deployment in the fake cluster
+func newDeployedApplication(t *testing.T, c *controller.Controller, user *model.User, name string) *model.Application {
+	ctx := context.Background()
+	labels := []model.KeyValue{
+		{Key: model.IpaasManagedLabel, Value: "true"},
+		{Key: model.ResourceNameLabel, Value: name},
+	}
+	deployment, err := c.ServiceManager.CreateNewDeployment(ctx, user.Namespace, name, name, "ubuntu/nginx", 1, 80, labels, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("error creating deployment: %v", err)
+	}
+	deployment.CurrentPodName = name + "-pod"
+
+	app := &model.Application{
+		Name:          name,
+		Kind:          model.ApplicationKindWeb,
+		State:         model.ApplicationStateRunning,
+		Owner:         user.Code,
+		ListeningPort: "80",
+		Service: &model.Service{
+			BaseResource: model.BaseResource{Name: name},
+			Deployment:   deployment,
+		},
+	}
+	if err := c.InsertApplication(ctx, app); err != nil {
+		t.Fatalf("error inserting application: %v", err)
+	}
+	return app
+}
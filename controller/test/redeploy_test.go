@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// changes the current pod of the application right before its first full update,
+// like the container events do while a long operation is running
+type conflictingApplicationRepo struct {
+	repo.ApplicationRepoer
+	podName   string
+	conflicts int
+}
+
+func (r *conflictingApplicationRepo) UpdateByID(ctx context.Context, a *model.Application, id primitive.ObjectID) (bool, error) {
+	if r.conflicts == 0 {
+		r.conflicts++
+		if err := r.ApplicationRepoer.UpdateCurrentPod(ctx, id, r.podName); err != nil {
+			return false, err
+		}
+	}
+	return r.ApplicationRepoer.UpdateByID(ctx, a, id)
+}
+
+func TestRedeployApplication(t *testing.T) {
+	c, _, clientset := newFakeController(t)
+	ctx := context.Background()
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	stale, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+	if err := c.ApplicationRepo.UpdateState(ctx, app.ID, model.ApplicationStateCrashed); err != nil {
+		t.Fatalf("error updating state: %v", err)
+	}
+
+	t.Run("stale application is restarted", func(t *testing.T) {
+		if err := c.RedeployApplication(ctx, user, stale); err != nil {
+			t.Fatalf("error redeploying application: %v", err)
+		}
+		deployment, err := clientset.AppsV1().Deployments(testNamespace).Get(ctx, app.Service.Deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting deployment: %v", err)
+		}
+		if deployment.Spec.Template.Annotations["date"] == "" {
+			t.Errorf("deployment should be restarted")
+		}
+	})
+
+	t.Run("only the service is saved", func(t *testing.T) {
+		stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if stored.Service.Deployment.CurrentPodName != "" {
+			t.Errorf("current pod should be cleared, got %s", stored.Service.Deployment.CurrentPodName)
+		}
+		if stored.State != model.ApplicationStateCrashed {
+			t.Errorf("concurrent state change should be kept, got %s", stored.State)
+		}
+	})
+}
+
+func TestUpdateApplicationGeneralEnvs(t *testing.T) {
+	c, _, _ := newFakeController(t)
+	ctx := context.Background()
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	envs := []model.KeyValue{{Key: "TEST_KEY", Value: "test-value"}}
+	if err := c.UpdateApplicationGeneral(ctx, app, user, "", "", envs); err != nil {
+		t.Fatalf("error updating application: %v", err)
+	}
+	envs = append(envs, model.KeyValue{Key: "OTHER_KEY", Value: "other-value"})
+	if err := c.UpdateApplicationGeneral(ctx, app, user, "", "", envs); err != nil {
+		t.Fatalf("error updating application again: %v", err)
+	}
+
+	stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+	if len(stored.Envs) != 2 {
+		t.Errorf("expected 2 envs, got %v", stored.Envs)
+	}
+	if stored.Service.Deployment.ConfigMap == nil {
+		t.Errorf("config map of the envs should be saved")
+	}
+}
+
+func TestBuildResultRetriesOnConflict(t *testing.T) {
+	c, _, _ := newFakeController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	//stops the rollout watcher started by the build result
+	t.Cleanup(cancel)
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	c.ApplicationRepo = &conflictingApplicationRepo{
+		ApplicationRepoer: c.ApplicationRepo,
+		podName:           "new-pod",
+	}
+	build := &model.BuildResponse{
+		ApplicationID: app.ID.Hex(),
+		ImageName:     "ubuntu/nginx:new",
+		BuiltCommit:   "new-commit",
+	}
+	if err := c.CreateApplicationFromApplicationIDandImageID(ctx, app.ID.Hex(), build); err != nil {
+		t.Fatalf("error saving build result: %v", err)
+	}
+
+	stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+	if stored.State != model.ApplicationStateRollingOut {
+		t.Errorf("application should be rolling out, got %s", stored.State)
+	}
+	if stored.LastRollout == nil || stored.LastRollout.Status != model.RolloutStatusProgressing || stored.LastRollout.PreviousImage != "ubuntu/nginx" {
+		t.Errorf("progressing rollout from ubuntu/nginx should be saved, got %+v", stored.LastRollout)
+	}
+	if stored.Service.Deployment.ImageRegistry != build.ImageName || stored.BuiltCommit != build.BuiltCommit {
+		t.Errorf("new image and commit should be saved, got %s and %s", stored.Service.Deployment.ImageRegistry, stored.BuiltCommit)
+	}
+	if stored.Service.Deployment.CurrentPodName != "new-pod" {
+		t.Errorf("concurrent change of the current pod should be kept, got %s", stored.Service.Deployment.CurrentPodName)
+	}
+
+	history, err := c.ListApplicationStateHistory(ctx, stored)
+	if err != nil {
+		t.Fatalf("error listing state history: %v", err)
+	}
+	if len(history) != 1 || history[0].From != model.ApplicationStateRunning || history[0].To != model.ApplicationStateRollingOut {
+		t.Errorf("expected a single transition from running to rolling out, got %+v", history)
+	}
+}
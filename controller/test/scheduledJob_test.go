@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListApplicationJobRuns(t *testing.T) {
+	ctx := context.Background()
+	c, _, _ := newFakeController(t)
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "jobs-app")
+
+	task, err := c.RunApplicationTask(ctx, app, user, "echo migrate")
+	if err != nil {
+		t.Fatalf("error running task: %v", err)
+	}
+	//the job of this run was deleted from the cluster before it was synced
+	orphan := &model.JobRun{
+		ID:            primitive.NewObjectID(),
+		CreatedAt:     time.Now().Add(-time.Hour),
+		ApplicationID: app.ID,
+		Owner:         user.Code,
+		Kind:          model.JobRunKindTask,
+		Status:        model.JobStatusRunning,
+		JobName:       "deleted-job",
+	}
+	if _, err := c.JobRunRepo.InsertOne(ctx, orphan); err != nil {
+		t.Fatalf("error inserting job run: %v", err)
+	}
+
+	runs, err := c.ListApplicationJobRuns(ctx, app, user)
+	if err != nil {
+		t.Fatalf("error listing job runs: %v", err)
+	}
+	statuses := make(map[primitive.ObjectID]model.JobStatus, len(runs))
+	for _, run := range runs {
+		statuses[run.ID] = run.Status
+	}
+
+	t.Run("run without job is failed", func(t *testing.T) {
+		if statuses[orphan.ID] != model.JobStatusFailed {
+			t.Fatalf("expected the run to be %s, got %q", model.JobStatusFailed, statuses[orphan.ID])
+		}
+	})
+
+	t.Run("run with job is kept", func(t *testing.T) {
+		if statuses[task.ID].IsFinished() {
+			t.Fatalf("expected the run to be unfinished, got %q", statuses[task.ID])
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetApplicationSecret(t *testing.T) {
+	c, _, clientset := newFakeController(t)
+	ctx := context.Background()
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	//the application is changed after it was read by the request
+	if err := c.ApplicationRepo.UpdateState(ctx, app.ID, model.ApplicationStateCrashed); err != nil {
+		t.Fatalf("error updating state: %v", err)
+	}
+
+	t.Run("secret is saved on the latest version", func(t *testing.T) {
+		if err := c.SetApplicationSecret(ctx, app, user, "API_KEY", "first"); err != nil {
+			t.Fatalf("error setting secret: %v", err)
+		}
+		stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if len(stored.Secrets) != 1 || stored.Secrets[0].Key != "API_KEY" {
+			t.Errorf("secret should be saved, got %+v", stored.Secrets)
+		}
+		if stored.State != model.ApplicationStateCrashed {
+			t.Errorf("concurrent state change should be kept, got %s", stored.State)
+		}
+		if stored.Service.Deployment.Secret == nil {
+			t.Fatalf("kubernetes secret should be saved in the deployment")
+		}
+	})
+
+	t.Run("kubernetes secret holds the saved value", func(t *testing.T) {
+		if err := c.SetApplicationSecret(ctx, app, user, "API_KEY", "second"); err != nil {
+			t.Fatalf("error updating secret: %v", err)
+		}
+		secret, err := clientset.CoreV1().Secrets(testNamespace).Get(ctx, app.Service.Deployment.Secret.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting secret: %v", err)
+		}
+		if secret.StringData["API_KEY"] != "second" {
+			t.Errorf("secret should hold the updated value, got %q", secret.StringData["API_KEY"])
+		}
+	})
+
+	t.Run("missing secret is not deleted", func(t *testing.T) {
+		stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if err := c.DeleteApplicationSecret(ctx, stored, user, "MISSING"); err == nil {
+			t.Errorf("deleting a missing secret should fail")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fails the failOn-th full update of the applications
+type flakyApplicationRepo struct {
+	repo.ApplicationRepoer
+	calls  int
+	failOn int
+}
+
+func (r *flakyApplicationRepo) UpdateByID(ctx context.Context, a *model.Application, id primitive.ObjectID) (bool, error) {
+	r.calls++
+	if r.calls == r.failOn {
+		return false, errors.New("update failed")
+	}
+	return r.ApplicationRepoer.UpdateByID(ctx, a, id)
+}
+
+func countVolumeClaims(t *testing.T, clientset *fake.Clientset) int {
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims(testNamespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing volume claims: %v", err)
+	}
+	return len(pvcs.Items)
+}
+
+func TestApplicationVolume(t *testing.T) {
+	c, _, clientset := newFakeController(t)
+	ctx := context.Background()
+	user := newTestUser(t, c)
+	app := newDeployedApplication(t, c, user, "test-app")
+
+	t.Run("claim of a volume that can not be saved is deleted", func(t *testing.T) {
+		applicationRepo := c.ApplicationRepo
+		//the volume is saved, saving its claim fails
+		c.ApplicationRepo = &flakyApplicationRepo{ApplicationRepoer: applicationRepo, failOn: 2}
+		defer func() { c.ApplicationRepo = applicationRepo }()
+
+		if _, err := c.AddApplicationVolume(ctx, app, user, "data", "/data", 1); err == nil {
+			t.Fatalf("adding the volume should fail when its claim can not be saved")
+		}
+		if n := countVolumeClaims(t, clientset); n != 0 {
+			t.Errorf("claim should be deleted, found %d", n)
+		}
+		stored, err := applicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if len(stored.Volumes) != 0 {
+			t.Errorf("volume should be removed, got %+v", stored.Volumes)
+		}
+	})
+
+	t.Run("volume is saved with its claim", func(t *testing.T) {
+		volume, err := c.AddApplicationVolume(ctx, app, user, "data", "/data", 1)
+		if err != nil {
+			t.Fatalf("error adding volume: %v", err)
+		}
+		if volume.PersistantVolumeClaim == nil {
+			t.Fatalf("returned volume should have its claim")
+		}
+		stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if len(stored.Volumes) != 1 || stored.Volumes[0].PersistantVolumeClaim == nil {
+			t.Errorf("volume should be saved with its claim, got %+v", stored.Volumes)
+		}
+		if n := countVolumeClaims(t, clientset); n != 1 {
+			t.Errorf("expected 1 claim, found %d", n)
+		}
+	})
+
+	t.Run("removed volume deletes its claim", func(t *testing.T) {
+		stored, err := c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if err := c.RemoveApplicationVolume(ctx, stored, user, "data"); err != nil {
+			t.Fatalf("error removing volume: %v", err)
+		}
+		stored, err = c.ApplicationRepo.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if len(stored.Volumes) != 0 {
+			t.Errorf("volume should be removed, got %+v", stored.Volumes)
+		}
+		if n := countVolumeClaims(t, clientset); n != 0 {
+			t.Errorf("claim should be deleted, found %d", n)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// checks if the user can open a tunnel to the application, only storage
+// applications can be tunneled since they are not exposed publicly
+func (c *Controller) CanTunnelApplication(app *model.Application, user *model.User) error {
+	if !c.config.Tunnel.Enabled {
+		return ErrTunnelDisabled
+	}
+	if app.Kind != model.ApplicationKindStorage {
+		return ErrTunnelNotSupported
+	}
+	if app.State != model.ApplicationStateRunning {
+		return ErrInvalidOperationInCurrentState
+	}
+	if app.Service == nil || app.Service.Deployment == nil || app.Service.Deployment.CurrentPodName == "" {
+		return ErrInvalidOperationInCurrentState
+	}
+	return nil
+}
+
+// forwards conn to the listening port of the current pod of the application,
+// blocks until one of the sides closes the connection, the context is cancelled
+// or the tunnel lasts more than the configured max duration.
+// The opening and the closing of the tunnel are recorded in the audit log
+func (c *Controller) TunnelApplication(ctx context.Context, app *model.Application, user *model.User, conn io.ReadWriter) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "TunnelApplication",
+	}
+
+	if err := c.CanTunnelApplication(app, user); err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(app.ListeningPort)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("invalid listening port %q: %v", app.ListeningPort, err)
+		return ErrInvalidPort
+	}
+
+	podName := app.Service.Deployment.CurrentPodName
+	target := fmt.Sprintf("%s:%d", podName, port)
+	ctx, cancel := context.WithTimeout(ctx, c.config.Tunnel.MaxDuration)
+	defer cancel()
+
+	c.recordAuditEvent(ctx, user, app, model.AuditActionTunnelOpened, target)
+	c.l.WithFields(fields).Infof("tunnel to %s of application %s opened", target, app.Name)
+
+	start := time.Now()
+	err = c.ServiceManager.PortForwardPod(ctx, user.Namespace, podName, port, conn)
+
+	details := fmt.Sprintf("duration: %s", time.Since(start).Round(time.Second))
+	if err != nil {
+		details += fmt.Sprintf(", error: %v", err)
+	}
+	//the request context might be already done when the client disconnects
+	c.insertAuditEvent(context.Background(), &model.AuditEvent{
+		CreatedAt:     time.Now(),
+		UserCode:      user.Code,
+		ApplicationID: app.ID,
+		Action:        model.AuditActionTunnelClosed,
+		Target:        target,
+		Details:       details,
+	})
+
+	if err != nil {
+		c.l.WithFields(fields).Errorf("tunnel to %s closed with error: %v", target, err)
+		return err
+	}
+	c.l.WithFields(fields).Infof("tunnel to %s of application %s closed", target, app.Name)
+	return nil
+}
@@ -49,6 +49,11 @@ func (c *Controller) CreateUser(ctx context.Context, info *model.UserInfo, role
 		return nil, err
 	}
 	user.Namespace = namespace
+	user.QuotaTier = c.config.Quota.DefaultTier
+
+	if err := c.createUserQuota(ctx, user); err != nil {
+		return nil, err
+	}
 
 	if _, err := c.ServiceManager.CreateNewRegistrySecret(ctx, namespace, staticPullSecretName, c.config.K8s.RegistryUrl, c.config.K8s.RegistryUsername, c.config.K8s.RegistryPassword); err != nil {
 		c.l.Errorf("error creating new registry secret: %v", err)
@@ -0,0 +1,342 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/sirupsen/logrus"
+)
+
+// the name is used in the kubernetes resource names so it must be a valid dns label
+var volumeNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,28}[a-z0-9])?$`)
+
+func volumePVCName(app *model.Application, volumeName string) string {
+	return fmt.Sprintf("pvc-%s-%s-%s", app.Name, volumeName, app.ID.Hex())
+}
+
+// returns all the volumes mounted in the application deployment, the data
+// volume of the storage templates comes first
+func applicationVolumes(app *model.Application) []*model.Volume {
+	volumes := make([]*model.Volume, 0, len(app.Volumes)+1)
+	if app.Service != nil && app.Service.Deployment != nil && app.Service.Deployment.Volume != nil {
+		volumes = append(volumes, app.Service.Deployment.Volume)
+	}
+	return append(volumes, app.Volumes...)
+}
+
+func (c *Controller) validateVolumeSize(size int64) error {
+	if size <= 0 || (c.config.Volumes.MaxSize > 0 && size > c.config.Volumes.MaxSize) {
+		return ErrInvalidVolumeSize
+	}
+	return nil
+}
+
+func (c *Controller) validateVolume(app *model.Application, name, mountPath string, size int64) error {
+	if !volumeNameRegex.MatchString(name) {
+		return ErrInvalidVolume
+	}
+	if !path.IsAbs(mountPath) || path.Clean(mountPath) == "/" {
+		return ErrInvalidVolume
+	}
+	if err := c.validateVolumeSize(size); err != nil {
+		return err
+	}
+	if c.config.Volumes.MaxPerApplication > 0 && len(app.Volumes) >= c.config.Volumes.MaxPerApplication {
+		return ErrQuotaExceeded
+	}
+	for _, volume := range applicationVolumes(app) {
+		if volume.Name == name || path.Clean(volume.MountPath) == path.Clean(mountPath) {
+			return ErrVolumeNameNotAvailable
+		}
+	}
+	return nil
+}
+
+// creates the pvc of the user volumes that don't have one yet
+func (c *Controller) createApplicationVolumes(ctx context.Context, app *model.Application, user *model.User) error {
+	for _, volume := range app.Volumes {
+		if volume.PersistantVolumeClaim != nil {
+			continue
+		}
+		pvc, err := c.createPersistantVolumeClaim(ctx, app, user, volumePVCName(app, volume.Name), c.config.Volumes.DefaultStorageClass, volume.Size)
+		if err != nil {
+			return err
+		}
+		volume.PersistantVolumeClaim = pvc
+	}
+	return nil
+}
+
+func (c *Controller) deleteApplicationVolumes(ctx context.Context, app *model.Application, user *model.User) error {
+	for _, volume := range app.Volumes {
+		if volume.PersistantVolumeClaim == nil {
+			continue
+		}
+		c.l.Debugf("deleting PVC %s", volume.PersistantVolumeClaim.Name)
+		if err := c.ServiceManager.DeletePersistantVolumeClmain(ctx, user.Namespace, volume.PersistantVolumeClaim.Name, gracePeriod); err != nil {
+			c.l.Errorf("error deleting PVC %s: %v", volume.PersistantVolumeClaim.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func findApplicationVolume(app *model.Application, name string) *model.Volume {
+	for _, volume := range applicationVolumes(app) {
+		if volume.Name == name {
+			return volume
+		}
+	}
+	return nil
+}
+
+func removeApplicationVolume(app *model.Application, name string) *model.Volume {
+	for i, volume := range app.Volumes {
+		if volume.Name == name {
+			app.Volumes = append(app.Volumes[:i], app.Volumes[i+1:]...)
+			return volume
+		}
+	}
+	return nil
+}
+
+// adds a persistent volume to the application, the size is in Gi. Applications
+// without a deployment create it on deploy, the others are restarted to mount it.
+// The volume is saved before its pvc is created and the pvc right after, so that
+// the application always knows the pvcs it owns
+func (c *Controller) AddApplicationVolume(ctx context.Context, app *model.Application, user *model.User, name, mountPath string, size int64) (*model.Volume, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "AddApplicationVolume",
+		"volume":        name,
+	}
+
+	volume := &model.Volume{
+		Name:      name,
+		MountPath: path.Clean(mountPath),
+		Size:      size,
+	}
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		if app.State == model.ApplicationStateDeleting || app.State == model.ApplicationStateStarting {
+			return ErrInvalidOperationInCurrentState
+		}
+		if err := c.validateVolume(app, name, mountPath, size); err != nil {
+			return err
+		}
+		app.Volumes = append(app.Volumes, volume)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if app.Service == nil || app.Service.Deployment == nil {
+		c.l.WithFields(fields).Infof("volume %s added, it will be created on deploy", name)
+		return volume, nil
+	}
+
+	pvc, err := c.createPersistantVolumeClaim(ctx, app, user, volumePVCName(app, name), c.config.Volumes.DefaultStorageClass, size)
+	if err != nil {
+		c.l.WithFields(fields).Errorf("error creating volume: %v", err)
+		c.removeUnmountedVolume(ctx, app, user, name, nil)
+		return nil, err
+	}
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		saved := findApplicationVolume(app, name)
+		if saved == nil {
+			return ErrVolumeNotFound
+		}
+		saved.PersistantVolumeClaim = pvc
+		return nil
+	}); err != nil {
+		c.l.WithFields(fields).Errorf("error saving volume claim: %v", err)
+		c.removeUnmountedVolume(ctx, app, user, name, pvc)
+		return nil, err
+	}
+	volume.PersistantVolumeClaim = pvc
+
+	//the volume is owned by the application from now on, if it can't be mounted
+	//it can still be removed by the user
+	if err := c.applyApplicationVolumes(ctx, app, user); err != nil {
+		c.l.WithFields(fields).Errorf("error mounting volume: %v", err)
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("volume %s added to application %s", name, app.Name)
+	return volume, nil
+}
+
+// undoes a volume that could not be created, it's not mounted by the deployment yet
+func (c *Controller) removeUnmountedVolume(ctx context.Context, app *model.Application, user *model.User, name string, pvc *model.PersistentVolumeClaim) {
+	if pvc != nil {
+		if err := c.ServiceManager.DeletePersistantVolumeClmain(ctx, user.Namespace, pvc.Name, gracePeriod); err != nil {
+			c.l.Errorf("error deleting PVC %s: %v", pvc.Name, err)
+			//the volume is kept so the pvc can still be removed by the user
+			return
+		}
+	}
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		removeApplicationVolume(app, name)
+		return nil
+	}); err != nil {
+		c.l.Errorf("error removing volume %s of application %s: %v", name, app.ID.Hex(), err)
+	}
+}
+
+// removes the volume from the application and deletes its pvc, the data is lost.
+// The volume is unmounted first and removed from the application before deleting
+// the pvc, a failed removal keeps the pvc in the application so it can be run again
+func (c *Controller) RemoveApplicationVolume(ctx context.Context, app *model.Application, user *model.User, name string) error {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "RemoveApplicationVolume",
+		"volume":        name,
+	}
+
+	if app.State == model.ApplicationStateDeleting || app.State == model.ApplicationStateStarting {
+		return ErrInvalidOperationInCurrentState
+	}
+
+	volume := removeApplicationVolume(app, name)
+	if volume == nil {
+		return ErrVolumeNotFound
+	}
+
+	if app.Service != nil && app.Service.Deployment != nil {
+		if err := c.applyApplicationVolumes(ctx, app, user); err != nil {
+			c.l.WithFields(fields).Errorf("error unmounting volume: %v", err)
+			return err
+		}
+	}
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		removeApplicationVolume(app, name)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	//the pvc is only removed by kubernetes after the pods stop using it
+	if volume.PersistantVolumeClaim != nil {
+		if err := c.ServiceManager.DeletePersistantVolumeClmain(ctx, user.Namespace, volume.PersistantVolumeClaim.Name, gracePeriod); err != nil {
+			c.l.WithFields(fields).Errorf("error deleting PVC %s: %v", volume.PersistantVolumeClaim.Name, err)
+			return err
+		}
+	}
+	c.l.WithFields(fields).Infof("volume %s removed from application %s", name, app.Name)
+	return nil
+}
+
+// increases the size of a volume, the size is in Gi and can not be reduced.
+// The data volume of the storage templates can also be resized
+func (c *Controller) ResizeApplicationVolume(ctx context.Context, app *model.Application, user *model.User, name string, size int64) (*model.Volume, error) {
+	fields := logrus.Fields{
+		"applicationID": app.ID.Hex(),
+		"userID":        user.Code,
+		"action":        "ResizeApplicationVolume",
+		"volume":        name,
+		"size":          size,
+	}
+
+	if app.State == model.ApplicationStateDeleting {
+		return nil, ErrInvalidOperationInCurrentState
+	}
+
+	volume := findApplicationVolume(app, name)
+	if volume == nil {
+		return nil, ErrVolumeNotFound
+	}
+	if err := c.validateVolumeSize(size); err != nil {
+		return nil, err
+	}
+	current := volume.Size
+	if volume.PersistantVolumeClaim != nil {
+		current = volume.PersistantVolumeClaim.StorageSize / gibibyte
+	}
+	if size <= current {
+		return nil, ErrInvalidVolumeSize
+	}
+
+	pvc := volume.PersistantVolumeClaim
+	if pvc != nil {
+		var err error
+		pvc, err = c.ServiceManager.ResizePersistentVolumeClaim(ctx, user.Namespace, pvc.Name, size*gibibyte)
+		if err != nil {
+			c.l.WithFields(fields).Errorf("error resizing PVC %s: %v", volume.PersistantVolumeClaim.Name, err)
+			return nil, convertServiceManagerError(err)
+		}
+	}
+	//the pvc is already resized, the new size must not be lost
+	if err := c.modifyApplication(ctx, app, func(app *model.Application) error {
+		volume = findApplicationVolume(app, name)
+		if volume == nil {
+			return ErrVolumeNotFound
+		}
+		if pvc != nil {
+			volume.PersistantVolumeClaim = pvc
+		}
+		volume.Size = size
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	c.l.WithFields(fields).Infof("volume %s of application %s resized to %dGi", name, app.Name, size)
+	return volume, nil
+}
+
+// returns the volumes of all the user applications, if the usage can not be read
+// from the cluster the volumes are returned without it
+func (c *Controller) ListUserVolumes(ctx context.Context, user *model.User) ([]*model.VolumeUsage, error) {
+	apps, err := c.ApplicationRepo.FindByOwner(ctx, user.Code)
+	if err != nil {
+		c.l.Errorf("error finding applications of user %s: %v", user.Code, err)
+		return nil, err
+	}
+
+	usage, err := c.ServiceManager.GetPersistentVolumeClaimsUsage(ctx, user.Namespace)
+	if err != nil {
+		c.l.Warnf("error getting volumes usage of user %s: %v", user.Code, err)
+		usage = nil
+	}
+
+	volumes := make([]*model.VolumeUsage, 0)
+	for _, app := range apps {
+		for _, volume := range applicationVolumes(app) {
+			v := &model.VolumeUsage{
+				ApplicationID:   app.ID,
+				ApplicationName: app.Name,
+				Name:            volume.Name,
+				MountPath:       volume.MountPath,
+				Size:            volume.Size * gibibyte,
+				Used:            -1,
+				Template:        app.Service != nil && app.Service.Deployment != nil && volume == app.Service.Deployment.Volume,
+			}
+			if pvc := volume.PersistantVolumeClaim; pvc != nil {
+				v.StorageClassName = pvc.StorageClassName
+				v.Size = pvc.StorageSize
+				if used, ok := usage[pvc.Name]; ok {
+					v.Used = used
+				}
+			}
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes, nil
+}
+
+// mounts the current volumes of the application in its deployment and restarts it,
+// the volumes are saved by the callers
+func (c *Controller) applyApplicationVolumes(ctx context.Context, app *model.Application, user *model.User) error {
+	updatedDeployment, err := c.ServiceManager.UpdateDeploymentVolumes(ctx, user.Namespace, app.Service.Deployment.Name, applicationVolumes(app))
+	if err != nil {
+		c.l.Errorf("error updating volumes of deployment %s: %v", app.Service.Deployment.Name, err)
+		return convertServiceManagerError(err)
+	}
+	updatedDeployment.ConfigMap = app.Service.Deployment.ConfigMap
+	updatedDeployment.Secret = app.Service.Deployment.Secret
+	updatedDeployment.Volume = app.Service.Deployment.Volume
+	app.Service.Deployment = updatedDeployment
+
+	return c.RedeployApplication(ctx, user, app)
+}
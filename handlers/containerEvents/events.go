@@ -2,52 +2,73 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
+	"sync"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/controller"
 	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/watch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 )
 
-type watchGeneratorFunc func(context.Context) (watch.Interface, error)
+const (
+	// times an application is retried with backoff before its changes are dropped,
+	// the next event or resync of the application queues it again
+	maxSyncRetries = 5
+	// annotation set by kubernetes on deployments and replica sets
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
 
-var FakeWatchGeneratorFunc = func(ctx context.Context) (watch.Interface, error) {
-	return nil, nil
-}
+type informerFactoryGeneratorFunc func(resync time.Duration) informers.SharedInformerFactory
 
+// ContainerEventHandler keeps the state of the applications in sync with their pods.
+// The pods, deployments and replica sets are watched by shared informers and every
+// change queues the id of the application, each application is then synced from
+// the informers cache by a single worker at a time
 type ContainerEventHandler struct {
-	watch          watch.Interface
-	watchGenerator watchGeneratorFunc
-	controller     *controller.Controller
-	l              *logrus.Logger
-	Done           chan struct{}
+	informerFactoryGenerator informerFactoryGeneratorFunc
+	resync                   time.Duration
+	workers                  int
+
+	queue            workqueue.RateLimitingInterface
+	podLister        corelisters.PodLister
+	deploymentLister appslisters.DeploymentLister
+	replicaSetLister appslisters.ReplicaSetLister
+
+	controller *controller.Controller
+	l          *logrus.Logger
+	Done       chan struct{}
 }
 
-func NewContainerEventHandler(ctx context.Context, controller *controller.Controller, watchGenerator watchGeneratorFunc, l *logrus.Logger) (*ContainerEventHandler, error) {
+func NewContainerEventHandler(controller *controller.Controller, informerFactoryGenerator informerFactoryGeneratorFunc, resync time.Duration, workers int, l *logrus.Logger) *ContainerEventHandler {
 	l.Debug("creating container handler")
-	c := new(ContainerEventHandler)
-	c.controller = controller
-	c.l = l
-	c.Done = make(chan struct{})
-	c.watchGenerator = watchGenerator
-	w, err := c.watchGenerator(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("error getting watch: %v", err)
+	if workers <= 0 {
+		workers = 1
+	}
+	return &ContainerEventHandler{
+		informerFactoryGenerator: informerFactoryGenerator,
+		resync:                   resync,
+		workers:                  workers,
+		controller:               controller,
+		l:                        l,
+		Done:                     make(chan struct{}),
 	}
-	l.Debug("created watch for container events")
-	c.watch = w
-	return c, nil
 }
 
 func StartContainerEventHandler(ctx context.Context, c *ContainerEventHandler, ID int, RoutineMonitor chan int) {
 	defer func() {
-		if c.watch != nil {
-			c.watch.Stop()
-		}
 		if r := recover(); r != nil {
 			c.l.Errorf("container event handler panic, recovering: \nerror: %v\n\nstack: %s", r, string(debug.Stack()))
 		}
@@ -60,186 +81,397 @@ func StartContainerEventHandler(ctx context.Context, c *ContainerEventHandler, I
 	}()
 
 	c.l.Info(">>> Starting ContainerEventHandler >>>")
-
-	controller := c.controller
-	logger := c.l
-	var err error
-	c, err = NewContainerEventHandler(ctx, controller, c.watchGenerator, logger)
-	if err != nil {
-		logger.Errorf("error creating ContainerEventHandler: %v", err)
-		return
-	}
 	c.start(ctx)
 }
 
 func (c *ContainerEventHandler) start(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			c.l.Info("ContainerEventHandler context canceled, stopping")
+	//the informers are stopped when the handler returns, a restart creates new ones
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	factory := c.informerFactoryGenerator(c.resync)
+	pods := factory.Core().V1().Pods()
+	deployments := factory.Apps().V1().Deployments()
+	replicaSets := factory.Apps().V1().ReplicaSets()
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: c.enqueue,
+	}
+	for _, informer := range []cache.SharedIndexInformer{pods.Informer(), deployments.Informer(), replicaSets.Informer()} {
+		if err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			c.l.Warnf("watch closed, resuming from the last resource version: %v", err)
+			cache.DefaultWatchErrorHandler(r, err)
+		}); err != nil {
+			c.l.Errorf("error setting watch error handler: %v", err)
 			return
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			c.l.Errorf("error adding event handler: %v", err)
+			return
+		}
+	}
+	c.podLister = pods.Lister()
+	c.deploymentLister = deployments.Lister()
+	c.replicaSetLister = replicaSets.Lister()
+
+	c.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "applications")
+	defer c.queue.ShutDown()
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+
+	c.l.Debug("waiting for informers cache sync")
+	if !cache.WaitForCacheSync(ctx.Done(), pods.Informer().HasSynced, deployments.Informer().HasSynced, replicaSets.Informer().HasSynced) {
+		c.l.Error("informers cache not synced, context canceled")
+		return
+	}
+	c.l.Infof("informers cache synced, starting %d workers", c.workers)
 
-		case event := <-c.watch.ResultChan():
-			//log.Println(event)
-			// c.l.Debugf("[EVENT] received %v event", event.Type)
-			if event.Type == watch.Bookmark {
-				c.l.Debugf("[EVENT] received bookmark event")
-				c.l.Debugf("event: %+v", event)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNextItem(ctx) {
 			}
-			pod, ok := event.Object.(*corev1.Pod)
-			if !ok {
-				continue
+		}()
+	}
+
+	<-ctx.Done()
+	c.l.Info("ContainerEventHandler context canceled, stopping")
+	c.queue.ShutDown()
+	wg.Wait()
+}
+
+// queues the application of the resource, resources without the app id
+// label (namespace level ones) are ignored
+func (c *ContainerEventHandler) enqueue(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	appID := object.GetLabels()[model.AppIDLabel]
+	if appID == "" {
+		return
+	}
+	c.queue.Add(appID)
+}
+
+func (c *ContainerEventHandler) processNextItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	appID := key.(string)
+	err := c.safeSyncApplication(ctx, appID)
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+	//the application was updated while syncing it, it's synced again from the latest version
+	if errors.Is(err, repo.ErrVersionConflict) || errors.Is(err, controller.ErrApplicationChanged) {
+		c.l.Debugf("application %s changed while syncing, syncing again", appID)
+		c.queue.Forget(key)
+		c.queue.Add(key)
+		return true
+	}
+	//retrying doesn't change the state of the application, the next event may
+	if errors.Is(err, controller.ErrInvalidStateTransition) {
+		c.l.Warnf("application %s not synced: %v", appID, err)
+		c.queue.Forget(key)
+		return true
+	}
+	if c.queue.NumRequeues(key) < maxSyncRetries {
+		c.l.Warnf("error syncing application %s, retrying: %v", appID, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.l.Errorf("error syncing application %s, dropping it after %d retries: %v", appID, maxSyncRetries, err)
+	c.queue.Forget(key)
+	return true
+}
+
+// a panic while syncing an application must not stop the worker
+func (c *ContainerEventHandler) safeSyncApplication(ctx context.Context, appID string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.l.Errorf("panic syncing application %s: \nerror: %v\n\nstack: %s", appID, r, string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.syncApplication(ctx, appID)
+}
+
+func (c *ContainerEventHandler) syncApplication(ctx context.Context, appID string) error {
+	appPrimitiveID, err := primitive.ObjectIDFromHex(appID)
+	if err != nil {
+		c.l.Errorf("error parsing app id to object id: %v", err)
+		return nil
+	}
+
+	app, err := c.controller.GetApplicationByID(ctx, appPrimitiveID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			c.l.Debugf("application %s not found, ignoring", appID)
+			return nil
+		}
+		return fmt.Errorf("error getting application by id: %v", err)
+	}
+
+	pods, err := c.podLister.List(labels.SelectorFromSet(labels.Set{model.AppIDLabel: appID}))
+	if err != nil {
+		return fmt.Errorf("error listing pods: %v", err)
+	}
+
+	//the application is removed once all its pods are gone
+	if app.State == model.ApplicationStateDeleting {
+		if len(pods) > 0 {
+			c.l.Debugf("application %s is being deleted, waiting for %d pods", app.Name, len(pods))
+			return nil
+		}
+		c.l.Infof("application %s has no pods left, deleting it", app.Name)
+		if _, err := c.controller.ApplicationRepo.DeleteByID(ctx, app.ID); err != nil {
+			return fmt.Errorf("error deleting application %s: %v", app.ID.Hex(), err)
+		}
+		return nil
+	}
+
+	pod := c.currentPod(app, pods)
+	if pod == nil {
+		c.l.Debugf("application %s has no pods", app.Name)
+		return nil
+	}
+	return c.syncPod(ctx, app, pod)
+}
+
+// returns the pod watched by the application, if it's gone the newest pod of
+// the current revision of the deployment is returned
+func (c *ContainerEventHandler) currentPod(app *model.Application, pods []*corev1.Pod) *corev1.Pod {
+	if len(pods) == 0 {
+		return nil
+	}
+	if app.Service != nil && app.Service.Deployment != nil && app.Service.Deployment.CurrentPodName != "" {
+		for _, pod := range pods {
+			if pod.Name == app.Service.Deployment.CurrentPodName {
+				return pod
 			}
+		}
+		c.l.Infof("current pod %s of application %s is gone, looking for a new one", app.Service.Deployment.CurrentPodName, app.Name)
+		//during a rollout the current pod is moved by the rollout watcher
+		if app.State != model.ApplicationStateRollingOut {
+			app.Service.Deployment.CurrentPodName = ""
+		}
+	}
 
-			f := make(logrus.Fields)
-			for _, c := range pod.Status.Conditions {
-				var value interface{}
-				if c.Status == "True" {
-					value = true
-				} else {
-					value = c.Reason
-				}
-				f[fmt.Sprintf("condition%s", c.Type)] = value
+	var newest *corev1.Pod
+	for _, pod := range c.currentRevisionPods(app, pods) {
+		if newest == nil ||
+			(newest.DeletionTimestamp != nil && pod.DeletionTimestamp == nil) ||
+			((newest.DeletionTimestamp == nil) == (pod.DeletionTimestamp == nil) && pod.CreationTimestamp.After(newest.CreationTimestamp.Time)) {
+			newest = pod
+		}
+	}
+	return newest
+}
+
+// returns the pods of the replica set of the current revision of the deployment,
+// all the pods are returned if the revision is not known
+func (c *ContainerEventHandler) currentRevisionPods(app *model.Application, pods []*corev1.Pod) []*corev1.Pod {
+	if app.Service == nil || app.Service.Deployment == nil {
+		return pods
+	}
+	deployment, err := c.deploymentLister.Deployments(pods[0].Namespace).Get(app.Service.Deployment.Name)
+	if err != nil {
+		return pods
+	}
+	revision := deployment.Annotations[revisionAnnotation]
+	if revision == "" {
+		return pods
+	}
+
+	var current []*corev1.Pod
+	for _, pod := range pods {
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "ReplicaSet" {
+			continue
+		}
+		replicaSet, err := c.replicaSetLister.ReplicaSets(pod.Namespace).Get(owner.Name)
+		if err != nil {
+			continue
+		}
+		if replicaSet.Annotations[revisionAnnotation] == revision {
+			current = append(current, pod)
+		}
+	}
+	if len(current) == 0 {
+		return pods
+	}
+	return current
+}
+
+// updates the application from the state of the container of its current pod,
+// it's called on every change and resync so it must be idempotent
+func (c *ContainerEventHandler) syncPod(ctx context.Context, app *model.Application, pod *corev1.Pod) error {
+	f := make(logrus.Fields)
+	for _, c := range pod.Status.Conditions {
+		var value interface{}
+		if c.Status == "True" {
+			value = true
+		} else {
+			value = c.Reason
+		}
+		f[fmt.Sprintf("condition%s", c.Type)] = value
+	}
+	c.l.WithFields(f).Debugf("[EVENT] pod %s", pod.Name)
+	if len(pod.Status.ContainerStatuses) == 0 {
+		c.l.Debugf("pod %s has no container statuses", pod.Name)
+		return nil
+	}
+	state := pod.Status.ContainerStatuses[0].State
+
+	if state.Running != nil {
+		if app.Service == nil || app.Service.Deployment == nil {
+			c.l.Warnf("application %s has no service or deployment, probably currently being created, updating", app.Name)
+			c.controller.AddCurrentPodToApplication(ctx, app.ID, pod.Name)
+			return nil
+		}
+		changed := false
+		running := false
+		if app.Service.Deployment.CurrentPodName == "" {
+			c.l.Debugf("application %s has a running container %s and we are watching none, watching", app.Name, pod.Name)
+			app.Service.Deployment.CurrentPodName = pod.Name
+			changed = true
+		} else if app.Service.Deployment.CurrentPodName != pod.Name {
+			c.l.Debugf("application %s has a running container %s, but it's not the one we're watching %s, ignoring", app.Name, pod.Name, app.Service.Deployment.CurrentPodName)
+			return nil
+		}
+		//during a rollout the state is updated by the rollout watcher
+		if app.State != model.ApplicationStateRunning &&
+			app.State != model.ApplicationStateStopped &&
+			app.State != model.ApplicationStateRollingOut &&
+			app.State.CanTransitionTo(model.ApplicationStateRunning) {
+			c.l.Infof("container %s is running, updating application state from %s to running", pod.Name, app.State)
+			running = true
+		}
+		//a running container is not necessarily ready to receive traffic
+		if health := podHealth(pod); app.Health != health {
+			c.l.Infof("updating application health from %s to %s", app.Health, health)
+			app.Health = health
+			changed = true
+		}
+		if running {
+			if err := c.controller.TransitionApplication(ctx, app, model.ApplicationStateRunning, fmt.Sprintf("container %s is running", pod.Name), model.StateTransitionActorContainerEvents); err != nil {
+				return fmt.Errorf("error updating application: %w", err)
 			}
-			c.l.WithFields(f).Debugf("[EVENT] pod %s", pod.Name)
-			// c.l.Debugf("thing %+v", pod.Status.ContainerStatuses)
-			if len(pod.Status.ContainerStatuses) == 0 {
-				c.l.Infof("pod %s has no container statuses", pod.Name)
-				continue
+			return nil
+		}
+		if changed {
+			if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
+				return fmt.Errorf("error updating application: %w", err)
 			}
-			state := pod.Status.ContainerStatuses[0].State
+		}
+		return nil
+	}
 
-			appID := pod.Labels[model.AppIDLabel]
-			appPrimitiveID, err := primitive.ObjectIDFromHex(appID)
-			if err != nil {
-				c.l.Errorf("error parsing app id to object id: %v", err)
-				continue
+	if state.Terminated != nil {
+		//pods removed by a rollout or a restart are terminated on purpose
+		if pod.DeletionTimestamp != nil {
+			c.l.Debugf("container %s terminated because the pod is being deleted, ignoring", pod.Name)
+			return nil
+		}
+		if app.State == model.ApplicationStateCrashed || app.State == model.ApplicationStateStopped {
+			c.l.Debugf("container %s is terminated, application %s already %s", pod.Name, app.Name, app.State)
+			return nil
+		}
+		if app.State == model.ApplicationStateStarting {
+			c.l.Infof("container %s is terminated, but application is still starting, there is a possible CrashLoopBackOff", pod.Name)
+			if err := c.controller.TransitionApplication(ctx, app, model.ApplicationStateCrashed, terminatedMessage(pod.Name, state.Terminated), model.StateTransitionActorContainerEvents); err != nil {
+				return fmt.Errorf("error updating application: %w", err)
 			}
-			c.l.Debugf("app id: %s", appID)
+			c.controller.NotifyApplicationEvent(app, model.NotificationEventApplicationCrashed, terminatedMessage(pod.Name, state.Terminated))
+			return nil
+		}
+		if app.Service == nil || app.Service.Deployment == nil {
+			c.l.Warnf("application %s has no service or deployment, probably currently being created, ignoring", app.Name)
+			return nil
+		}
+		if app.Service.Deployment.CurrentPodName != pod.Name {
+			c.l.Debugf("application %s has a terminated container %s, but it's not the one we're watching %s, ignoring", app.Name, pod.Name, app.Service.Deployment.CurrentPodName)
+			return nil
+		}
+		c.l.Infof("container %s is terminated with %d status code at %v, unexpected container death, notifying user", pod.Name, state.Terminated.ExitCode, state.Terminated.FinishedAt)
+		app.Health = model.ApplicationHealthNotReady
+		if err := c.controller.TransitionApplication(ctx, app, model.ApplicationStateCrashed, terminatedMessage(pod.Name, state.Terminated), model.StateTransitionActorContainerEvents); err != nil {
+			return fmt.Errorf("error updating application: %w", err)
+		}
+		c.controller.NotifyApplicationEvent(app, model.NotificationEventApplicationCrashed, terminatedMessage(pod.Name, state.Terminated))
+		return nil
+	}
 
-			app, err := c.controller.GetApplicationByID(ctx, appPrimitiveID)
-			if err != nil {
-				c.l.Errorf("error getting application by id: %v", err)
-				continue
+	if state.Waiting != nil {
+		c.l.Debugf("container %s is waiting: %s", pod.Name, state.Waiting.Reason)
+		switch state.Waiting.Reason {
+		case "ContainerCreating":
+			if app.Service == nil || app.Service.Deployment == nil {
+				c.l.Warnf("application %s has no service or deployment, probably currently being created, ignoring", app.Name)
+				return nil
 			}
-
-			c.l.Debugf("app %+v", app)
-			// c.l.Debugf("event %+v", event)
-
-			if state.Running != nil {
-				if app.Service == nil || app.Service.Deployment == nil {
-					c.l.Warnf("application %s has no service or deployment, probably currently being created, updating", app.Name)
-					c.controller.AddCurrentPodToApplication(ctx, app.ID, pod.Name)
-					continue
-				}
-				c.l.Info("currentPodName: ", app.Service.Deployment.CurrentPodName)
-				if app.Service.Deployment.CurrentPodName != "" {
-					if app.Service.Deployment.CurrentPodName != pod.Name {
-						c.l.Warnf("application %s has a running container %s, but it's not the one we're watching %s, ignoring", app.Name, pod.Name, app.Service.Deployment.CurrentPodName)
-						continue
-					}
-				} else {
-					c.l.Debugf("application %s has a running container %s and we are watching none, watching", app.Name, pod.Name)
-					app.Service.Deployment.CurrentPodName = pod.Name
-					if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-						c.l.Errorf("error updating application: %v", err)
-					}
-				}
-				c.l.Debugf("state running: %+v\n", state.Running)
-				c.l.Infof("container %s is running", pod.Name)
-				if app.State != model.ApplicationStateRunning &&
-					app.State != model.ApplicationStateDeleting {
-					c.l.Infof("updating application state from %s to running", app.State)
-					app.State = model.ApplicationStateRunning
-					if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-						c.l.Errorf("error updating application: %v", err)
-						continue
-					}
-				}
-				continue
+			//the old pod is kept alive by the rolling update until the new one is ready,
+			//the rollout watcher moves the current pod once the rollout is completed
+			if app.Service.Deployment.CurrentPodName != "" {
+				c.l.Debugf("application %s already has a current container %s, keeping it", app.Name, app.Service.Deployment.CurrentPodName)
+				return nil
+			}
+			c.l.Infof("creating container %s", pod.Name)
+			app.Service.Deployment.CurrentPodName = pod.Name
+			if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
+				return fmt.Errorf("error updating application: %w", err)
 			}
 
-			if state.Terminated != nil {
-				if app.State == model.ApplicationStateStarting {
-					c.l.Infof("container %s is terminated, but application is still starting, there is a possible CrashLoopBackOff", pod.Name)
-					app.State = model.ApplicationStateCrashed
-					if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-						c.l.Errorf("error updating application: %v", err)
-					}
-					continue
-				}
-				if app.Service == nil || app.Service.Deployment == nil {
-					c.l.Warnf("application %s has no service or deployment, probably currently being created, ignoring", app.Name)
-					continue
-				}
-				if app.Service.Deployment.CurrentPodName != pod.Name {
-					c.l.Warnf("application %s has a terminated container %s, but it's not the one we're watching %s, ignoring", app.Name, pod.Name, app.Service.Deployment.CurrentPodName)
-					continue
-				}
-				c.l.Debugf("state terminated: %+v\n", state.Terminated)
-				c.l.Infof("container %s is terminated with %d status code at %v", pod.Name, state.Terminated.ExitCode, state.Terminated.FinishedAt)
-				if app.State == model.ApplicationStateDeleting {
-					if event.Type == watch.Deleted {
-						if _, err := c.controller.ApplicationRepo.DeleteByID(ctx, app.ID); err != nil {
-							c.l.Errorf("error deleting application %s: %v", app.ID.Hex(), err)
-						}
-					} else {
-						c.l.Info("application is being deleted or already crashed, passing")
-					}
-					continue
-				}
-				c.l.Info("unexpected container death, notifying user")
-				app.State = model.ApplicationStateCrashed
-				if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-					c.l.Errorf("error updating application: %v", err)
-					continue
-				}
+		case "CrashLoopBackOff":
+			status := pod.Status.ContainerStatuses[0]
+			var exitCode int32
+			var reason string
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				exitCode = terminated.ExitCode
+				reason = terminated.Reason
+			}
+			if err := c.controller.RecordApplicationCrash(ctx, app, pod.Namespace, pod.Name, status.RestartCount, exitCode, reason); err != nil {
+				return fmt.Errorf("error recording crash of application %s: %w", app.Name, err)
 			}
 
-			if state.Waiting != nil {
-				c.l.Debugf("state waiting: %+v\n", state.Waiting)
-				c.l.Infof("container %s is waiting: %s", pod.Name, state.Waiting.Reason)
-				switch state.Waiting.Reason {
-				case "ContainerCreating":
-					c.l.Infof("creating container %s", pod.Name)
-					if app.Service == nil || app.Service.Deployment == nil {
-						c.l.Warnf("application %s has no service or deployment, probably currently being created, ignoring", app.Name)
-						continue
-					}
-					if app.Service.Deployment.CurrentPodName != "" {
-						c.l.Warnf("application %s already has a running container, deleting it", app.Name)
-						if err := c.controller.ServiceManager.DeletePod(ctx, pod.Namespace, app.Service.Deployment.CurrentPodName); err != nil {
-							c.l.Errorf("error deleting pod: %v", err)
-							continue
-						}
-					}
-					app.Service.Deployment.CurrentPodName = pod.Name
-					if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-						c.l.Errorf("error updating application: %v", err)
-						continue
-					}
-
-				case "CrashLoopBackOff":
-					c.l.Infof("container %s is in crash loop, deleting to reset restart counter", pod.Name)
-					c.l.Warnf("crashLoopBackOff, not restarting counter to not starve the cluster")
-					// if err := c.controller.ServiceManager.DeletePod(ctx, pod.Namespace, pod.Name); err != nil {
-					// 	c.l.Errorf("error deleting pod: %v", err)
-					// 	continue
-					// }
-					// if app.Service == nil || app.Service.Deployment == nil {
-					// 	c.l.Warnf("application %s has no service or deployment, probably currently being created, ignoring", app.Name)
-					// 	continue
-					// }
-					// app.Service.Deployment.CurrentPodName = ""
-					// if _, err := c.controller.ApplicationRepo.UpdateByID(ctx, app, app.ID); err != nil {
-					// 	c.l.Errorf("error updating application: %v", err)
-					// 	continue
-					// }
-
-				default:
-					c.l.Warnf("unknown way to handle waiting state %s for container %s", state.Waiting.Reason, pod.Name)
-				}
+		default:
+			c.l.Debugf("unknown way to handle waiting state %s for container %s", state.Waiting.Reason, pod.Name)
+		}
+	}
+	return nil
+}
+
+// returns the health of the pod based on its ready condition, which takes
+// into account the readiness probe of the containers
+func podHealth(pod *corev1.Pod) model.ApplicationHealth {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			if condition.Status == corev1.ConditionTrue {
+				return model.ApplicationHealthReady
 			}
+			return model.ApplicationHealthNotReady
 		}
 	}
+	return model.ApplicationHealthUnknown
+}
+
+func terminatedMessage(podName string, terminated *corev1.ContainerStateTerminated) string {
+	message := fmt.Sprintf("container %s terminated with exit code %d", podName, terminated.ExitCode)
+	if terminated.Reason != "" {
+		message += fmt.Sprintf(" (%s)", terminated.Reason)
+	}
+	return message
 }
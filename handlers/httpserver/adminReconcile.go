@@ -0,0 +1,17 @@
+package httpserver
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// runs a dry run of the reconciler and returns what it would change,
+// nothing is applied to the database nor to the cluster
+func (h *httpHandler) AdminReconcileReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	report, err := h.controller.Reconcile(ctx, true)
+	if err != nil {
+		h.l.Errorf("error running reconciliation dry run: %v", err)
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "reconciliation report generated successfully", report)
+}
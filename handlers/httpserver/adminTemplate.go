@@ -0,0 +1,288 @@
+package httpserver
+
+import (
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// the full template, the model hides the deployment fields from the users.
+	// Version, deprecated and createdAt are ignored in requests
+	HttpAdminTemplate struct {
+		Code            string                    `json:"code"`
+		Version         int                       `json:"version"`
+		CreatedAt       time.Time                 `json:"createdAt"`
+		Deprecated      bool                      `json:"deprecated"`
+		Available       bool                      `json:"available"`
+		Name            string                    `json:"name"`
+		ImageName       string                    `json:"imageName"`
+		ListeningPort   string                    `json:"listeningPort"`
+		RequiredEnvs    []model.KeyValue          `json:"requiredEnvs"`
+		OptionalEnvs    []model.KeyValue          `json:"optionalEnvs"`
+		DefaultEnvs     []model.KeyValue          `json:"defaultEnvs"`
+		SecretEnvs      []string                  `json:"secretEnvs"`
+		GeneratedEnvs   []HttpAdminGeneratedEnv   `json:"generatedEnvs"`
+		Description     string                    `json:"description"`
+		Documentation   string                    `json:"documentation"`
+		PersistancePath string                    `json:"persistancePath"`
+		Kind            model.ApplicationKind     `json:"kind"`
+		Resources       *model.ContainerResources `json:"resources,omitempty"`
+		Link            *HttpAdminTemplateLink    `json:"link,omitempty"`
+		Backup          *HttpAdminTemplateBackup  `json:"backup,omitempty"`
+		Target          *HttpAdminTemplateTarget  `json:"target,omitempty"`
+	}
+
+	HttpAdminGeneratedEnv struct {
+		Key    string                 `json:"key"`
+		Kind   model.GeneratedEnvKind `json:"kind"`
+		Length int                    `json:"length"`
+		Prefix string                 `json:"prefix"`
+	}
+
+	HttpAdminTemplateLink struct {
+		Scheme      string `json:"scheme"`
+		EnvName     string `json:"envName"`
+		UserEnv     string `json:"userEnv"`
+		DefaultUser string `json:"defaultUser"`
+		PasswordEnv string `json:"passwordEnv"`
+		DatabaseEnv string `json:"databaseEnv"`
+	}
+
+	HttpAdminTemplateBackup struct {
+		Image          string `json:"image"`
+		BackupCommand  string `json:"backupCommand"`
+		RestoreCommand string `json:"restoreCommand"`
+		Extension      string `json:"extension"`
+	}
+
+	HttpAdminTemplateTarget struct {
+		Templates []string         `json:"templates"`
+		Envs      []model.KeyValue `json:"envs"`
+	}
+
+	// empty values are not updated
+	HttpAdminUpdateTemplate struct {
+		Name          string `json:"name"`
+		Description   string `json:"description"`
+		Documentation string `json:"documentation"`
+		Available     *bool  `json:"available"`
+	}
+
+	HttpAdminDeprecateTemplate struct {
+		Deprecated bool `json:"deprecated"`
+	}
+)
+
+func convertHttpAdminTemplateToModel(t *HttpAdminTemplate) *model.Template {
+	template := &model.Template{
+		Code:            t.Code,
+		Available:       t.Available,
+		Name:            t.Name,
+		ImageName:       t.ImageName,
+		ListeningPort:   t.ListeningPort,
+		RequiredEnvs:    t.RequiredEnvs,
+		OptionalEnvs:    t.OptionalEnvs,
+		DefaultEnvs:     t.DefaultEnvs,
+		SecretEnvs:      t.SecretEnvs,
+		Description:     t.Description,
+		Documentation:   t.Documentation,
+		PersistancePath: t.PersistancePath,
+		Kind:            t.Kind,
+		Resources:       t.Resources,
+	}
+	for _, env := range t.GeneratedEnvs {
+		template.GeneratedEnvs = append(template.GeneratedEnvs, model.GeneratedEnv{
+			Key:    env.Key,
+			Kind:   env.Kind,
+			Length: env.Length,
+			Prefix: env.Prefix,
+		})
+	}
+	if t.Link != nil {
+		template.Link = &model.TemplateLink{
+			Scheme:      t.Link.Scheme,
+			EnvName:     t.Link.EnvName,
+			UserEnv:     t.Link.UserEnv,
+			DefaultUser: t.Link.DefaultUser,
+			PasswordEnv: t.Link.PasswordEnv,
+			DatabaseEnv: t.Link.DatabaseEnv,
+		}
+	}
+	if t.Backup != nil {
+		template.Backup = &model.TemplateBackup{
+			Image:          t.Backup.Image,
+			BackupCommand:  t.Backup.BackupCommand,
+			RestoreCommand: t.Backup.RestoreCommand,
+			Extension:      t.Backup.Extension,
+		}
+	}
+	if t.Target != nil {
+		template.Target = &model.TemplateTarget{
+			Templates: t.Target.Templates,
+			Envs:      t.Target.Envs,
+		}
+	}
+	return template
+}
+
+func convertModelTemplateToHttpAdmin(template *model.Template) *HttpAdminTemplate {
+	t := &HttpAdminTemplate{
+		Code:            template.Code,
+		Version:         template.Version,
+		CreatedAt:       template.CreatedAt,
+		Deprecated:      template.Deprecated,
+		Available:       template.Available,
+		Name:            template.Name,
+		ImageName:       template.ImageName,
+		ListeningPort:   template.ListeningPort,
+		RequiredEnvs:    template.RequiredEnvs,
+		OptionalEnvs:    template.OptionalEnvs,
+		DefaultEnvs:     template.DefaultEnvs,
+		SecretEnvs:      template.SecretEnvs,
+		Description:     template.Description,
+		Documentation:   template.Documentation,
+		PersistancePath: template.PersistancePath,
+		Kind:            template.Kind,
+		Resources:       template.Resources,
+	}
+	for _, env := range template.GeneratedEnvs {
+		t.GeneratedEnvs = append(t.GeneratedEnvs, HttpAdminGeneratedEnv{
+			Key:    env.Key,
+			Kind:   env.Kind,
+			Length: env.Length,
+			Prefix: env.Prefix,
+		})
+	}
+	if template.Link != nil {
+		t.Link = &HttpAdminTemplateLink{
+			Scheme:      template.Link.Scheme,
+			EnvName:     template.Link.EnvName,
+			UserEnv:     template.Link.UserEnv,
+			DefaultUser: template.Link.DefaultUser,
+			PasswordEnv: template.Link.PasswordEnv,
+			DatabaseEnv: template.Link.DatabaseEnv,
+		}
+	}
+	if template.Backup != nil {
+		t.Backup = &HttpAdminTemplateBackup{
+			Image:          template.Backup.Image,
+			BackupCommand:  template.Backup.BackupCommand,
+			RestoreCommand: template.Backup.RestoreCommand,
+			Extension:      template.Backup.Extension,
+		}
+	}
+	if template.Target != nil {
+		t.Target = &HttpAdminTemplateTarget{
+			Templates: template.Target.Templates,
+			Envs:      template.Target.Envs,
+		}
+	}
+	return t
+}
+
+func convertModelTemplatesToHttpAdmin(templates []*model.Template) []*HttpAdminTemplate {
+	resp := make([]*HttpAdminTemplate, 0, len(templates))
+	for _, template := range templates {
+		resp = append(resp, convertModelTemplateToHttpAdmin(template))
+	}
+	return resp
+}
+
+func (h *httpHandler) AdminListTemplates(c echo.Context) error {
+	ctx := c.Request().Context()
+	templates, err := h.controller.AdminListTemplates(ctx)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "templates listed successfully", convertModelTemplatesToHttpAdmin(templates))
+}
+
+func (h *httpHandler) AdminNewTemplate(c echo.Context) error {
+	post := new(HttpAdminTemplate)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	template, err := h.controller.CreateTemplate(ctx, convertHttpAdminTemplateToModel(post))
+	if err != nil {
+		h.l.Errorf("error creating template: %v", err)
+		switch err {
+		case controller.ErrInvalidTemplate:
+			return respError(c, 400, "invalid template", "the code must be 3 to 16 uppercase letters or digits, name, image, a valid port and kind are required, storage templates need an absolute persistance path", ErrInvalidTemplate)
+		case controller.ErrTemplateCodeNotAvailable:
+			return respError(c, 400, "template code not available", "there is already a template with the same code, create a new version instead", ErrTemplateCodeNotAvailable)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "template created successfully", convertModelTemplateToHttpAdmin(template))
+}
+
+func (h *httpHandler) AdminListTemplateVersions(c echo.Context) error {
+	ctx := c.Request().Context()
+	versions, err := h.controller.ListTemplateVersions(ctx, c.Param("code"))
+	if err != nil {
+		if err == controller.ErrTemplateNotFound {
+			return respError(c, 404, "template code not found", "this template code is not found, make sure the right one was selected", ErrTemplateCodeNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "template versions listed successfully", convertModelTemplatesToHttpAdmin(versions))
+}
+
+func (h *httpHandler) AdminNewTemplateVersion(c echo.Context) error {
+	post := new(HttpAdminTemplate)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	template, err := h.controller.CreateTemplateVersion(ctx, c.Param("code"), convertHttpAdminTemplateToModel(post))
+	if err != nil {
+		h.l.Errorf("error creating template version: %v", err)
+		switch err {
+		case controller.ErrTemplateNotFound:
+			return respError(c, 404, "template code not found", "this template code is not found, make sure the right one was selected", ErrTemplateCodeNotFound)
+		case controller.ErrInvalidTemplate:
+			return respError(c, 400, "invalid template", "name, image, a valid port and the same kind of the previous version are required, storage templates need an absolute persistance path", ErrInvalidTemplate)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "template version created successfully", convertModelTemplateToHttpAdmin(template))
+}
+
+func (h *httpHandler) AdminUpdateTemplate(c echo.Context) error {
+	post := new(HttpAdminUpdateTemplate)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	template, err := h.controller.UpdateTemplate(ctx, c.Param("code"), post.Name, post.Description, post.Documentation, post.Available)
+	if err != nil {
+		if err == controller.ErrTemplateNotFound {
+			return respError(c, 404, "template code not found", "this template code is not found, make sure the right one was selected", ErrTemplateCodeNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "template updated successfully", convertModelTemplateToHttpAdmin(template))
+}
+
+func (h *httpHandler) AdminDeprecateTemplate(c echo.Context) error {
+	post := new(HttpAdminDeprecateTemplate)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DeprecateTemplate(ctx, c.Param("code"), post.Deprecated); err != nil {
+		if err == controller.ErrTemplateNotFound {
+			return respError(c, 404, "template code not found", "this template code is not found, make sure the right one was selected", ErrTemplateCodeNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "template deprecation updated successfully", nil)
+}
@@ -19,8 +19,26 @@ type (
 		Port        string           `json:"port"`
 		Description string           `json:"description,omitempty"`
 		Envs        []model.KeyValue `json:"envs,omitempty"`
+		// stored as secrets, the values are never returned by the api
+		Secrets []model.KeyValue `json:"secrets,omitempty"`
+
+		RootDirectory string              `json:"rootDirectory"`
+		HealthChecks  *model.HealthChecks `json:"healthChecks,omitempty"`
+	}
+
+	// workers have no port, service or ingress route
+	HttpRequestNewWorkerApplication struct {
+		Name        string           `json:"name"`
+		Repo        string           `json:"repo"`
+		Branch      string           `json:"branch"`
+		Description string           `json:"description,omitempty"`
+		Envs        []model.KeyValue `json:"envs,omitempty"`
+		// stored as secrets, the values are never returned by the api
+		Secrets []model.KeyValue `json:"secrets,omitempty"`
 
 		RootDirectory string `json:"rootDirectory"`
+		// only exec probes or probes with an explicit port, none by default
+		HealthChecks *model.HealthChecks `json:"healthChecks,omitempty"`
 	}
 
 	HttpRequestApplicationGeneralUpdate struct {
@@ -33,6 +51,11 @@ type (
 	HttpRequestApplicationBuildUpdate struct {
 		BuildConfig model.BuildConfig `json:"buildConfig"`
 	}
+
+	HttpRequestApplicationHealthChecksUpdate struct {
+		// if not provided the default health checks are restored
+		HealthChecks *model.HealthChecks `json:"healthChecks,omitempty"`
+	}
 )
 
 func (h *httpHandler) NewWebApplication(c echo.Context) error {
@@ -56,8 +79,16 @@ func (h *httpHandler) NewWebApplication(c echo.Context) error {
 		post.RootDirectory = "/"
 	}
 
-	app, err := h.controller.CreateNewWebApplication(ctx, user.Code, user.Info.GithubAccessToken, post.Name, post.Repo, post.Branch, post.Port, post.Envs, post.RootDirectory)
+	app, err := h.controller.CreateNewWebApplication(ctx, user.Code, user.Info.GithubAccessToken, post.Name, post.Repo, post.Branch, post.Port, post.Envs, post.Secrets, post.RootDirectory, post.HealthChecks)
 	if err != nil {
+		switch err {
+		case controller.ErrInvalidHealthCheck:
+			return respError(c, 400, "invalid health check", "the provided health checks are invalid, check the probe kind, port and command", ErrInvalidHealthCheck)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secrets must have a valid env name, a non empty value and can not be repeated", ErrInvalidSecret)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "you reached the maximum number of applications allowed by your quota", ErrQuotaExceeded)
+		}
 		//TODO: handle error
 		return respError(c, 500, "unexpected error", "", ErrUnexpected)
 	}
@@ -69,6 +100,47 @@ func (h *httpHandler) NewWebApplication(c echo.Context) error {
 	return respSuccess(c, 200, "application created successfully", resp)
 }
 
+func (h *httpHandler) NewWorkerApplication(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	ctx := c.Request().Context()
+
+	post := new(HttpRequestNewWorkerApplication)
+	if err := c.Bind(post); err != nil {
+		h.l.Debugf("error binding request body: %v", err)
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+	if !h.controller.IsNameAvailableUserWide(ctx, post.Name, user.Code) {
+		return respError(c, 400, "name taken", "name not available, there is already another service in your namespace with that name, change it please", ErrNameTaken)
+	}
+
+	if post.RootDirectory == "" {
+		post.RootDirectory = "/"
+	}
+
+	app, err := h.controller.CreateNewWorkerApplication(ctx, user.Code, user.Info.GithubAccessToken, post.Name, post.Repo, post.Branch, post.Envs, post.Secrets, post.RootDirectory, post.HealthChecks)
+	if err != nil {
+		switch err {
+		case controller.ErrInvalidHealthCheck:
+			return respError(c, 400, "invalid health check", "the provided health checks are invalid, workers have no port so http and tcp probes must set one", ErrInvalidHealthCheck)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secrets must have a valid env name, a non empty value and can not be repeated", ErrInvalidSecret)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "you reached the maximum number of applications allowed by your quota", ErrQuotaExceeded)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+
+	resp := map[string]interface{}{
+		"applicationID": app.ID.Hex(),
+		"state":         app.State,
+	}
+	return respSuccess(c, 200, "application created successfully", resp)
+}
+
 func (h *httpHandler) GetApplicationStatus(c echo.Context) error {
 	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
 	if msgErr != nil {
@@ -96,9 +168,14 @@ func (h *httpHandler) GetApplicationStatus(c echo.Context) error {
 		return respError(c, 404, "inexisting application id", fmt.Sprintf("the application with id=%s does not exists", applicationID), ErrInexistingApplication)
 	}
 
+	health := app.Health
+	if health == "" {
+		health = model.ApplicationHealthUnknown
+	}
 	resp := map[string]interface{}{
 		"applicationID": app.ID.Hex(),
 		"state":         app.State,
+		"health":        health,
 	}
 	return respSuccess(c, 200, "retreived state succesfully", resp)
 }
@@ -199,6 +276,8 @@ func (h *httpHandler) DeleteApplication(c echo.Context) error {
 		switch err {
 		case controller.ErrInvalidOperationInCurrentState:
 			return respError(c, 400, "invalid operation in current state", "the application is in a state that does not allow this operation", ErrInvalidOperationInCurrentState)
+		case controller.ErrApplicationPartOfStack:
+			return respError(c, 400, "application part of a stack", fmt.Sprintf("the application is part of the stack with id=%s, delete the stack instead", app.StackID.Hex()), ErrApplicationPartOfStack)
 		default:
 			return respError(c, 500, "unexpected error", "", ErrUnexpected)
 		}
@@ -234,10 +313,16 @@ func (h *httpHandler) UpdateApplicationGeneral(c echo.Context) error {
 			return respError(c, 501, "unable to update name", "the application does not support updating the name at the moment", ErrNotImplemented)
 		case controller.ErrInvalidPort:
 			return respError(c, 400, "invalid port", fmt.Sprintf("the provided port %q is not a valid port, it needs to be an integer and be between 0 and 65535", patch.Port), ErrInvalidRequestBody)
+		case controller.ErrInvalidOperationWithCurrentKind:
+			return respError(c, 400, "invalid operation with current kind", "worker applications have no port", ErrInvalidOperationWithCurrentKind)
 		case controller.ErrInvalidEnv:
 			return respError(c, 400, "invalid env", "the provided envs are invalid, they need to be a list of key value pairs", ErrInvalidRequestBody)
 		case controller.ErrNoChanges:
 			return respSuccess(c, 200, "no changes", nil)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while updating it, try again", ErrApplicationChanged)
 		default:
 			return respError(c, 500, "unexpected error", "", ErrUnexpected)
 		}
@@ -283,6 +368,40 @@ func (h *httpHandler) UpdateApplicationBuild(c echo.Context) error {
 	return respSuccess(c, 200, "application build plan is being updated", nil)
 }
 
+func (h *httpHandler) UpdateApplicationHealthChecks(c echo.Context) error {
+	var patch HttpRequestApplicationHealthChecksUpdate
+	if err := c.Bind(&patch); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.UpdateApplicationHealthChecks(ctx, app, user, patch.HealthChecks); err != nil {
+		h.l.Errorf("error updating application health checks: %v", err)
+		switch err {
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrInvalidHealthCheck:
+			return respError(c, 400, "invalid health check", "the provided health checks are invalid, check the probe kind, port and command", ErrInvalidHealthCheck)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while updating its health checks, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "application health checks updated successfully", nil)
+}
+
 func (h *httpHandler) RedeployApplication(c echo.Context) error {
 	user, app, err := h.GetUserAndApplication(c)
 	if user == nil || app == nil {
@@ -302,6 +421,51 @@ func (h *httpHandler) RedeployApplication(c echo.Context) error {
 	return respSuccess(c, 200, "application is restarting")
 }
 
+// restarts an application stopped after too many crashes
+func (h *httpHandler) ResumeApplication(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.controller.ResumeApplication(ctx, user, app); err != nil {
+		switch err {
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, only stopped applications can be resumed", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while resuming it, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+
+	return respSuccess(c, 200, "application is resuming")
+}
+
+func (h *httpHandler) GetApplicationTimeline(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	transitions, err := h.controller.ListApplicationStateHistory(ctx, app)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "application state timeline", transitions)
+}
+
 func (h *httpHandler) RolloutApplication(c echo.Context) error {
 	user, app, err := h.GetUserAndApplication(c)
 	if user == nil || app == nil {
@@ -322,6 +486,8 @@ func (h *httpHandler) RolloutApplication(c echo.Context) error {
 			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
 		case controller.ErrLastVersionAlreadyDeployed:
 			return respError(c, 400, "last version already up to date", "the last version of the application is already up to date", ErrVersionUpToDate)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while starting the rollout, try again", ErrApplicationChanged)
 		default:
 			return respError(c, 500, "unexpected error", "", ErrUnexpected)
 		}
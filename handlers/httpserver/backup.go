@@ -0,0 +1,186 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// an empty schedule disables the automatic backups
+	HttpRequestBackupSchedule struct {
+		Schedule string `json:"schedule"`
+	}
+
+	// either the target application or the name of the new application must be set
+	HttpRequestRestoreBackup struct {
+		TargetApplicationID string `json:"targetApplicationID"`
+		NewApplicationName  string `json:"newApplicationName"`
+	}
+
+	HttpResponseRestoreBackup struct {
+		Application *model.Application   `json:"application"`
+		Restore     *model.BackupRestore `json:"restore"`
+	}
+)
+
+// sends the response of the errors shared by all the backup routes
+func respBackupError(c echo.Context, app *model.Application, err error) error {
+	switch err {
+	case controller.ErrBackupsNotConfigured:
+		return respError(c, 501, "backups not configured", "backups are not enabled on this instance", ErrBackupsNotConfigured)
+	case controller.ErrBackupsNotSupported:
+		return respError(c, 400, "backups not supported", "the template of the application does not support backups", ErrBackupsNotSupported)
+	case controller.ErrInvalidOperationWithCurrentKind:
+		return respError(c, 400, "invalid operation with current kind", "only storage applications can be backed up", ErrInvalidOperationWithCurrentKind)
+	case controller.ErrInvalidOperationInCurrentState:
+		return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+	case controller.ErrQuotaExceeded:
+		return respError(c, 403, "quota exceeded", "the backup job requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+	default:
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+}
+
+func (h *httpHandler) ListApplicationBackups(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	backups, err := h.controller.ListApplicationBackups(ctx, app, user)
+	if err != nil {
+		h.l.Errorf("error listing application backups: %v", err)
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "application backups", backups)
+}
+
+func (h *httpHandler) CreateApplicationBackup(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	backup, err := h.controller.CreateApplicationBackup(ctx, app, user)
+	if err != nil {
+		h.l.Errorf("error creating application backup: %v", err)
+		return respBackupError(c, app, err)
+	}
+	return respSuccess(c, 200, "backup started", backup)
+}
+
+func (h *httpHandler) SetApplicationBackupSchedule(c echo.Context) error {
+	var post HttpRequestBackupSchedule
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.SetApplicationBackupSchedule(ctx, app, user, post.Schedule); err != nil {
+		h.l.Errorf("error setting application backup schedule: %v", err)
+		if err == controller.ErrInvalidBackupSchedule {
+			return respError(c, 400, "invalid backup schedule", "the schedule must be in the cron format (ex: 0 3 * * *) or a macro like @daily", ErrInvalidBackupSchedule)
+		}
+		return respBackupError(c, app, err)
+	}
+	return respSuccess(c, 200, "backup schedule updated successfully", nil)
+}
+
+func (h *httpHandler) RestoreApplicationBackup(c echo.Context) error {
+	var post HttpRequestRestoreBackup
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+	if (post.TargetApplicationID == "") == (post.NewApplicationName == "") {
+		return respError(c, 400, "invalid request body", "either targetApplicationID or newApplicationName must be set", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	backupID, err := primitive.ObjectIDFromHex(c.Param("backupID"))
+	if err != nil {
+		return respError(c, 400, "invalid backup id", "backupID is invalid", ErrInvalidBackupID)
+	}
+
+	ctx := c.Request().Context()
+	backup, err := h.controller.GetBackup(ctx, user, backupID)
+	if err == nil && backup.ApplicationID != app.ID {
+		err = controller.ErrBackupNotFound
+	}
+	if err != nil {
+		if err == controller.ErrBackupNotFound {
+			return respError(c, 404, "backup not found", fmt.Sprintf("the backup with id=%s does not exists", backupID.Hex()), ErrBackupNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+
+	resp := HttpResponseRestoreBackup{}
+	target := app
+	if post.NewApplicationName != "" {
+		if !h.controller.IsNameAvailableUserWide(ctx, post.NewApplicationName, user.Code) {
+			return respError(c, 400, "name taken", "name not available, there is already another service in your namespace with that name, change it please", ErrNameTaken)
+		}
+		resp.Application, resp.Restore, err = h.controller.RestoreBackupToNewApplication(ctx, user, backup, post.NewApplicationName)
+		if resp.Application != nil {
+			target = resp.Application
+		}
+	} else {
+		targetID, parseErr := primitive.ObjectIDFromHex(post.TargetApplicationID)
+		if parseErr != nil {
+			return respError(c, 400, "invalid application id", "targetApplicationID is invalid", ErrInvalidApplicationID)
+		}
+		target, err = h.controller.GetApplicationByID(ctx, targetID)
+		if err != nil || target.Owner != user.Code {
+			if err == nil || err == repo.ErrNotFound {
+				return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", targetID.Hex()), ErrInexistingApplication)
+			}
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+		resp.Application = target
+		resp.Restore, err = h.controller.RestoreBackup(ctx, user, backup, target)
+	}
+	if err != nil {
+		h.l.Errorf("error restoring backup: %v", err)
+		switch err {
+		case controller.ErrBackupNotRestorable:
+			return respError(c, 400, "backup not restorable", "the backup is not completed or its template does not support restores", ErrBackupNotRestorable)
+		case controller.ErrBackupTemplateMismatch:
+			return respError(c, 400, "backup template mismatch", "the backup can only be restored in applications based on the same template", ErrBackupTemplateMismatch)
+		case controller.ErrApplicationNameNotAvailable:
+			return respError(c, 400, "name taken", "name not available, there is already another service in your namespace with that name, change it please", ErrNameTaken)
+		}
+		return respBackupError(c, target, err)
+	}
+	return respSuccess(c, 200, "restore started", resp)
+}
@@ -0,0 +1,228 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	HttpRequestNewEnvGroup struct {
+		Name    string           `json:"name"`
+		Envs    []model.KeyValue `json:"envs,omitempty"`
+		Secrets []model.KeyValue `json:"secrets,omitempty"`
+	}
+
+	HttpRequestEnvGroupUpdate struct {
+		// if provided replaces all the plain envs of the group
+		Envs []model.KeyValue `json:"envs,omitempty"`
+		// created or updated, the other secrets are kept
+		Secrets        []model.KeyValue `json:"secrets,omitempty"`
+		DeletedSecrets []string         `json:"deletedSecrets,omitempty"`
+	}
+)
+
+// returns the env group of the path param if owned by the user, on error
+// the response is already sent and the returned error must be returned by the handler
+func (h *httpHandler) getUserEnvGroup(c echo.Context, user *model.User) (*model.EnvGroup, error) {
+	envGroupID, err := primitive.ObjectIDFromHex(c.Param("envGroupID"))
+	if err != nil {
+		return nil, respError(c, 400, "invalid env group id", "envGroupID is invalid", ErrInvalidEnvGroupID)
+	}
+
+	ctx := c.Request().Context()
+	group, err := h.controller.GetEnvGroup(ctx, user, envGroupID)
+	if err != nil {
+		if err == controller.ErrEnvGroupNotFound {
+			return nil, respError(c, 404, "env group not found", fmt.Sprintf("the env group with id=%s does not exists", envGroupID.Hex()), ErrEnvGroupNotFound)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return group, nil
+}
+
+func (h *httpHandler) ListEnvGroups(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	ctx := c.Request().Context()
+	groups, err := h.controller.ListEnvGroups(ctx, user)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "env groups", groups)
+}
+
+func (h *httpHandler) NewEnvGroup(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	post := new(HttpRequestNewEnvGroup)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	group, err := h.controller.CreateEnvGroup(ctx, user, post.Name, post.Envs, post.Secrets)
+	if err != nil {
+		h.l.Errorf("error creating env group: %v", err)
+		switch err {
+		case controller.ErrInvalidEnvGroupName:
+			return respError(c, 400, "invalid env group name", "the name must be lowercase alphanumeric with dashes and at most 40 characters", ErrInvalidEnvGroupName)
+		case controller.ErrEnvGroupNameNotAvailable:
+			return respError(c, 400, "name taken", "you already have an env group with this name", ErrNameTaken)
+		case controller.ErrInvalidEnv:
+			return respError(c, 400, "invalid env", "the provided envs are invalid, they need to be a list of key value pairs", ErrInvalidRequestBody)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secrets must have a valid env name, a non empty value and can not be repeated", ErrInvalidSecret)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "env group created successfully", group)
+}
+
+func (h *httpHandler) GetEnvGroup(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	group, err := h.getUserEnvGroup(c, user)
+	if group == nil {
+		return err
+	}
+	return respSuccess(c, 200, "env group", group)
+}
+
+func (h *httpHandler) UpdateEnvGroup(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	var patch HttpRequestEnvGroupUpdate
+	if err := c.Bind(&patch); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	group, err := h.getUserEnvGroup(c, user)
+	if group == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.UpdateEnvGroup(ctx, user, group, patch.Envs, patch.Secrets, patch.DeletedSecrets); err != nil {
+		h.l.Errorf("error updating env group: %v", err)
+		switch err {
+		case controller.ErrNoChanges:
+			return respSuccess(c, 200, "no changes", nil)
+		case controller.ErrInvalidEnv:
+			return respError(c, 400, "invalid env", "the provided envs are invalid, they need to be a list of key value pairs", ErrInvalidRequestBody)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secrets must have a valid env name, a non empty value and can not be repeated", ErrInvalidSecret)
+		case controller.ErrSecretNotFound:
+			return respError(c, 404, "secret not found", "one of the secrets to delete does not exists in the env group", ErrSecretNotFound)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "env group updated successfully, attached applications are restarting", nil)
+}
+
+func (h *httpHandler) DeleteEnvGroup(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	group, err := h.getUserEnvGroup(c, user)
+	if group == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DeleteEnvGroup(ctx, user, group); err != nil {
+		h.l.Errorf("error deleting env group: %v", err)
+		if err == controller.ErrEnvGroupInUse {
+			return respError(c, 400, "env group in use", "the env group is attached to some applications, detach it before deleting it", ErrEnvGroupInUse)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "env group deleted successfully", nil)
+}
+
+func (h *httpHandler) AttachEnvGroup(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	group, err := h.getUserEnvGroup(c, user)
+	if group == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.AttachEnvGroup(ctx, app, user, group); err != nil {
+		h.l.Errorf("error attaching env group: %v", err)
+		switch err {
+		case controller.ErrEnvGroupAlreadyAttached:
+			return respError(c, 400, "env group already attached", "the env group is already attached to the application", ErrEnvGroupAlreadyAttached)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while attaching the env group, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "env group attached successfully", nil)
+}
+
+func (h *httpHandler) DetachEnvGroup(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	group, err := h.getUserEnvGroup(c, user)
+	if group == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DetachEnvGroup(ctx, app, user, group); err != nil {
+		h.l.Errorf("error detaching env group: %v", err)
+		switch err {
+		case controller.ErrEnvGroupNotFound:
+			return respError(c, 404, "env group not attached", "the env group is not attached to the application", ErrEnvGroupNotFound)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while detaching the env group, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "env group detached successfully", nil)
+}
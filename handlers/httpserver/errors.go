@@ -35,10 +35,93 @@ const (
 	ErrInvalidBuilder                  HttpErrorType = "invalid_builder"
 	ErrInvalidDockerfilePath           HttpErrorType = "invalid_dockerfile_path"
 	ErrInvalidPhaseCommand             HttpErrorType = "invalid_phase_command"
+	ErrInvalidHealthCheck              HttpErrorType = "invalid_health_check"
+	ErrApplicationChanged              HttpErrorType = "application_changed"
 
 	//template related errors
 	ErrTemplateCodeNotFound          HttpErrorType = "template_code_not_found"
 	ErrMissingRequiredEnvForTemplate HttpErrorType = "missing_required_env_for_template"
+	ErrInvalidTemplate               HttpErrorType = "invalid_template"
+	ErrTemplateCodeNotAvailable      HttpErrorType = "template_code_not_available"
+	ErrTemplateDeprecated            HttpErrorType = "template_deprecated"
+	ErrTemplateVersionNotNewer       HttpErrorType = "template_version_not_newer"
+
+	//quota errors
+	ErrQuotaExceeded HttpErrorType = "quota_exceeded"
+
+	//secret errors
+	ErrInvalidSecret  HttpErrorType = "invalid_secret"
+	ErrSecretNotFound HttpErrorType = "secret_not_found"
+
+	//env group errors
+	ErrInvalidEnvGroupID       HttpErrorType = "invalid_env_group_id"
+	ErrInvalidEnvGroupName     HttpErrorType = "invalid_env_group_name"
+	ErrEnvGroupNotFound        HttpErrorType = "env_group_not_found"
+	ErrEnvGroupInUse           HttpErrorType = "env_group_in_use"
+	ErrEnvGroupAlreadyAttached HttpErrorType = "env_group_already_attached"
+
+	//link errors
+	ErrApplicationNotLinkable    HttpErrorType = "application_not_linkable"
+	ErrApplicationsAlreadyLinked HttpErrorType = "applications_already_linked"
+	ErrApplicationsNotLinked     HttpErrorType = "applications_not_linked"
+	ErrInvalidLinkEnv            HttpErrorType = "invalid_link_env"
+	ErrIncompatibleTarget        HttpErrorType = "incompatible_target"
+
+	//volume errors
+	ErrInvalidVolume          HttpErrorType = "invalid_volume"
+	ErrInvalidVolumeSize      HttpErrorType = "invalid_volume_size"
+	ErrVolumeNotFound         HttpErrorType = "volume_not_found"
+	ErrVolumeNameNotAvailable HttpErrorType = "volume_name_not_available"
+
+	//backup errors
+	ErrBackupsNotConfigured   HttpErrorType = "backups_not_configured"
+	ErrBackupsNotSupported    HttpErrorType = "backups_not_supported"
+	ErrInvalidBackupSchedule  HttpErrorType = "invalid_backup_schedule"
+	ErrInvalidBackupID        HttpErrorType = "invalid_backup_id"
+	ErrBackupNotFound         HttpErrorType = "backup_not_found"
+	ErrBackupNotRestorable    HttpErrorType = "backup_not_restorable"
+	ErrBackupTemplateMismatch HttpErrorType = "backup_template_mismatch"
+
+	//scheduled job errors
+	ErrInvalidJobName            HttpErrorType = "invalid_job_name"
+	ErrInvalidJobSchedule        HttpErrorType = "invalid_job_schedule"
+	ErrInvalidJobCommand         HttpErrorType = "invalid_job_command"
+	ErrInvalidJobID              HttpErrorType = "invalid_job_id"
+	ErrInvalidJobRunID           HttpErrorType = "invalid_job_run_id"
+	ErrJobNameNotAvailable       HttpErrorType = "job_name_not_available"
+	ErrScheduledJobNotFound      HttpErrorType = "scheduled_job_not_found"
+	ErrJobRunNotFound            HttpErrorType = "job_run_not_found"
+	ErrScheduledJobsLimitReached HttpErrorType = "scheduled_jobs_limit_reached"
+
+	//exec errors
+	ErrExecDisabled HttpErrorType = "exec_disabled"
+
+	//tunnel errors
+	ErrTunnelDisabled     HttpErrorType = "tunnel_disabled"
+	ErrTunnelNotSupported HttpErrorType = "tunnel_not_supported"
+
+	//metrics errors
+	ErrMetricsNotAvailable HttpErrorType = "metrics_not_available"
+	ErrInvalidMetricsRange HttpErrorType = "invalid_metrics_range"
+
+	//notification errors
+	ErrNotificationsDisabled            HttpErrorType = "notifications_disabled"
+	ErrNotificationChannelNotSupported  HttpErrorType = "notification_channel_not_supported"
+	ErrInvalidNotificationTarget        HttpErrorType = "invalid_notification_target"
+	ErrInvalidNotificationEvent         HttpErrorType = "invalid_notification_event"
+	ErrInvalidNotificationChannelID     HttpErrorType = "invalid_notification_channel_id"
+	ErrNotificationChannelNotFound      HttpErrorType = "notification_channel_not_found"
+	ErrNotificationChannelsLimitReached HttpErrorType = "notification_channels_limit_reached"
+	ErrNotificationFailed               HttpErrorType = "notification_failed"
+
+	//stack errors
+	ErrStackTemplateNotFound  HttpErrorType = "stack_template_not_found"
+	ErrInvalidStackTemplate   HttpErrorType = "invalid_stack_template"
+	ErrInvalidStackName       HttpErrorType = "invalid_stack_name"
+	ErrInvalidStackID         HttpErrorType = "invalid_stack_id"
+	ErrStackNotFound          HttpErrorType = "stack_not_found"
+	ErrStackComponentFailed   HttpErrorType = "stack_component_failed"
+	ErrApplicationPartOfStack HttpErrorType = "application_part_of_stack"
 
 	//http errors
 	ErrInvalidRequestBody HttpErrorType = "invalid_request_body"
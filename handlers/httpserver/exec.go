@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gorilla/websocket"
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+)
+
+// text messages sent by the client, binary messages are the stdin
+type WebSocketExecMessage struct {
+	Type string `json:"type"`
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+// opens an interactive shell in the current pod of the application, the
+// terminal is resized with {"type":"resize","cols":80,"rows":24} text messages
+func (h *httpHandler) ExecApplication(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if err := h.controller.CanExecApplication(app, user); err != nil {
+		switch err {
+		case controller.ErrExecNotAllowed:
+			return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+		case controller.ErrExecDisabled:
+			return respError(c, 403, "exec disabled", "opening a shell in the applications is disabled", ErrExecDisabled)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "application not running", "a shell can be opened only when the application is running", ErrInvalidOperationInCurrentState)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+
+	conn, err := webSocketUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		//the upgrader already replied with an http error
+		h.l.Errorf("error upgrading exec connection: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	resize := make(chan model.TerminalSize, 1)
+	output := &webSocketWriter{conn: conn}
+
+	go func() {
+		defer cancel()
+		defer stdinWriter.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			switch messageType {
+			case websocket.BinaryMessage:
+				if _, err := stdinWriter.Write(data); err != nil {
+					return
+				}
+			case websocket.TextMessage:
+				var msg WebSocketExecMessage
+				if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" {
+					continue
+				}
+				//only the latest size matters
+				select {
+				case <-resize:
+				default:
+				}
+				resize <- model.TerminalSize{Width: msg.Cols, Height: msg.Rows}
+			}
+		}
+	}()
+
+	err = h.controller.ExecApplication(ctx, app, user, model.ExecStreams{
+		Stdin:  stdinReader,
+		Stdout: output,
+		Resize: resize,
+	})
+	stdinReader.Close()
+	if err != nil && ctx.Err() == nil {
+		output.Close(websocket.CloseInternalServerErr, "exec session failed")
+		return nil
+	}
+	output.Close(websocket.CloseNormalClosure, "exec session ended")
+	return nil
+}
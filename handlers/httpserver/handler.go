@@ -43,6 +43,7 @@ func (h *httpHandler) RegisterRoutes() {
 	user := authGroup.Group("/user")
 	user.GET("/info", h.UserInfo)
 	user.POST("/update", h.UpdateUser)
+	user.GET("/quota", h.UserQuota)
 	// todo delete
 	// user.DELETE("/", h.DeleteUser)
 
@@ -50,18 +51,69 @@ func (h *httpHandler) RegisterRoutes() {
 	application.GET("/list/:kind", h.ListApplications)
 	application.POST("/new/web", h.NewWebApplication)
 	application.POST("/new/template", h.NewApplicationFromTemplate)
+	application.POST("/new/worker", h.NewWorkerApplication)
 
 	//specific application routes
 	application.GET("/:applicationID", h.GetApplication)
 	application.PATCH("/:applicationID/update/general", h.UpdateApplicationGeneral)
 	application.PATCH("/:applicationID/update/build", h.UpdateApplicationBuild)
+	application.PATCH("/:applicationID/update/healthchecks", h.UpdateApplicationHealthChecks)
 	application.DELETE("/:applicationID/delete", h.DeleteApplication)
 	application.GET("/:applicationID/redeploy", h.RedeployApplication)
 	application.GET("/:applicationID/status", h.GetApplicationStatus)
 	application.GET("/:applicationID/rollout", h.RolloutApplication)
+	application.POST("/:applicationID/resume", h.ResumeApplication)
+	application.GET("/:applicationID/timeline", h.GetApplicationTimeline)
 	application.GET("/:applicationID/logs", h.GetApplicationLogs)
-	// todo, get stats and metrics
-	// application.GET("/:applicationID/stats", h.GetApplicationStats)
+	application.GET("/:applicationID/secrets", h.ListApplicationSecrets)
+	application.PUT("/:applicationID/secrets/:key", h.SetApplicationSecret)
+	application.DELETE("/:applicationID/secrets/:key", h.DeleteApplicationSecret)
+	application.GET("/:applicationID/audit", h.ListApplicationAuditEvents)
+	application.POST("/:applicationID/envgroups/:envGroupID", h.AttachEnvGroup)
+	application.DELETE("/:applicationID/envgroups/:envGroupID", h.DetachEnvGroup)
+	application.POST("/:applicationID/links/:targetID", h.LinkApplications)
+	application.DELETE("/:applicationID/links/:targetID", h.UnlinkApplications)
+	application.POST("/:applicationID/volumes", h.AddApplicationVolume)
+	application.PATCH("/:applicationID/volumes/:volumeName/resize", h.ResizeApplicationVolume)
+	application.DELETE("/:applicationID/volumes/:volumeName", h.RemoveApplicationVolume)
+	application.GET("/:applicationID/backups", h.ListApplicationBackups)
+	application.POST("/:applicationID/backups", h.CreateApplicationBackup)
+	application.PUT("/:applicationID/backups/schedule", h.SetApplicationBackupSchedule)
+	application.POST("/:applicationID/backups/:backupID/restore", h.RestoreApplicationBackup)
+	application.POST("/:applicationID/upgrade", h.UpgradeApplicationTemplate)
+	application.GET("/:applicationID/jobs", h.ListScheduledJobs)
+	application.POST("/:applicationID/jobs", h.CreateScheduledJob)
+	application.PATCH("/:applicationID/jobs/:jobID", h.UpdateScheduledJob)
+	application.DELETE("/:applicationID/jobs/:jobID", h.DeleteScheduledJob)
+	application.POST("/:applicationID/tasks", h.RunApplicationTask)
+	application.GET("/:applicationID/runs", h.ListApplicationJobRuns)
+	application.GET("/:applicationID/runs/:runID", h.GetApplicationJobRun)
+	application.GET("/:applicationID/notifications", h.ListNotificationChannels)
+	application.POST("/:applicationID/notifications", h.CreateNotificationChannel)
+	application.PATCH("/:applicationID/notifications/:channelID", h.UpdateNotificationChannel)
+	application.DELETE("/:applicationID/notifications/:channelID", h.DeleteNotificationChannel)
+	application.POST("/:applicationID/notifications/:channelID/test", h.TestNotificationChannel)
+	application.GET("/:applicationID/stats", h.GetApplicationStats)
+
+	//websockets can't set the authorization header from browsers
+	api.GET("/application/:applicationID/exec", h.ExecApplication, h.webSocketTokenMiddleware, h.jwtHeaderCheckerMiddleware)
+	api.GET("/application/:applicationID/tunnel", h.TunnelApplication, h.webSocketTokenMiddleware, h.jwtHeaderCheckerMiddleware)
+
+	stack := authGroup.Group("/stack")
+	stack.GET("/list", h.ListStacks)
+	stack.POST("/new", h.NewStack)
+	stack.GET("/:stackID", h.GetStack)
+	stack.DELETE("/:stackID/delete", h.DeleteStack)
+
+	volume := authGroup.Group("/volume")
+	volume.GET("/list", h.ListUserVolumes)
+
+	envGroup := authGroup.Group("/envgroup")
+	envGroup.GET("/list", h.ListEnvGroups)
+	envGroup.POST("/new", h.NewEnvGroup)
+	envGroup.GET("/:envGroupID", h.GetEnvGroup)
+	envGroup.PATCH("/:envGroupID/update", h.UpdateEnvGroup)
+	envGroup.DELETE("/:envGroupID/delete", h.DeleteEnvGroup)
 
 	validate := authGroup.Group("/validate")
 	validate.POST("/name", h.IsValidName)
@@ -70,11 +122,23 @@ func (h *httpHandler) RegisterRoutes() {
 	templates := api.Group("/templates")
 	templates.GET("/list", h.ListTemplates)
 	templates.GET("/:code", h.GetTemplate)
+	templates.GET("/stacks/list", h.ListStackTemplates)
+	templates.GET("/stacks/:code", h.GetStackTemplate)
 
 	// analyze := authGroup.Group("/analyze")
 	// analyze.POST("/repo", h.AnalyzeRepositoryContent)
 
-	// adminGroup := api.Group("/admin", h.jwtHeaderCheckerMiddleware, h.adminCheckerMiddleware)
+	adminGroup := api.Group("/admin", h.jwtHeaderCheckerMiddleware, h.adminCheckerMiddleware)
+	adminTemplate := adminGroup.Group("/template")
+	adminTemplate.GET("/list", h.AdminListTemplates)
+	adminTemplate.POST("/new", h.AdminNewTemplate)
+	adminTemplate.GET("/:code/versions", h.AdminListTemplateVersions)
+	adminTemplate.POST("/:code/versions", h.AdminNewTemplateVersion)
+	adminTemplate.PATCH("/:code/update", h.AdminUpdateTemplate)
+	adminTemplate.POST("/:code/deprecate", h.AdminDeprecateTemplate)
+
+	adminGroup.GET("/reconcile/report", h.AdminReconcileReport)
+
 	// adminUser := adminGroup.Group("/user")
 	// adminUser.GET("/list", h.AdminListUsers)
 	// adminUser.GET("/:userID", h.AdminGetUser)
@@ -0,0 +1,118 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	HttpRequestLinkApplications struct {
+		EnvName string `json:"envName"` //optional, the default one of the storage template is used if empty
+	}
+)
+
+// returns the application with id=targetID owned by the user, if an error occurs the
+// response is already sent and the returned error must be returned by the handler
+func (h *httpHandler) getUserLinkTarget(c echo.Context, user *model.User) (*model.Application, error) {
+	targetID, err := primitive.ObjectIDFromHex(c.Param("targetID"))
+	if err != nil {
+		return nil, respError(c, 400, "invalid application id", "targetID is invalid", ErrInvalidApplicationID)
+	}
+
+	ctx := c.Request().Context()
+	target, err := h.controller.GetApplicationByID(ctx, targetID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", targetID.Hex()), ErrInexistingApplication)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	if target.Owner != user.Code {
+		return nil, respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", targetID.Hex()), ErrInexistingApplication)
+	}
+	return target, nil
+}
+
+func (h *httpHandler) LinkApplications(c echo.Context) error {
+	var post HttpRequestLinkApplications
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	target, err := h.getUserLinkTarget(c, user)
+	if target == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.LinkApplications(ctx, user, app, target, post.EnvName); err != nil {
+		h.l.Errorf("error linking applications: %v", err)
+		switch err {
+		case controller.ErrInvalidOperationWithCurrentKind:
+			return respError(c, 400, "invalid operation with current kind", "only a storage application can be linked to a web application", ErrInvalidOperationWithCurrentKind)
+		case controller.ErrApplicationNotLinkable:
+			return respError(c, 400, "application not linkable", "the template of the storage application does not support links", ErrApplicationNotLinkable)
+		case controller.ErrApplicationsAlreadyLinked:
+			return respError(c, 400, "applications already linked", "the storage application is already linked to the application", ErrApplicationsAlreadyLinked)
+		case controller.ErrInvalidLinkEnv:
+			return respError(c, 400, "invalid link env", "the env name must be a valid env name not used by other links of the application", ErrInvalidLinkEnv)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", "one of the applications is being deleted", ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while linking it, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "applications linked successfully", app.Links)
+}
+
+func (h *httpHandler) UnlinkApplications(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	target, err := h.getUserLinkTarget(c, user)
+	if target == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.UnlinkApplications(ctx, user, app, target); err != nil {
+		h.l.Errorf("error unlinking applications: %v", err)
+		switch err {
+		case controller.ErrApplicationsNotLinked:
+			return respError(c, 404, "applications not linked", "the storage application is not linked to the application", ErrApplicationsNotLinked)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while unlinking it, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "applications unlinked successfully", nil)
+}
@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultStatsRange  = time.Hour
+	defaultStatsPoints = 120
+)
+
+// parses RFC3339 times or times relative to now like the log provider
+// (now, now-1h, now-30m)
+func parseStatsTime(value string, now time.Time) (time.Time, error) {
+	if value == "now" {
+		return now, nil
+	}
+	if strings.HasPrefix(value, "now-") {
+		d, err := time.ParseDuration(strings.TrimPrefix(value, "now-"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// returns cpu, memory, restarts, network and requests of the application as
+// time series, from and to default to the last hour, step defaults to the
+// duration that gives about 120 points
+func (h *httpHandler) GetApplicationStats(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	now := time.Now()
+	from, to := now.Add(-defaultStatsRange), now
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = parseStatsTime(value, now); err != nil {
+			return respError(c, 400, "invalid from", fmt.Sprintf("unable to parse %q, use RFC3339 or now-<duration>", value), ErrInvalidMetricsRange)
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = parseStatsTime(value, now); err != nil {
+			return respError(c, 400, "invalid to", fmt.Sprintf("unable to parse %q, use RFC3339 or now-<duration>", value), ErrInvalidMetricsRange)
+		}
+	}
+	step := max(to.Sub(from)/defaultStatsPoints, 15*time.Second).Truncate(time.Second)
+	if value := c.QueryParam("step"); value != "" {
+		if step, err = time.ParseDuration(value); err != nil {
+			return respError(c, 400, "invalid step", fmt.Sprintf("unable to parse %q as a duration", value), ErrInvalidMetricsRange)
+		}
+	}
+
+	stats, err := h.controller.GetApplicationStats(c.Request().Context(), app, user, from, to, step)
+	if err != nil {
+		switch err {
+		case controller.ErrMetricsNotAvailable:
+			return respError(c, 503, "metrics not available", "the metrics of the applications are not available at the moment", ErrMetricsNotAvailable)
+		case controller.ErrInvalidMetricsRange:
+			return respError(c, 400, "invalid range", "from must be before to, step must be at least 1s and the range can't contain too many points", ErrInvalidMetricsRange)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "application not deployed", "the application has not been deployed yet", ErrInvalidOperationInCurrentState)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "application stats", stats)
+}
@@ -2,7 +2,11 @@ package httpserver
 
 import (
 	"runtime/debug"
+	"strconv"
+	"time"
 
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -37,3 +41,37 @@ func (h *httpHandler) jwtHeaderCheckerMiddleware(next echo.HandlerFunc) echo.Han
 		return next(c)
 	}
 }
+
+// must be used after jwtHeaderCheckerMiddleware, only admin users can continue
+func (h *httpHandler) adminCheckerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+		if msgErr != nil {
+			return respErrorFromHttpError(c, msgErr)
+		}
+		if user.Role != model.RoleAdmin {
+			return respError(c, 403, "forbidden", "only admins can access this resource", ErrForbidden)
+		}
+		return next(c)
+	}
+}
+
+// records the latency of the requests by route, the route is the path
+// registered in the router so the ids in the url don't create new series
+func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		status := c.Response().Status
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			status = httpErr.Code
+		}
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// target is the email address for email channels and the url for the webhooks
+	HttpRequestNotificationChannel struct {
+		Kind   model.NotificationChannelKind `json:"kind"`
+		Target string                        `json:"target"`
+		Events []model.NotificationEvent     `json:"events"`
+	}
+
+	// empty values are not updated
+	HttpRequestUpdateNotificationChannel struct {
+		Target  string                    `json:"target"`
+		Events  []model.NotificationEvent `json:"events"`
+		Enabled *bool                     `json:"enabled"`
+	}
+)
+
+// sends the response of the errors shared by all the notification routes
+func respNotificationError(c echo.Context, err error) error {
+	switch err {
+	case controller.ErrNotificationsDisabled:
+		return respError(c, 403, "notifications disabled", "notifications are disabled at the moment", ErrNotificationsDisabled)
+	case controller.ErrNotificationChannelNotSupported:
+		return respError(c, 400, "notification channel not supported", "the supported channels are email, webhook, slack and discord, email might not be available", ErrNotificationChannelNotSupported)
+	case controller.ErrInvalidNotificationTarget:
+		return respError(c, 400, "invalid notification target", "the target must be an email address for email channels and an https url for the webhooks", ErrInvalidNotificationTarget)
+	case controller.ErrInvalidNotificationEvent:
+		return respError(c, 400, "invalid notification events", "at least one of applicationCrashed, buildFailed and rolloutFailed is required", ErrInvalidNotificationEvent)
+	case controller.ErrNotificationChannelsLimitReached:
+		return respError(c, 400, "notification channels limit reached", "the application has reached the maximum number of notification channels", ErrNotificationChannelsLimitReached)
+	case controller.ErrNotificationFailed:
+		return respError(c, 502, "notification failed", "the channel did not accept the notification, check the target", ErrNotificationFailed)
+	case controller.ErrNoChanges:
+		return respSuccess(c, 200, "no changes", nil)
+	default:
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+}
+
+// returns the notification channel of the channelID param, if it's nil the response has already been sent
+func (h *httpHandler) getApplicationNotificationChannel(c echo.Context, app *model.Application) (*model.NotificationChannel, error) {
+	channelID, err := primitive.ObjectIDFromHex(c.Param("channelID"))
+	if err != nil {
+		return nil, respError(c, 400, "invalid channel id", "channelID is invalid", ErrInvalidNotificationChannelID)
+	}
+	channel, err := h.controller.GetNotificationChannel(c.Request().Context(), app, channelID)
+	if err != nil {
+		if err == controller.ErrNotificationChannelNotFound {
+			return nil, respError(c, 404, "notification channel not found", fmt.Sprintf("the notification channel with id=%s does not exists", channelID.Hex()), ErrNotificationChannelNotFound)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return channel, nil
+}
+
+func (h *httpHandler) ListNotificationChannels(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	channels, err := h.controller.ListNotificationChannels(c.Request().Context(), app)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "notification channels", channels)
+}
+
+func (h *httpHandler) CreateNotificationChannel(c echo.Context) error {
+	var post HttpRequestNotificationChannel
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	channel, err := h.controller.CreateNotificationChannel(ctx, app, user, post.Kind, post.Target, post.Events)
+	if err != nil {
+		h.l.Errorf("error creating notification channel: %v", err)
+		return respNotificationError(c, err)
+	}
+	return respSuccess(c, 200, "notification channel created successfully", channel)
+}
+
+func (h *httpHandler) UpdateNotificationChannel(c echo.Context) error {
+	var post HttpRequestUpdateNotificationChannel
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	channel, respErr := h.getApplicationNotificationChannel(c, app)
+	if channel == nil {
+		return respErr
+	}
+
+	ctx := c.Request().Context()
+	channel, err = h.controller.UpdateNotificationChannel(ctx, app, user, channel, post.Target, post.Events, post.Enabled)
+	if err != nil {
+		h.l.Errorf("error updating notification channel: %v", err)
+		return respNotificationError(c, err)
+	}
+	return respSuccess(c, 200, "notification channel updated successfully", channel)
+}
+
+func (h *httpHandler) DeleteNotificationChannel(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	channel, respErr := h.getApplicationNotificationChannel(c, app)
+	if channel == nil {
+		return respErr
+	}
+
+	if err := h.controller.DeleteNotificationChannel(c.Request().Context(), app, user, channel); err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "notification channel deleted successfully", nil)
+}
+
+func (h *httpHandler) TestNotificationChannel(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	channel, respErr := h.getApplicationNotificationChannel(c, app)
+	if channel == nil {
+		return respErr
+	}
+
+	if err := h.controller.TestNotificationChannel(c.Request().Context(), app, channel); err != nil {
+		return respNotificationError(c, err)
+	}
+	return respSuccess(c, 200, "test notification sent", nil)
+}
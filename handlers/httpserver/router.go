@@ -51,6 +51,7 @@ func InitRouter(e *echo.Echo, l *logrus.Logger, controller *controller.Controlle
 		},
 	}))
 	// e.Use(middleware.Recover())
+	e.Use(metricsMiddleware)
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		Skipper:      middleware.DefaultSkipper,
@@ -0,0 +1,232 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// the command is run with sh in the image of the application
+	HttpRequestScheduledJob struct {
+		Name     string `json:"name"`
+		Schedule string `json:"schedule"`
+		Command  string `json:"command"`
+	}
+
+	// empty values are not updated
+	HttpRequestUpdateScheduledJob struct {
+		Schedule string `json:"schedule"`
+		Command  string `json:"command"`
+	}
+
+	HttpRequestTask struct {
+		Command string `json:"command"`
+	}
+
+	HttpResponseJobRun struct {
+		*model.JobRun
+		Logs string `json:"logs"`
+	}
+)
+
+// sends the response of the errors shared by all the job routes
+func respJobError(c echo.Context, app *model.Application, err error) error {
+	switch err {
+	case controller.ErrInvalidJobSchedule:
+		return respError(c, 400, "invalid job schedule", "the schedule must be in the cron format (ex: 0 3 * * *) or a macro like @daily", ErrInvalidJobSchedule)
+	case controller.ErrInvalidJobCommand:
+		return respError(c, 400, "invalid job command", "the command can not be empty or longer than 4096 characters", ErrInvalidJobCommand)
+	case controller.ErrInvalidOperationInCurrentState:
+		return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state and must be deployed to run jobs", app.State), ErrInvalidOperationInCurrentState)
+	case controller.ErrQuotaExceeded:
+		return respError(c, 403, "quota exceeded", "the job requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+	default:
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+}
+
+// returns the scheduled job of the jobID param, if it's nil the response has already been sent
+func (h *httpHandler) getApplicationScheduledJob(c echo.Context, app *model.Application) (*model.ScheduledJob, error) {
+	jobID, err := primitive.ObjectIDFromHex(c.Param("jobID"))
+	if err != nil {
+		return nil, respError(c, 400, "invalid job id", "jobID is invalid", ErrInvalidJobID)
+	}
+	job, err := h.controller.GetScheduledJob(c.Request().Context(), app, jobID)
+	if err != nil {
+		if err == controller.ErrScheduledJobNotFound {
+			return nil, respError(c, 404, "scheduled job not found", fmt.Sprintf("the scheduled job with id=%s does not exists", jobID.Hex()), ErrScheduledJobNotFound)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return job, nil
+}
+
+func (h *httpHandler) ListScheduledJobs(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	jobs, err := h.controller.ListScheduledJobs(c.Request().Context(), app)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "scheduled jobs", jobs)
+}
+
+func (h *httpHandler) CreateScheduledJob(c echo.Context) error {
+	var post HttpRequestScheduledJob
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	job, err := h.controller.CreateScheduledJob(ctx, app, user, post.Name, post.Schedule, post.Command)
+	if err != nil {
+		h.l.Errorf("error creating scheduled job: %v", err)
+		switch err {
+		case controller.ErrInvalidJobName:
+			return respError(c, 400, "invalid job name", "the name must be 1 to 32 lowercase letters, digits or dashes", ErrInvalidJobName)
+		case controller.ErrJobNameNotAvailable:
+			return respError(c, 400, "job name not available", "the application already has a scheduled job with this name", ErrJobNameNotAvailable)
+		case controller.ErrScheduledJobsLimitReached:
+			return respError(c, 400, "scheduled jobs limit reached", "the application has reached the maximum number of scheduled jobs", ErrScheduledJobsLimitReached)
+		}
+		return respJobError(c, app, err)
+	}
+	return respSuccess(c, 200, "scheduled job created successfully", job)
+}
+
+func (h *httpHandler) UpdateScheduledJob(c echo.Context) error {
+	var post HttpRequestUpdateScheduledJob
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	job, respErr := h.getApplicationScheduledJob(c, app)
+	if job == nil {
+		return respErr
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.UpdateScheduledJob(ctx, app, user, job, post.Schedule, post.Command); err != nil {
+		h.l.Errorf("error updating scheduled job: %v", err)
+		return respJobError(c, app, err)
+	}
+	return respSuccess(c, 200, "scheduled job updated successfully", job)
+}
+
+func (h *httpHandler) DeleteScheduledJob(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	job, respErr := h.getApplicationScheduledJob(c, app)
+	if job == nil {
+		return respErr
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DeleteScheduledJob(ctx, app, user, job); err != nil {
+		h.l.Errorf("error deleting scheduled job: %v", err)
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "scheduled job deleted successfully", nil)
+}
+
+func (h *httpHandler) RunApplicationTask(c echo.Context) error {
+	var post HttpRequestTask
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	run, err := h.controller.RunApplicationTask(ctx, app, user, post.Command)
+	if err != nil {
+		h.l.Errorf("error running application task: %v", err)
+		return respJobError(c, app, err)
+	}
+	return respSuccess(c, 200, "task started", run)
+}
+
+func (h *httpHandler) ListApplicationJobRuns(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	runs, err := h.controller.ListApplicationJobRuns(c.Request().Context(), app, user)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "job runs", runs)
+}
+
+func (h *httpHandler) GetApplicationJobRun(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	runID, err := primitive.ObjectIDFromHex(c.Param("runID"))
+	if err != nil {
+		return respError(c, 400, "invalid run id", "runID is invalid", ErrInvalidJobRunID)
+	}
+
+	run, logs, err := h.controller.GetApplicationJobRun(c.Request().Context(), app, user, runID)
+	if err != nil {
+		if err == controller.ErrJobRunNotFound {
+			return respError(c, 404, "job run not found", fmt.Sprintf("the job run with id=%s does not exists", runID.Hex()), ErrJobRunNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "job run", HttpResponseJobRun{JobRun: run, Logs: logs})
+}
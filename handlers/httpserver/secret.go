@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	HttpRequestSetSecret struct {
+		Value string `json:"value"`
+	}
+)
+
+// the values are always masked, secrets are write only
+func (h *httpHandler) ListApplicationSecrets(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	return respSuccess(c, 200, "application secrets", app.Secrets)
+}
+
+func (h *httpHandler) SetApplicationSecret(c echo.Context) error {
+	var post HttpRequestSetSecret
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.SetApplicationSecret(ctx, app, user, c.Param("key"), post.Value); err != nil {
+		h.l.Errorf("error setting application secret: %v", err)
+		switch err {
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "the key must be a valid env name and the value can not be empty", ErrInvalidSecret)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while setting the secret, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "application secret set successfully")
+}
+
+func (h *httpHandler) DeleteApplicationSecret(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DeleteApplicationSecret(ctx, app, user, c.Param("key")); err != nil {
+		h.l.Errorf("error deleting application secret: %v", err)
+		switch err {
+		case controller.ErrSecretNotFound:
+			return respError(c, 404, "secret not found", fmt.Sprintf("the application has no secret with key=%s", c.Param("key")), ErrSecretNotFound)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while deleting the secret, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "application secret deleted successfully")
+}
+
+func (h *httpHandler) ListApplicationAuditEvents(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	events, err := h.controller.ListApplicationAuditEvents(ctx, app)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "application audit events", events)
+}
@@ -0,0 +1,178 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	HttpRequestNewStack struct {
+		Name      string `json:"name"`
+		StackCode string `json:"stackCode"`
+		// envs of every component by component name
+		Envs map[string][]model.KeyValue `json:"envs,omitempty"`
+		// set in all the components
+		SharedEnvs []model.KeyValue `json:"sharedEnvs,omitempty"`
+	}
+
+	HttpStack struct {
+		*model.Stack
+		Applications []*model.Application `json:"applications"`
+	}
+)
+
+// returns the stack of the path param if owned by the user, on error
+// the response is already sent and the returned error must be returned by the handler
+func (h *httpHandler) getUserStack(c echo.Context, user *model.User) (*model.Stack, error) {
+	stackID, err := primitive.ObjectIDFromHex(c.Param("stackID"))
+	if err != nil {
+		return nil, respError(c, 400, "invalid stack id", "stackID is invalid", ErrInvalidStackID)
+	}
+
+	ctx := c.Request().Context()
+	stack, err := h.controller.GetStack(ctx, user, stackID)
+	if err != nil {
+		if err == controller.ErrStackNotFound {
+			return nil, respError(c, 404, "stack not found", fmt.Sprintf("the stack with id=%s does not exists", stackID.Hex()), ErrStackNotFound)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return stack, nil
+}
+
+func (h *httpHandler) ListStackTemplates(c echo.Context) error {
+	ctx := c.Request().Context()
+	stackTemplates, err := h.controller.ListStackTemplates(ctx)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "stack templates listed successfully", stackTemplates)
+}
+
+func (h *httpHandler) GetStackTemplate(c echo.Context) error {
+	ctx := c.Request().Context()
+	stackTemplate, err := h.controller.GetStackTemplateByCode(ctx, c.Param("code"))
+	if err != nil {
+		if err == controller.ErrStackTemplateNotFound {
+			return respError(c, 404, "stack template not found", "this stack template code is not found, make sure the right one was selected", ErrStackTemplateNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "stack template retrieved successfully", stackTemplate)
+}
+
+func (h *httpHandler) ListStacks(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	ctx := c.Request().Context()
+	stacks, err := h.controller.ListStacks(ctx, user)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "stacks", stacks)
+}
+
+func (h *httpHandler) NewStack(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	post := new(HttpRequestNewStack)
+	if err := c.Bind(post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	ctx := c.Request().Context()
+	stackTemplate, err := h.controller.GetStackTemplateByCode(ctx, post.StackCode)
+	if err != nil {
+		if err == controller.ErrStackTemplateNotFound {
+			return respError(c, 400, "stack template not found", "this stack template code is not found, make sure the right one was selected", ErrStackTemplateNotFound)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+
+	stack, generated, err := h.controller.CreateStack(ctx, user, post.Name, stackTemplate, post.Envs, post.SharedEnvs)
+	if err != nil {
+		h.l.Errorf("error creating stack: %v", err)
+		switch err {
+		case controller.ErrInvalidStackName:
+			return respError(c, 400, "invalid stack name", "the name must be lowercase alphanumeric with dashes and at most 24 characters", ErrInvalidStackName)
+		case controller.ErrApplicationNameNotAvailable:
+			return respError(c, 400, "name taken", "you already have a stack or an application with this name", ErrNameTaken)
+		case controller.ErrInvalidStackTemplate, controller.ErrTemplateDeprecated:
+			return respError(c, 500, "invalid stack template", "the stack template is not valid, please contact the customer support", ErrInvalidStackTemplate)
+		case controller.ErrInvalidEnv:
+			return respError(c, 400, "invalid env", "envs must be key value pairs and can only be set for the components of the stack", ErrInvalidRequestBody)
+		case controller.ErrMissingRequiredEnvForTemplate:
+			return respError(c, 400, "missing required envs", "missing required envs for a component of the stack", ErrMissingRequiredEnvForTemplate)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secret envs must have a valid env name and a non empty value", ErrInvalidSecret)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "creating the applications of this stack would exceed your quota", ErrQuotaExceeded)
+		case controller.ErrStackComponentFailed:
+			return respError(c, 500, "stack component failed", "a component of the stack failed to start, the stack was deleted", ErrStackComponentFailed)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while creating a component of the stack, try again", ErrApplicationChanged)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+
+	resp := map[string]interface{}{
+		"stackID": stack.ID.Hex(),
+		"state":   stack.State,
+	}
+	//the generated credentials are only returned here, they can't be read again
+	if len(generated) > 0 {
+		resp["generatedEnvs"] = generated
+	}
+	return respSuccess(c, 200, "stack created successfully", resp)
+}
+
+func (h *httpHandler) GetStack(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	stack, err := h.getUserStack(c, user)
+	if stack == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	apps, err := h.controller.GetStackApplications(ctx, stack)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "stack", &HttpStack{Stack: stack, Applications: apps})
+}
+
+func (h *httpHandler) DeleteStack(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	stack, err := h.getUserStack(c, user)
+	if stack == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.DeleteStack(ctx, user, stack); err != nil {
+		h.l.Errorf("error deleting stack: %v", err)
+		if err == controller.ErrInvalidOperationInCurrentState {
+			return respError(c, 400, "invalid operation in current state", "the stack or one of its applications is still starting, retry later", ErrInvalidOperationInCurrentState)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "stack deleted successfully", nil)
+}
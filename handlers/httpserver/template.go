@@ -1,10 +1,13 @@
 package httpserver
 
 import (
+	"fmt"
+
 	"github.com/ipaas-org/ipaas-backend/controller"
 	"github.com/ipaas-org/ipaas-backend/model"
 	"github.com/ipaas-org/ipaas-backend/repo"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type (
@@ -12,16 +15,23 @@ type (
 		Name         string           `json:"name"`
 		TemplateCode string           `json:"templateCode"`
 		Envs         []model.KeyValue `json:"envs,omitempty"`
+		// required by the managment templates bound to a storage application
+		TargetID string `json:"targetID,omitempty"`
+		// bound managment applications are private unless set
+		Public bool `json:"public,omitempty"`
 	}
 
 	HttpTemplate struct {
 		Code          string                `json:"code"`
+		Version       int                   `json:"version"`
 		Name          string                `json:"name"`
 		RequiredEnvs  []model.KeyValue      `json:"requiredEnvs"`
 		OptionalEnvs  []model.KeyValue      `json:"optionalEnvs"`
+		GeneratedEnvs []model.GeneratedEnv  `json:"generatedEnvs"` //envs generated if not set, even if required
 		Description   string                `json:"description"`
 		Documentation string                `json:"documentation"`
 		Kind          model.ApplicationKind `json:"kind"`
+		Target        *model.TemplateTarget `json:"target,omitempty"` //the storage templates it can be bound to
 	}
 )
 
@@ -50,11 +60,35 @@ func (h *httpHandler) NewApplicationFromTemplate(c echo.Context) error {
 		return respError(c, 500, "unexpected error", "", ErrUnexpected)
 	}
 
-	app, err := h.controller.CreateNewApplicationBasedOnTemplate(ctx, user.Code, post.Name, template, post.Envs)
+	var app *model.Application
+	var generated []model.KeyValue
+	if template.Target != nil || post.TargetID != "" {
+		target, respErr := h.getUserTargetApplication(c, user, post.TargetID)
+		if target == nil {
+			return respErr
+		}
+		app, generated, err = h.controller.CreateManagmentApplication(ctx, user.Code, post.Name, template, post.Envs, target, post.Public)
+	} else {
+		app, generated, err = h.controller.CreateNewApplicationBasedOnTemplate(ctx, user.Code, post.Name, template, post.Envs)
+	}
 	if err != nil {
 		switch err {
+		case controller.ErrInvalidOperationWithCurrentKind:
+			return respError(c, 400, "invalid operation with current kind", "only managment templates can be bound to a storage application", ErrInvalidOperationWithCurrentKind)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", "the target storage application is not running", ErrInvalidOperationInCurrentState)
+		case controller.ErrIncompatibleTarget:
+			return respError(c, 400, "incompatible target", "the target storage application is not supported by this template", ErrIncompatibleTarget)
 		case controller.ErrMissingRequiredEnvForTemplate:
 			return respError(c, 400, "missing required envs", "missing required envs for this template", ErrMissingRequiredEnvForTemplate)
+		case controller.ErrTemplateDeprecated:
+			return respError(c, 400, "template deprecated", "this template is deprecated and can't be used for new applications", ErrTemplateDeprecated)
+		case controller.ErrInvalidSecret:
+			return respError(c, 400, "invalid secret", "secret envs must have a valid env name and a non empty value", ErrInvalidSecret)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "creating this application would exceed your quota, delete an application or free some resources", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while creating it, try again", ErrApplicationChanged)
 		}
 		return respError(c, 500, "unexpected error", "", ErrUnexpected)
 	}
@@ -63,9 +97,38 @@ func (h *httpHandler) NewApplicationFromTemplate(c echo.Context) error {
 		"applicationID": app.ID.Hex(),
 		"state":         app.State,
 	}
+	//the generated credentials are only returned here, they can't be read again
+	if len(generated) > 0 {
+		resp["generatedEnvs"] = generated
+	}
 	return respSuccess(c, 200, "application created successfully", resp)
 }
 
+// returns the storage application a managment application is bound to, on error
+// the response is already sent and the returned error must be returned by the handler
+func (h *httpHandler) getUserTargetApplication(c echo.Context, user *model.User, targetIDHex string) (*model.Application, error) {
+	if targetIDHex == "" {
+		return nil, respError(c, 400, "missing target id", "this template must be bound to a storage application, targetID is required", ErrInvalidApplicationID)
+	}
+	targetID, err := primitive.ObjectIDFromHex(targetIDHex)
+	if err != nil {
+		return nil, respError(c, 400, "invalid target id", "targetID is invalid", ErrInvalidApplicationID)
+	}
+
+	ctx := c.Request().Context()
+	target, err := h.controller.GetApplicationByID(ctx, targetID)
+	if err != nil {
+		if err == repo.ErrNotFound {
+			return nil, respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", targetID.Hex()), ErrInexistingApplication)
+		}
+		return nil, respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	if target.Owner != user.Code {
+		return nil, respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", targetID.Hex()), ErrInexistingApplication)
+	}
+	return target, nil
+}
+
 func (h *httpHandler) ListTemplates(c echo.Context) error {
 	ctx := c.Request().Context()
 	templates, err := h.controller.ListTemplates(ctx)
@@ -76,12 +139,15 @@ func (h *httpHandler) ListTemplates(c echo.Context) error {
 	for _, t := range templates {
 		respTemplates = append(respTemplates, &HttpTemplate{
 			Code:          t.Code,
+			Version:       t.Version,
 			Name:          t.Name,
 			RequiredEnvs:  t.RequiredEnvs,
 			OptionalEnvs:  t.OptionalEnvs,
+			GeneratedEnvs: t.GeneratedEnvs,
 			Description:   t.Description,
 			Documentation: t.Documentation,
 			Kind:          t.Kind,
+			Target:        t.Target,
 		})
 	}
 	return respSuccess(c, 200, "templates listed successfully", respTemplates)
@@ -102,12 +168,67 @@ func (h *httpHandler) GetTemplate(c echo.Context) error {
 	}
 	resp := &HttpTemplate{
 		Code:          template.Code,
+		Version:       template.Version,
 		Name:          template.Name,
 		RequiredEnvs:  template.RequiredEnvs,
 		OptionalEnvs:  template.OptionalEnvs,
+		GeneratedEnvs: template.GeneratedEnvs,
 		Description:   template.Description,
 		Documentation: template.Documentation,
 		Kind:          template.Kind,
+		Target:        template.Target,
 	}
 	return respSuccess(c, 200, "template retrieved successfully", resp)
 }
+
+type HttpRequestUpgradeApplication struct {
+	Version int `json:"version"` //optional, the latest version is used if not set
+}
+
+func (h *httpHandler) UpgradeApplicationTemplate(c echo.Context) error {
+	var post HttpRequestUpgradeApplication
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	generated, err := h.controller.UpgradeApplicationTemplate(ctx, app, user, post.Version)
+	if err != nil {
+		h.l.Errorf("error upgrading application template: %v", err)
+		switch err {
+		case controller.ErrTemplateNotFound:
+			return respError(c, 404, "template version not found", fmt.Sprintf("the template %s has no version %d", app.BasedOn, post.Version), ErrTemplateCodeNotFound)
+		case controller.ErrTemplateVersionNotNewer:
+			return respError(c, 400, "template version not newer", fmt.Sprintf("the application already uses version %d of the template", app.TemplateVersion), ErrTemplateVersionNotNewer)
+		case controller.ErrMissingRequiredEnvForTemplate:
+			return respError(c, 400, "missing required envs", "the new version requires envs that are not set in the application", ErrMissingRequiredEnvForTemplate)
+		case controller.ErrInvalidOperationWithCurrentKind:
+			return respError(c, 400, "invalid operation with current kind", "only storage applications can be upgraded", ErrInvalidOperationWithCurrentKind)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the new version requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while upgrading it, try again", ErrApplicationChanged)
+		}
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+
+	resp := map[string]interface{}{
+		"templateVersion": app.TemplateVersion,
+	}
+	//the generated credentials are only returned here, they can't be read again
+	if len(generated) > 0 {
+		resp["generatedEnvs"] = generated
+	}
+	return respSuccess(c, 200, "application upgraded successfully", resp)
+}
@@ -0,0 +1,63 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gorilla/websocket"
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/labstack/echo/v4"
+)
+
+// opens a tcp tunnel to the listening port of a storage application, the
+// binary messages carry the bytes of the connection in both directions.
+// Each websocket is a single tcp connection, see cmd/ipaas-tunnel
+func (h *httpHandler) TunnelApplication(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	if err := h.controller.CanTunnelApplication(app, user); err != nil {
+		switch err {
+		case controller.ErrTunnelDisabled:
+			return respError(c, 403, "tunnel disabled", "tunnels to the applications are disabled", ErrTunnelDisabled)
+		case controller.ErrTunnelNotSupported:
+			return respError(c, 400, "tunnel not supported", "tunnels can be opened only to storage applications", ErrTunnelNotSupported)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "application not running", "a tunnel can be opened only when the application is running", ErrInvalidOperationInCurrentState)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+
+	conn, err := webSocketUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		//the upgrader already replied with an http error
+		h.l.Errorf("error upgrading tunnel connection: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	output := &webSocketWriter{conn: conn}
+	stream := struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: &webSocketReader{conn: conn},
+		Writer: output,
+	}
+
+	//the request context is not cancelled when the websocket is closed
+	if err := h.controller.TunnelApplication(context.Background(), app, user, stream); err != nil {
+		output.Close(websocket.CloseInternalServerErr, "tunnel failed")
+		return nil
+	}
+	output.Close(websocket.CloseNormalClosure, "tunnel closed")
+	return nil
+}
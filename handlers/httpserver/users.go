@@ -75,3 +75,18 @@ func (h *httpHandler) UpdateUser(c echo.Context) error {
 
 	return respSuccess(c, 200, "user info updated correctly", post.Theme)
 }
+
+func (h *httpHandler) UserQuota(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+	ctx := c.Request().Context()
+
+	usage, err := h.controller.GetUserQuotaUsage(ctx, user)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "unexpected error trying to get the quota usage", ErrUnexpected)
+	}
+
+	return respSuccess(c, 200, "user quota usage", usage)
+}
@@ -44,7 +44,7 @@ func (h *httpHandler) IsValidName(c echo.Context) error {
 			return respSuccess(c, 200, "name is not available", &HttpNameValidatingResponse{Valid: false})
 		}
 		return respSuccess(c, 200, "name is available", &HttpNameValidatingResponse{Valid: true})
-	case model.ApplicationKindStorage, model.ApplicationKindManagment:
+	case model.ApplicationKindStorage, model.ApplicationKindManagment, model.ApplicationKindWorker:
 		user, err := h.ValidateAccessTokenAndGetUser(c)
 		if err != nil {
 			h.l.Errorf("error validating user when validating name: %v", err.Message)
@@ -0,0 +1,140 @@
+package httpserver
+
+import (
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// the size is in Gi
+	HttpRequestNewVolume struct {
+		Name      string `json:"name"`
+		MountPath string `json:"mountPath"`
+		Size      int64  `json:"size"`
+	}
+
+	HttpRequestResizeVolume struct {
+		Size int64 `json:"size"`
+	}
+)
+
+func (h *httpHandler) ListUserVolumes(c echo.Context) error {
+	user, msgErr := h.ValidateAccessTokenAndGetUser(c)
+	if msgErr != nil {
+		return respErrorFromHttpError(c, msgErr)
+	}
+
+	ctx := c.Request().Context()
+	volumes, err := h.controller.ListUserVolumes(ctx, user)
+	if err != nil {
+		return respError(c, 500, "unexpected error", "", ErrUnexpected)
+	}
+	return respSuccess(c, 200, "user volumes", volumes)
+}
+
+func (h *httpHandler) AddApplicationVolume(c echo.Context) error {
+	var post HttpRequestNewVolume
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	volume, err := h.controller.AddApplicationVolume(ctx, app, user, post.Name, post.MountPath, post.Size)
+	if err != nil {
+		h.l.Errorf("error adding application volume: %v", err)
+		switch err {
+		case controller.ErrInvalidVolume:
+			return respError(c, 400, "invalid volume", "the name must be a valid dns label and the mount path an absolute path", ErrInvalidVolume)
+		case controller.ErrInvalidVolumeSize:
+			return respError(c, 400, "invalid volume size", "the size is in Gi and must be greater than 0 and not exceed the max size", ErrInvalidVolumeSize)
+		case controller.ErrVolumeNameNotAvailable:
+			return respError(c, 400, "volume name not available", "the application already has a volume with the same name or mount path", ErrVolumeNameNotAvailable)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the volume requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while adding the volume, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "volume added successfully", volume)
+}
+
+func (h *httpHandler) ResizeApplicationVolume(c echo.Context) error {
+	var post HttpRequestResizeVolume
+	if err := c.Bind(&post); err != nil {
+		return respError(c, 400, "invalid request body", "", ErrInvalidRequestBody)
+	}
+
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	volume, err := h.controller.ResizeApplicationVolume(ctx, app, user, c.Param("volumeName"), post.Size)
+	if err != nil {
+		h.l.Errorf("error resizing application volume: %v", err)
+		switch err {
+		case controller.ErrVolumeNotFound:
+			return respError(c, 404, "volume not found", fmt.Sprintf("the application has no volume with name=%s", c.Param("volumeName")), ErrVolumeNotFound)
+		case controller.ErrInvalidVolumeSize:
+			return respError(c, 400, "invalid volume size", "the size is in Gi, it must be greater than the current one and not exceed the max size", ErrInvalidVolumeSize)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the volume requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while resizing the volume, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "volume resized successfully", volume)
+}
+
+func (h *httpHandler) RemoveApplicationVolume(c echo.Context) error {
+	user, app, err := h.GetUserAndApplication(c)
+	if user == nil || app == nil {
+		return err
+	}
+
+	if user.Code != app.Owner {
+		return respError(c, 404, "inexisting applcation id", fmt.Sprintf("the application with id=%s does not exists", app.ID.Hex()), ErrInexistingApplication)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.controller.RemoveApplicationVolume(ctx, app, user, c.Param("volumeName")); err != nil {
+		h.l.Errorf("error removing application volume: %v", err)
+		switch err {
+		case controller.ErrVolumeNotFound:
+			return respError(c, 404, "volume not found", fmt.Sprintf("the application has no volume with name=%s", c.Param("volumeName")), ErrVolumeNotFound)
+		case controller.ErrInvalidOperationInCurrentState:
+			return respError(c, 400, "invalid operation in current state", fmt.Sprintf("the application is in %q state, this operation is not allowed in that state", app.State), ErrInvalidOperationInCurrentState)
+		case controller.ErrQuotaExceeded:
+			return respError(c, 403, "quota exceeded", "the update requires more resources than the ones allowed by your quota", ErrQuotaExceeded)
+		case controller.ErrApplicationChanged:
+			return respError(c, 409, "application changed", "the application was changed while removing the volume, try again", ErrApplicationChanged)
+		default:
+			return respError(c, 500, "unexpected error", "", ErrUnexpected)
+		}
+	}
+	return respSuccess(c, 200, "volume removed successfully", nil)
+}
@@ -0,0 +1,99 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// the client must offer this subprotocol, it can also offer
+	// bearer.<access token> since browsers can't set headers on websockets
+	webSocketSubprotocol   = "ipaas"
+	webSocketBearerPrefix  = "bearer."
+	webSocketWriteDeadline = 10 * time.Second
+)
+
+var webSocketUpgrader = websocket.Upgrader{
+	Subprotocols: []string{webSocketSubprotocol},
+	//the authentication is done with the token and not with cookies
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type (
+	// reads the binary messages as a stream, text messages are ignored
+	webSocketReader struct {
+		conn    *websocket.Conn
+		current io.Reader
+	}
+
+	// writes binary messages, safe to be used from multiple goroutines
+	webSocketWriter struct {
+		mu   sync.Mutex
+		conn *websocket.Conn
+	}
+)
+
+func (r *webSocketReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			messageType, reader, err := r.conn.NextReader()
+			if err != nil {
+				//closing the websocket is the end of the stream
+				return 0, io.EOF
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			r.current = reader
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (w *webSocketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conn.SetWriteDeadline(time.Now().Add(webSocketWriteDeadline))
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *webSocketWriter) Close(code int, reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msg := websocket.FormatCloseMessage(code, reason)
+	w.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(webSocketWriteDeadline))
+}
+
+// must be used before jwtHeaderCheckerMiddleware, moves the access token sent
+// as websocket subprotocol in the authorization header
+func (h *httpHandler) webSocketTokenMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Header.Get("Authorization") == "" {
+			for _, protocol := range websocket.Subprotocols(req) {
+				if strings.HasPrefix(protocol, webSocketBearerPrefix) {
+					req.Header.Set("Authorization", "Bearer "+strings.TrimPrefix(protocol, webSocketBearerPrefix))
+					break
+				}
+			}
+		}
+		return next(c)
+	}
+}
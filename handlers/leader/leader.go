@@ -0,0 +1,86 @@
+package leader
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+type electorGeneratorFunc func(callbacks leaderelection.LeaderCallbacks) (*leaderelection.LeaderElector, error)
+
+// LeaderElection runs the election of the replica running the leader only routines.
+// The context of the leadership is sent on Leading every time it's acquired, it's
+// cancelled when the leadership is lost or released
+type LeaderElection struct {
+	elector *leaderelection.LeaderElector
+	ctx     context.Context //context of the election, cancelled on shutdown
+	leading atomic.Bool
+	Leading chan context.Context
+	l       *logrus.Logger
+	Done    chan struct{}
+}
+
+func NewLeaderElection(electorGenerator electorGeneratorFunc, l *logrus.Logger) (*LeaderElection, error) {
+	l.Debug("creating leader election")
+	e := new(LeaderElection)
+	e.l = l
+	e.Leading = make(chan context.Context, 1)
+	e.Done = make(chan struct{})
+
+	elector, err := electorGenerator(leaderelection.LeaderCallbacks{
+		OnStartedLeading: e.onStartedLeading,
+		OnStoppedLeading: e.onStoppedLeading,
+		OnNewLeader:      e.onNewLeader,
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.elector = elector
+	return e, nil
+}
+
+func StartLeaderElection(ctx context.Context, e *LeaderElection, ID int, RoutineMonitor chan int) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.l.Errorf("leader election panic, recovering: \nerror: %v\n\nstack: %s", r, string(debug.Stack()))
+		}
+		if ctx.Err() == nil {
+			RoutineMonitor <- ID
+		} else {
+			e.l.Info("LeaderElection not restarting, context was canceled")
+			close(e.Done)
+		}
+	}()
+
+	e.l.Info(">>> Starting LeaderElection >>>")
+	e.ctx = ctx
+	//blocks until the context is cancelled or the leadership is lost,
+	//on cancel the lease is released before returning
+	e.elector.Run(ctx)
+}
+
+func (e *LeaderElection) onStartedLeading(ctx context.Context) {
+	e.l.Info("leadership acquired, starting leader routines")
+	e.leading.Store(true)
+	e.Leading <- ctx
+}
+
+// a replica that lost the leadership can't be sure its routines are stopped
+// before the new leader starts them, it exits to be restarted as a follower
+func (e *LeaderElection) onStoppedLeading() {
+	if !e.leading.Swap(false) {
+		return
+	}
+	if e.ctx.Err() != nil {
+		e.l.Info("leadership released")
+		return
+	}
+	e.l.Fatal("leadership lost, exiting to not run the leader routines twice")
+}
+
+func (e *LeaderElection) onNewLeader(identity string) {
+	e.l.Infof("current leader: %s", identity)
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime/debug"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/controller"
 	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
 )
@@ -29,6 +31,9 @@ type RabbitMQ struct {
 	restarts int
 }
 
+// how often the messages waiting in the response queue are counted
+const queueInspectInterval = 15 * time.Second
+
 func NewRabbitMQ(uri, requestQueue, responseQueue string, controller *controller.Controller, logger *logrus.Logger) *RabbitMQ {
 	logger.Infof("listening on %s for responses", responseQueue)
 	return &RabbitMQ{
@@ -140,14 +145,41 @@ func (r *RabbitMQ) Start(ctx context.Context, ID int, routineMonitor chan int) {
 	r.l.Info("rabbitmq done consuming")
 }
 
+// updates the number of messages waiting in the response queue, they are
+// the lag of the consumer since the messages are processed one at a time
+func (r *RabbitMQ) inspectResponseQueue() {
+	queue, err := r.Channel.QueueDeclarePassive(
+		r.responseQueueName, // name
+		true,                // durable
+		false,               // delete when unused
+		false,               // exclusive
+		false,               // no-wait
+		nil,                 // arguments
+	)
+	if err != nil {
+		r.l.Errorf("r.Channel.QueueDeclarePassive: %v", err)
+		return
+	}
+	metrics.ResponseQueueMessages.Set(float64(queue.Messages))
+}
+
 func (r *RabbitMQ) consume(ctx context.Context) {
+	ticker := time.NewTicker(queueInspectInterval)
+	defer ticker.Stop()
+	r.inspectResponseQueue()
+
 	for {
 		select {
 		case <-ctx.Done():
 			r.l.Info("stopping rabbitmq consumer")
 			return
+		case <-ticker.C:
+			r.inspectResponseQueue()
 		case d := <-r.Delivery:
 			r.l.Info("received message from rabbitmq")
+			if !d.Timestamp.IsZero() {
+				metrics.ResponseQueueDelaySeconds.Observe(time.Since(d.Timestamp).Seconds())
+			}
 			r.l.Debugf("received: %q", string(d.Body))
 			if d.Body == nil {
 				if err := d.Ack(false); err != nil {
@@ -169,6 +201,7 @@ func (r *RabbitMQ) consume(ctx context.Context) {
 			}
 
 			r.l.Debug(response)
+			metrics.BuildResponses.WithLabelValues(string(response.Status), string(response.Fault)).Inc()
 			if response.IsError {
 				r.l.Info("r.Controller: error building image:", response.Message)
 				r.l.Info("r.Controller: error building image fault:", response.Fault)
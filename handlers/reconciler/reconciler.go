@@ -0,0 +1,80 @@
+package reconciler
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/controller"
+	"github.com/sirupsen/logrus"
+)
+
+// periodically reconciles the applications in the database with the cluster
+type Reconciler struct {
+	controller *controller.Controller
+	interval   time.Duration
+	dryRun     bool
+	l          *logrus.Logger
+	Done       chan struct{}
+}
+
+func NewReconciler(controller *controller.Controller, interval time.Duration, dryRun bool, l *logrus.Logger) *Reconciler {
+	l.Debug("creating reconciler")
+	return &Reconciler{
+		controller: controller,
+		interval:   interval,
+		dryRun:     dryRun,
+		l:          l,
+		Done:       make(chan struct{}),
+	}
+}
+
+func StartReconciler(ctx context.Context, r *Reconciler, ID int, RoutineMonitor chan int) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.l.Errorf("reconciler panic, recovering: \nerror: %v\n\nstack: %s", rec, string(debug.Stack()))
+		}
+		if ctx.Err() == nil {
+			RoutineMonitor <- ID
+		} else {
+			r.l.Info("Reconciler not restarting, context was canceled")
+			r.Done <- struct{}{}
+		}
+	}()
+
+	r.l.Info(">>> Starting Reconciler >>>")
+	r.start(ctx)
+}
+
+// reconciles once when started, operations interrupted by a restart or by the
+// loss of the leadership are resumed without waiting for the first interval
+func (r *Reconciler) start(ctx context.Context) {
+	r.reconcile(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.l.Info("Reconciler context canceled, stopping")
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	report, err := r.controller.Reconcile(ctx, r.dryRun)
+	if err != nil {
+		r.l.Errorf("error reconciling: %v", err)
+		return
+	}
+	applied := 0
+	for _, action := range report.Actions {
+		if action.Applied {
+			applied++
+		}
+	}
+	r.l.Infof("reconciliation completed in %v, %d actions found, %d applied", report.FinishedAt.Sub(report.StartedAt), len(report.Actions), applied)
+}
@@ -12,13 +12,18 @@ import (
 	"github.com/ipaas-org/ipaas-backend/controller"
 	events "github.com/ipaas-org/ipaas-backend/handlers/containerEvents"
 	"github.com/ipaas-org/ipaas-backend/handlers/httpserver"
+	"github.com/ipaas-org/ipaas-backend/handlers/leader"
 	"github.com/ipaas-org/ipaas-backend/handlers/rabbitmq"
+	"github.com/ipaas-org/ipaas-backend/handlers/reconciler"
 	"github.com/ipaas-org/ipaas-backend/pkg/logger"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
 	"github.com/ipaas-org/ipaas-backend/repo/mock"
 	mongoRepo "github.com/ipaas-org/ipaas-backend/repo/mongo"
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"k8s.io/client-go/tools/leaderelection"
 )
 
 const (
@@ -30,6 +35,8 @@ const (
 	StartRMQHandler
 	StartDatabaseCleanupHandler
 	StartContainerEventHandler
+	StartReconciler
+	StartLeaderElection
 )
 
 func main() {
@@ -53,6 +60,16 @@ func main() {
 		c.TokenRepo = mock.NewTokenRepoer()
 		c.StateRepo = mock.NewStateRepoer()
 		c.ApplicationRepo = mock.NewApplicationRepoer()
+		c.TemplateRepo = mock.NewTemplateRepoer()
+		c.AuditRepo = mock.NewAuditRepoer()
+		c.StateHistoryRepo = mock.NewStateHistoryRepoer()
+		c.EnvGroupRepo = mock.NewEnvGroupRepoer()
+		c.BackupRepo = mock.NewBackupRepoer()
+		c.StackTemplateRepo = mock.NewStackTemplateRepoer()
+		c.StackRepo = mock.NewStackRepoer()
+		c.ScheduledJobRepo = mock.NewScheduledJobRepoer()
+		c.JobRunRepo = mock.NewJobRunRepoer()
+		c.NotificationChannelRepo = mock.NewNotificationChannelRepoer()
 
 	case "mongo":
 		l.Info("using mongo database")
@@ -93,6 +110,51 @@ func main() {
 		templateCollection := client.Database("ipaas").Collection("templates")
 		templateRepo := mongoRepo.NewTemplateRepoer(templateCollection)
 		c.TemplateRepo = templateRepo
+
+		l.Debug("connecting to audit collection")
+		auditCollection := client.Database("ipaas").Collection("audit")
+		auditRepo := mongoRepo.NewAuditRepoer(auditCollection)
+		c.AuditRepo = auditRepo
+
+		l.Debug("connecting to state history collection")
+		stateHistoryCollection := client.Database("ipaas").Collection("stateHistory")
+		stateHistoryRepo := mongoRepo.NewStateHistoryRepoer(stateHistoryCollection)
+		c.StateHistoryRepo = stateHistoryRepo
+
+		l.Debug("connecting to env group collection")
+		envGroupCollection := client.Database("ipaas").Collection("envGroup")
+		envGroupRepo := mongoRepo.NewEnvGroupRepoer(envGroupCollection)
+		c.EnvGroupRepo = envGroupRepo
+
+		l.Debug("connecting to backup collection")
+		backupCollection := client.Database("ipaas").Collection("backup")
+		backupRepo := mongoRepo.NewBackupRepoer(backupCollection)
+		c.BackupRepo = backupRepo
+
+		l.Debug("connecting to stack template collection")
+		stackTemplateCollection := client.Database("ipaas").Collection("stackTemplates")
+		stackTemplateRepo := mongoRepo.NewStackTemplateRepoer(stackTemplateCollection)
+		c.StackTemplateRepo = stackTemplateRepo
+
+		l.Debug("connecting to stack collection")
+		stackCollection := client.Database("ipaas").Collection("stack")
+		stackRepo := mongoRepo.NewStackRepoer(stackCollection)
+		c.StackRepo = stackRepo
+
+		l.Debug("connecting to scheduled job collection")
+		scheduledJobCollection := client.Database("ipaas").Collection("scheduledJob")
+		scheduledJobRepo := mongoRepo.NewScheduledJobRepoer(scheduledJobCollection)
+		c.ScheduledJobRepo = scheduledJobRepo
+
+		l.Debug("connecting to job run collection")
+		jobRunCollection := client.Database("ipaas").Collection("jobRun")
+		jobRunRepo := mongoRepo.NewJobRunRepoer(jobRunCollection)
+		c.JobRunRepo = jobRunRepo
+
+		l.Debug("connecting to notification channel collection")
+		notificationChannelCollection := client.Database("ipaas").Collection("notificationChannel")
+		notificationChannelRepo := mongoRepo.NewNotificationChannelRepoer(notificationChannelCollection)
+		c.NotificationChannelRepo = notificationChannelRepo
 	default:
 		l.Fatalf("main - unknown database driver: %s", conf.Database.Driver)
 	}
@@ -100,6 +162,8 @@ func main() {
 	tempTokenStorage := mock.NewTemporaryTokenRepoer()
 	c.TempTokenRepo = tempTokenStorage
 
+	prometheus.MustRegister(metrics.NewApplicationsCollector(c.CountApplications))
+
 	e := echo.New()
 	httpHandler := httpserver.InitRouter(e, l, c, conf)
 
@@ -110,9 +174,26 @@ func main() {
 	rmq.Close()
 	l.Debugf("closing rmq connection")
 
-	containerEventHandler, err := events.NewContainerEventHandler(ctx, c, c.ServiceManager.GetEventsChan, l)
-	if err != nil {
-		l.Fatalf("main - events.NewContainerEventHandler - error creating container event handler: %s", err.Error())
+	containerEventHandler := events.NewContainerEventHandler(c, c.ServiceManager.NewInformerFactory, conf.ClusterWatch.Resync, conf.ClusterWatch.Workers, l)
+
+	applicationReconciler := reconciler.NewReconciler(c, conf.Reconciler.Interval, conf.Reconciler.DryRun, l)
+
+	var leaderElection *leader.LeaderElection
+	if conf.LeaderElection.Enabled {
+		identity := conf.LeaderElection.Identity
+		if identity == "" {
+			identity, err = os.Hostname()
+			if err != nil {
+				l.Fatalf("main - os.Hostname - error getting leader election identity: %s", err.Error())
+			}
+		}
+		le := conf.LeaderElection
+		leaderElection, err = leader.NewLeaderElection(func(callbacks leaderelection.LeaderCallbacks) (*leaderelection.LeaderElector, error) {
+			return c.ServiceManager.NewLeaderElector(le.Namespace, le.LeaseName, identity, le.LeaseDuration, le.RenewDeadline, le.RetryPeriod, callbacks)
+		}, l)
+		if err != nil {
+			l.Fatalf("main - leader.NewLeaderElection - error creating leader election: %s", err.Error())
+		}
 	}
 
 	interrupt := make(chan os.Signal, 1)
@@ -124,7 +205,19 @@ func main() {
 	RoutineMonitor := make(chan int, 100)
 	RoutineMonitor <- StartHTTPHandler
 	RoutineMonitor <- StartRMQHandler
-	RoutineMonitor <- StartContainerEventHandler
+	//the container event handler and the reconciler run only in the leader,
+	//they are stopped with the context of the leadership
+	leaderCtx := ctx
+	var leading chan context.Context
+	if leaderElection != nil {
+		leading = leaderElection.Leading
+		RoutineMonitor <- StartLeaderElection
+	} else {
+		RoutineMonitor <- StartContainerEventHandler
+		if conf.Reconciler.Enabled {
+			RoutineMonitor <- StartReconciler
+		}
+	}
 
 	for {
 		select {
@@ -144,11 +237,28 @@ func main() {
 				l.Info("main - http handler finished")
 			case <-containerEventHandler.Done:
 				l.Info("main - container event handler finished")
+			case <-applicationReconciler.Done:
+				l.Info("main - reconciler finished")
+			}
+			//the lease is released on cancel, waiting for it lets another replica take over immediately
+			if leaderElection != nil {
+				select {
+				case <-gracefulTimer:
+					l.Info("main - graceful shutdown timeout reached before releasing the lease, exiting with status 1")
+					os.Exit(1)
+				case <-leaderElection.Done:
+					l.Info("main - leader election finished")
+				}
 			}
 			//returns 0 because the shutdown was successful
 			os.Exit(0)
 		case err = <-rmq.Error:
 			l.Errorf("rabbitmq handler error: %v", err)
+		case leaderCtx = <-leading:
+			RoutineMonitor <- StartContainerEventHandler
+			if conf.Reconciler.Enabled {
+				RoutineMonitor <- StartReconciler
+			}
 		default:
 		}
 
@@ -161,7 +271,11 @@ func main() {
 			case StartHTTPHandler:
 				go httpserver.StartRouter(ctx, httpHandler, conf, StartHTTPHandler, RoutineMonitor)
 			case StartContainerEventHandler:
-				go events.StartContainerEventHandler(ctx, containerEventHandler, StartContainerEventHandler, RoutineMonitor)
+				go events.StartContainerEventHandler(leaderCtx, containerEventHandler, StartContainerEventHandler, RoutineMonitor)
+			case StartReconciler:
+				go reconciler.StartReconciler(leaderCtx, applicationReconciler, StartReconciler, RoutineMonitor)
+			case StartLeaderElection:
+				go leader.StartLeaderElection(ctx, leaderElection, StartLeaderElection, RoutineMonitor)
 			default:
 			}
 		default:
@@ -16,30 +16,50 @@ type (
 	}
 
 	Application struct {
-		ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
-		UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
-		Name          string             `bson:"name" json:"name"`
-		Kind          ApplicationKind    `bson:"kind" json:"kind"`
-		DnsName       string             `bson:"dnsName" json:"dnsName"`
-		State         ApplicationState   `bson:"state" json:"state"`
-		Owner         string             `bson:"owner" json:"owner"`
-		ListeningPort string             `bson:"listeningPort" json:"listeningPort"`
-		Description   string             `bson:"description,omitempty" json:"description,omitempty"`
-		GithubRepo    string             `bson:"githubRepo" json:"githubRepo"`
-		GithubBranch  string             `bson:"githubBranch" json:"githubBranch"`
-		BuiltCommit   string             `bson:"builtCommit" json:"builtCommit,omitempty"`
-		Visiblity     string             `bson:"visiblity" json:"visiblity"`
-		IsUpdatable   bool               `bson:"isUpdatable" json:"isUpdatable"`
-		Service       *Service           `bson:"service" json:"-"`
-		Envs          []KeyValue         `bson:"envs" json:"envs"`
-		BasedOn       string             `bson:"basedOn" json:"basedOn"` //id of the template the application is based on
-		BuildPlan     *BuildConfig       `bson:"buildPlan" json:"buildPlan"`
-		BuildOutput   string             `bson:"buildOutput" json:"buildOutput"`
-		RepoAnalisys  *RepoAnalisys      `bson:"repoAnalysis" json:"repoAnalysis"`
+		ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+		CreatedAt       time.Time            `bson:"createdAt" json:"createdAt"`
+		UpdatedAt       time.Time            `bson:"updatedAt" json:"updatedAt"`
+		Version         int64                `bson:"version" json:"version"` //incremented on every update, the updates of a stale version are rejected
+		Name            string               `bson:"name" json:"name"`
+		Kind            ApplicationKind      `bson:"kind" json:"kind"`
+		DnsName         string               `bson:"dnsName" json:"dnsName"`
+		State           ApplicationState     `bson:"state" json:"state"`
+		Owner           string               `bson:"owner" json:"owner"`
+		ListeningPort   string               `bson:"listeningPort" json:"listeningPort"`
+		Description     string               `bson:"description,omitempty" json:"description,omitempty"`
+		GithubRepo      string               `bson:"githubRepo" json:"githubRepo"`
+		GithubBranch    string               `bson:"githubBranch" json:"githubBranch"`
+		BuiltCommit     string               `bson:"builtCommit" json:"builtCommit,omitempty"`
+		Visiblity       string               `bson:"visiblity" json:"visiblity"`
+		IsUpdatable     bool                 `bson:"isUpdatable" json:"isUpdatable"`
+		Service         *Service             `bson:"service" json:"-"`
+		Envs            []KeyValue           `bson:"envs" json:"envs"`
+		Secrets         []SecretEnv          `bson:"secrets" json:"secrets"`
+		EnvGroups       []primitive.ObjectID `bson:"envGroups" json:"envGroups"`             //ids of the attached env groups, loaded before the application envs
+		Links           []ApplicationLink    `bson:"links" json:"links"`                     //storage applications used by this application
+		LinkedBy        []ApplicationLink    `bson:"linkedBy" json:"linkedBy"`               //applications using this storage application
+		Volumes         []*Volume            `bson:"userVolumes" json:"volumes"`             //persistent volumes added by the user, the pvc is nil until the application is deployed
+		BackupCron      string               `bson:"backupCron" json:"backupCron"`           //cron schedule of the backups, empty if disabled
+		BasedOn         string               `bson:"basedOn" json:"basedOn"`                 //id of the template the application is based on
+		TemplateVersion int                  `bson:"templateVersion" json:"templateVersion"` //version of the template the application is pinned to
+		StackID         primitive.ObjectID   `bson:"stackID,omitempty" json:"stackID"`       //stack the application is part of, it's deleted with it
+		TargetID        primitive.ObjectID   `bson:"targetID,omitempty" json:"targetID"`     //storage application managed by this managment application, it's deleted with it
+		BuildPlan       *BuildConfig         `bson:"buildPlan" json:"buildPlan"`
+		BuildOutput     string               `bson:"buildOutput" json:"buildOutput"`
+		RepoAnalisys    *RepoAnalisys        `bson:"repoAnalysis" json:"repoAnalysis"`
+		HealthChecks    *HealthChecks        `bson:"healthChecks" json:"healthChecks"`
+		Health          ApplicationHealth    `bson:"health" json:"health"`
+		LastRollout     *Rollout             `bson:"lastRollout" json:"lastRollout"`
+		CrashLoop       *CrashLoop           `bson:"crashLoop" json:"crashLoop"`
 		// Image          *Image             `bson:"image" json:"image,omitempty"`
 	}
 
+	ApplicationCount struct {
+		Kind  ApplicationKind  `bson:"kind" json:"kind"`
+		State ApplicationState `bson:"state" json:"state"`
+		Count int              `bson:"count" json:"count"`
+	}
+
 	// NewApplication struct {
 	// 	ID          primitive.ObjectID
 	// 	CreatedAt   time.Time
@@ -102,6 +122,7 @@ const (
 	ApplicationKindWeb       ApplicationKind = "web"
 	ApplicationKindStorage   ApplicationKind = "storage"
 	ApplicationKindManagment ApplicationKind = "managment"
+	ApplicationKindWorker    ApplicationKind = "worker" //built from git like web applications, without service, ingress and port
 
 	ApplicationStatePending    ApplicationState = "pending"
 	ApplicationStateBuilding   ApplicationState = "building"
@@ -111,6 +132,7 @@ const (
 	ApplicationStateDeleting   ApplicationState = "deleting"
 	ApplicationStateCrashed    ApplicationState = "crashed"
 	ApplicationStateRollingOut ApplicationState = "rollingOut"
+	ApplicationStateStopped    ApplicationState = "stopped" //scaled to 0 after too many crashes, until resumed
 
 	ApplicationVisiblityPublic  = "public"
 	ApplicationVisiblityPrivate = "private"
@@ -120,6 +142,11 @@ func (s ApplicationKind) String() string {
 	return string(s)
 }
 
+// web and worker applications are built from a git repository
+func (s ApplicationKind) IsBuiltFromRepo() bool {
+	return s == ApplicationKindWeb || s == ApplicationKindWorker
+}
+
 func (a ApplicationState) String() string {
 	return string(a)
 }
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	AuditAction string
+
+	// AuditEvent keeps track of sensitive operations done by users,
+	// it must never contain secret values
+	AuditEvent struct {
+		ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+		UserCode      string             `bson:"userCode" json:"userCode"`
+		ApplicationID primitive.ObjectID `bson:"applicationID,omitempty" json:"applicationID,omitempty"`
+		EnvGroupID    primitive.ObjectID `bson:"envGroupID,omitempty" json:"envGroupID,omitempty"`
+		Action        AuditAction        `bson:"action" json:"action"`
+		Target        string             `bson:"target" json:"target"`
+		Details       string             `bson:"details,omitempty" json:"details,omitempty"`
+	}
+)
+
+const (
+	AuditActionSecretCreated AuditAction = "secretCreated"
+	AuditActionSecretUpdated AuditAction = "secretUpdated"
+	AuditActionSecretDeleted AuditAction = "secretDeleted"
+
+	AuditActionExecSessionStarted AuditAction = "execSessionStarted"
+	AuditActionExecSessionEnded   AuditAction = "execSessionEnded"
+	AuditActionTunnelOpened       AuditAction = "tunnelOpened"
+	AuditActionTunnelClosed       AuditAction = "tunnelClosed"
+
+	AuditActionApplicationResumed AuditAction = "applicationResumed"
+)
+
+func (a AuditAction) String() string {
+	return string(a)
+}
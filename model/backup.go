@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	BackupKind string
+
+	// Backup is a dump of a storage application uploaded to the backups bucket,
+	// it can only be restored in applications based on the same template
+	Backup struct {
+		ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+		FinishedAt      time.Time          `bson:"finishedAt" json:"finishedAt"`
+		ApplicationID   primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		ApplicationName string             `bson:"applicationName" json:"applicationName"`
+		Owner           string             `bson:"owner" json:"-"`
+		TemplateCode    string             `bson:"templateCode" json:"templateCode"`
+		Kind            BackupKind         `bson:"kind" json:"kind"`
+		Status          JobStatus          `bson:"status" json:"status"`
+		JobName         string             `bson:"jobName" json:"-"`
+		ObjectKey       string             `bson:"objectKey" json:"objectKey"`
+		Restores        []BackupRestore    `bson:"restores" json:"restores"`
+	}
+
+	BackupRestore struct {
+		ApplicationID primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		JobName       string             `bson:"jobName" json:"-"`
+		Status        JobStatus          `bson:"status" json:"status"`
+		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	}
+
+	// the commands are run with sh, they can use the envs of the application plus
+	// BACKUP_HOST, BACKUP_PORT and BACKUP_FILE (the path of the dump)
+	TemplateBackup struct {
+		Image          string `bson:"image" json:"-"` //image with the client tools, the template one if empty
+		BackupCommand  string `bson:"backupCommand" json:"-"`
+		RestoreCommand string `bson:"restoreCommand" json:"-"`
+		Extension      string `bson:"extension" json:"extension"`
+	}
+)
+
+const (
+	BackupKindScheduled BackupKind = "scheduled"
+	BackupKindManual    BackupKind = "manual"
+
+	BackupOfLabel = "backupOf"
+)
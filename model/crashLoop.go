@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type (
+	// CrashLoop keeps track of the crashes of the current container of an
+	// application, once the restarts reach the configured threshold the
+	// deployment is scaled to 0 and the application is stopped until resumed
+	CrashLoop struct {
+		RestartCount int32     `bson:"restartCount" json:"restartCount"`
+		LastExitCode int32     `bson:"lastExitCode" json:"lastExitCode"`
+		LastReason   string    `bson:"lastReason" json:"lastReason"`
+		LastCrashAt  time.Time `bson:"lastCrashAt" json:"lastCrashAt"`
+		Logs         string    `bson:"logs" json:"logs"` //last lines of the logs of the crashed container
+		Stopped      bool      `bson:"stopped" json:"stopped"`
+		StoppedAt    time.Time `bson:"stoppedAt,omitempty" json:"stoppedAt,omitempty"`
+	}
+)
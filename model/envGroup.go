@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// EnvGroup is a named set of envs and secrets of a user that can be attached
+	// to many applications, the group has its own config map and secret in the
+	// user namespace that are loaded by every attached application
+	EnvGroup struct {
+		ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+		UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+		Name          string             `bson:"name" json:"name"`
+		Owner         string             `bson:"owner" json:"owner"`
+		Envs          []KeyValue         `bson:"envs" json:"envs"`
+		Secrets       []SecretEnv        `bson:"secrets" json:"secrets"`
+		ConfigMapName string             `bson:"configMapName" json:"-"`
+		SecretName    string             `bson:"secretName" json:"-"`
+	}
+)
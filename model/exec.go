@@ -0,0 +1,21 @@
+package model
+
+import "io"
+
+type (
+	TerminalSize struct {
+		Width  uint16 `json:"cols"`
+		Height uint16 `json:"rows"`
+	}
+
+	// ExecStreams are attached to a command run in a container, with Tty the
+	// stderr is merged in the stdout. The terminal is resized with the sizes
+	// sent on Resize, a nil channel keeps the default size
+	ExecStreams struct {
+		Stdin  io.Reader
+		Stdout io.Writer
+		Stderr io.Writer
+		Tty    bool
+		Resize <-chan TerminalSize
+	}
+)
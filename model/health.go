@@ -0,0 +1,43 @@
+package model
+
+type (
+	ProbeKind         string
+	ApplicationHealth string
+
+	// Probe describes a single k8s probe, only the fields of the selected kind are used
+	Probe struct {
+		Kind                ProbeKind `bson:"kind" json:"kind"`
+		Path                string    `bson:"path,omitempty" json:"path,omitempty"`       //http only
+		Port                string    `bson:"port,omitempty" json:"port,omitempty"`       //http and tcp, defaults to the listening port
+		Command             []string  `bson:"command,omitempty" json:"command,omitempty"` //exec only
+		InitialDelaySeconds int32     `bson:"initialDelaySeconds" json:"initialDelaySeconds"`
+		PeriodSeconds       int32     `bson:"periodSeconds" json:"periodSeconds"`
+		TimeoutSeconds      int32     `bson:"timeoutSeconds" json:"timeoutSeconds"`
+		FailureThreshold    int32     `bson:"failureThreshold" json:"failureThreshold"`
+	}
+
+	// HealthChecks groups the probes of an application, a nil probe is not applied
+	HealthChecks struct {
+		Liveness  *Probe `bson:"liveness" json:"liveness"`
+		Readiness *Probe `bson:"readiness" json:"readiness"`
+		Startup   *Probe `bson:"startup" json:"startup"`
+	}
+)
+
+const (
+	ProbeKindHTTP ProbeKind = "http"
+	ProbeKindTCP  ProbeKind = "tcp"
+	ProbeKindExec ProbeKind = "exec"
+
+	ApplicationHealthUnknown  ApplicationHealth = "unknown"
+	ApplicationHealthReady    ApplicationHealth = "ready"
+	ApplicationHealthNotReady ApplicationHealth = "notReady"
+)
+
+func (p ProbeKind) String() string {
+	return string(p)
+}
+
+func (h ApplicationHealth) String() string {
+	return string(h)
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+type (
+	JobStatus string
+
+	// JobContainer is a container of a job, the command is run as is (no shell)
+	JobContainer struct {
+		Name       string
+		Image      string
+		Command    []string
+		Envs       []KeyValue
+		EnvSources []EnvSource //loaded in order before the envs
+	}
+
+	// JobSpec describes the pods of a job, the init containers are run in order before
+	// the containers. If SharedDir is set an empty dir is mounted there in all the containers,
+	// the volumes are mounted only in the containers
+	JobSpec struct {
+		InitContainers []JobContainer
+		Containers     []JobContainer
+		SharedDir      string
+		Volumes        []*Volume
+		BackoffLimit   int32
+		//seconds after which a finished job is deleted, 0 keeps it
+		TTLSecondsAfterFinished int32
+	}
+
+	Job struct {
+		BaseResource
+		Status      JobStatus `bson:"status" json:"status"`
+		CronJobName string    `bson:"cronJobName" json:"cronJobName,omitempty"` //set if the job was created by a cron job
+		StartedAt   time.Time `bson:"startedAt" json:"startedAt"`
+		FinishedAt  time.Time `bson:"finishedAt" json:"finishedAt"`
+	}
+
+	CronJob struct {
+		BaseResource
+		Schedule string `bson:"schedule" json:"schedule"`
+	}
+)
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+func (s JobStatus) IsFinished() bool {
+	return s == JobStatusSucceeded || s == JobStatusFailed
+}
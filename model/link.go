@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// ApplicationLink connects a web application to a storage application, the
+	// connection url is generated in a secret loaded by the web application.
+	// The link is stored in both applications, ApplicationID is the other one
+	ApplicationLink struct {
+		ApplicationID   primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		ApplicationName string             `bson:"applicationName" json:"applicationName"`
+		EnvName         string             `bson:"envName" json:"envName"`
+		SecretName      string             `bson:"secretName" json:"-"`
+		CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	}
+
+	// TemplateLink describes how to build the connection url of the applications
+	// based on a template, the envs are the keys of the template envs used to fill it
+	TemplateLink struct {
+		Scheme      string `bson:"scheme" json:"scheme"`
+		EnvName     string `bson:"envName" json:"envName"` //default env generated in the linked application (ex: DATABASE_URL)
+		UserEnv     string `bson:"userEnv" json:"-"`
+		DefaultUser string `bson:"defaultUser" json:"-"` //used when the user env is not set (ex: root)
+		PasswordEnv string `bson:"passwordEnv" json:"-"`
+		DatabaseEnv string `bson:"databaseEnv" json:"-"`
+	}
+)
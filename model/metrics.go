@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type (
+	// identifies the kubernetes resources of an application for the metrics
+	// providers, ServiceName is empty for applications without a service
+	MetricsTarget struct {
+		Namespace      string
+		DeploymentName string
+		ServiceName    string
+	}
+
+	MetricPoint struct {
+		Timestamp time.Time `json:"timestamp"`
+		Value     float64   `json:"value"`
+	}
+
+	// time series of an application, the series not supported by the metrics
+	// provider are empty
+	ApplicationMetrics struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+		//seconds between two points
+		Step            int64         `json:"step"`
+		CPU             []MetricPoint `json:"cpu"`             //cores
+		Memory          []MetricPoint `json:"memory"`          //bytes
+		Restarts        []MetricPoint `json:"restarts"`        //total restarts of the containers
+		NetworkReceive  []MetricPoint `json:"networkReceive"`  //bytes per second
+		NetworkTransmit []MetricPoint `json:"networkTransmit"` //bytes per second
+		Requests        []MetricPoint `json:"requests"`        //requests per second
+	}
+)
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	NotificationChannelKind string
+	NotificationEvent       string
+
+	// NotificationChannel sends the selected events of an application to the
+	// target, the target is encrypted since webhook urls are credentials
+	NotificationChannel struct {
+		ID            primitive.ObjectID      `bson:"_id,omitempty" json:"id"`
+		CreatedAt     time.Time               `bson:"createdAt" json:"createdAt"`
+		UpdatedAt     time.Time               `bson:"updatedAt" json:"updatedAt"`
+		ApplicationID primitive.ObjectID      `bson:"applicationID" json:"applicationID"`
+		Owner         string                  `bson:"owner" json:"-"`
+		Kind          NotificationChannelKind `bson:"kind" json:"kind"`
+		Target        string                  `bson:"target" json:"-"`
+		MaskedTarget  string                  `bson:"maskedTarget" json:"target"`
+		Events        []NotificationEvent     `bson:"events" json:"events"`
+		Enabled       bool                    `bson:"enabled" json:"enabled"`
+	}
+
+	// Notification is the content sent on the channels
+	Notification struct {
+		Event           NotificationEvent  `json:"event"`
+		ApplicationID   primitive.ObjectID `json:"applicationID"`
+		ApplicationName string             `json:"applicationName"`
+		Message         string             `json:"message"`
+		//notifications of the same event not sent because of the rate limit
+		Suppressed int       `json:"suppressed"`
+		CreatedAt  time.Time `json:"createdAt"`
+	}
+)
+
+const (
+	NotificationChannelKindEmail   NotificationChannelKind = "email"
+	NotificationChannelKindWebhook NotificationChannelKind = "webhook" //the notification is posted as json
+	NotificationChannelKindSlack   NotificationChannelKind = "slack"
+	NotificationChannelKindDiscord NotificationChannelKind = "discord"
+)
+
+const (
+	NotificationEventApplicationCrashed NotificationEvent = "applicationCrashed"
+	NotificationEventBuildFailed        NotificationEvent = "buildFailed"
+	NotificationEventRolloutFailed      NotificationEvent = "rolloutFailed"
+	NotificationEventTest               NotificationEvent = "test" //sent on request to check the channel
+)
+
+func (k NotificationChannelKind) String() string {
+	return string(k)
+}
+
+func (e NotificationEvent) String() string {
+	return string(e)
+}
+
+func (n NotificationChannel) HasEvent(event NotificationEvent) bool {
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package model
+
+type (
+	// QuotaResources holds the amount of each resource tracked by the user quota,
+	// cpu, memory and storage are k8s quantities (ex: 500m, 2Gi)
+	QuotaResources struct {
+		CPU          string `bson:"cpu" json:"cpu"`
+		Memory       string `bson:"memory" json:"memory"`
+		Storage      string `bson:"storage" json:"storage"`
+		Pods         int64  `bson:"pods" json:"pods"`
+		Applications int64  `bson:"applications" json:"applications"`
+	}
+
+	ResourceQuota struct {
+		BaseResource
+		Hard QuotaResources `bson:"hard" json:"hard"`
+		Used QuotaResources `bson:"used" json:"used"`
+	}
+
+	LimitRange struct {
+		BaseResource
+		DefaultCPU    string `bson:"defaultCpu" json:"defaultCpu"`
+		DefaultMemory string `bson:"defaultMemory" json:"defaultMemory"`
+		MaxCPU        string `bson:"maxCpu" json:"maxCpu"`
+		MaxMemory     string `bson:"maxMemory" json:"maxMemory"`
+	}
+
+	QuotaUsage struct {
+		Tier string         `json:"tier"`
+		Hard QuotaResources `json:"hard"`
+		Used QuotaResources `json:"used"`
+	}
+)
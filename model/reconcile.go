@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+type (
+	ManagedResourceKind string
+	ReconcileActionKind string
+
+	// ManagedResource is a cluster resource created for an application, found by
+	// the ipaasManaged and appID labels
+	ManagedResource struct {
+		Kind          ManagedResourceKind `json:"kind"`
+		Name          string              `json:"name"`
+		Namespace     string              `json:"namespace"`
+		ApplicationID string              `json:"applicationID"`
+		Terminating   bool                `json:"terminating"`
+		CreatedAt     time.Time           `json:"createdAt"`
+	}
+
+	// ReconcileAction is a divergence between the database and the cluster found
+	// by the reconciler, it's applied only if the reconciliation is not a dry run
+	ReconcileAction struct {
+		Kind          ReconcileActionKind `json:"kind"`
+		ApplicationID string              `json:"applicationID"`
+		Resource      *ManagedResource    `json:"resource,omitempty"`
+		Reason        string              `json:"reason"`
+		Applied       bool                `json:"applied"`
+		Error         string              `json:"error,omitempty"`
+	}
+
+	ReconcileReport struct {
+		DryRun       bool               `json:"dryRun"`
+		StartedAt    time.Time          `json:"startedAt"`
+		FinishedAt   time.Time          `json:"finishedAt"`
+		Applications int                `json:"applications"`
+		Resources    int                `json:"resources"`
+		Actions      []*ReconcileAction `json:"actions"`
+	}
+)
+
+const (
+	ManagedResourceKindDeployment   ManagedResourceKind = "deployment"
+	ManagedResourceKindService      ManagedResourceKind = "service"
+	ManagedResourceKindIngressRoute ManagedResourceKind = "ingressRoute"
+	ManagedResourceKindConfigMap    ManagedResourceKind = "configMap"
+	ManagedResourceKindSecret       ManagedResourceKind = "secret"
+	ManagedResourceKindPVC          ManagedResourceKind = "persistentVolumeClaim"
+
+	ReconcileActionDeleteOrphan      ReconcileActionKind = "deleteOrphan"      //resource of an application not in the database
+	ReconcileActionRecreateResource  ReconcileActionKind = "recreateResource"  //resource of the application missing in the cluster
+	ReconcileActionMarkFailed        ReconcileActionKind = "markFailed"        //application that can't be repaired without a new deploy
+	ReconcileActionResumeDeletion    ReconcileActionKind = "resumeDeletion"    //application stuck in deleting with resources left
+	ReconcileActionRemoveApplication ReconcileActionKind = "removeApplication" //application stuck in deleting without resources left
+	ReconcileActionResumeRollout     ReconcileActionKind = "resumeRollout"     //application stuck in rolling out after its watcher was lost
+	ReconcileActionSecureEnvs        ReconcileActionKind = "secureEnvs"        //plain envs the template of the application marks as secrets
+)
+
+func (k ManagedResourceKind) String() string {
+	return string(k)
+}
+
+func (k ReconcileActionKind) String() string {
+	return string(k)
+}
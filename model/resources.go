@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type (
 	BaseResource struct {
 		Name      string     `bson:"name" json:"name"`
@@ -21,14 +23,22 @@ type (
 		MemoryLimits   string     `bson:"memoryLimits" json:"memoryLimits"`
 		Port           int32      `bson:"port" json:"port"`
 		CurrentPodName string     `bson:"currentPodName" json:"currentPodName"`
-		Volume         *Volume    `bson:"volumes" json:"volumes"`
+		Volume         *Volume    `bson:"volumes" json:"volumes"` //data volume of the storage templates, the user volumes are in Application.Volumes
 		ConfigMap      *ConfigMap `bson:"configMap" json:"configMap"`
+		Secret         *Secret    `bson:"secret" json:"secret"`
 	}
 
 	Volume struct {
 		Name                  string
 		PersistantVolumeClaim *PersistentVolumeClaim `bson:"pvc" json:"pvc"`
 		MountPath             string                 `bson:"mountPath" json:"mountPath"`
+		Size                  int64                  `bson:"size" json:"size"` //requested size in Gi, the pvc is created with this size
+	}
+
+	// cpu and memory are k8s quantities (ex: 500m, 512Mi), empty values use the defaults
+	ContainerResources struct {
+		CPU    string `bson:"cpu" json:"cpu"`
+		Memory string `bson:"memory" json:"memory"`
 	}
 
 	PersistentVolumeClaim struct {
@@ -43,6 +53,20 @@ type (
 		Data []KeyValue `bson:"data" json:"data"`
 	}
 
+	EnvSourceKind string
+
+	// EnvSource is a config map or a secret the container envs are loaded from
+	EnvSource struct {
+		Kind EnvSourceKind `bson:"kind" json:"kind"`
+		Name string        `bson:"name" json:"name"`
+	}
+
+	// only the keys of the secret are kept, the values are never read back from the cluster
+	Secret struct {
+		BaseResource
+		Keys []string `bson:"keys" json:"keys"`
+	}
+
 	Service struct {
 		BaseResource
 		Port         int32         `bson:"port" json:"port"`
@@ -58,6 +82,15 @@ type (
 		// Service     *Service `bson:"service" json:"service"`
 	}
 
+	Pod struct {
+		BaseResource
+		Phase        string    `bson:"phase" json:"phase"`
+		Ready        bool      `bson:"ready" json:"ready"`
+		Terminating  bool      `bson:"terminating" json:"terminating"`
+		RestartCount int32     `bson:"restartCount" json:"restartCount"`
+		CreatedAt    time.Time `bson:"createdAt" json:"createdAt"`
+	}
+
 	ContainerStatus string
 )
 
@@ -65,6 +98,11 @@ const (
 	ContainerCreatedStatus ContainerStatus = "created"
 )
 
+const (
+	EnvSourceKindConfigMap EnvSourceKind = "configMap"
+	EnvSourceKindSecret    EnvSourceKind = "secret"
+)
+
 const (
 	EnvironmentLabel  = "environment"
 	OwnerLabel        = "ownedBy"
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type (
+	RolloutStatus string
+
+	// Rollout keeps track of the last image update of an application, the previous
+	// image and commit are used to revert the deployment if the new version fails
+	Rollout struct {
+		Image          string        `bson:"image" json:"image"`
+		PreviousImage  string        `bson:"previousImage" json:"previousImage"`
+		Commit         string        `bson:"commit" json:"commit"`
+		PreviousCommit string        `bson:"previousCommit" json:"previousCommit"`
+		Status         RolloutStatus `bson:"status" json:"status"`
+		Reason         string        `bson:"reason,omitempty" json:"reason,omitempty"`
+		StartedAt      time.Time     `bson:"startedAt" json:"startedAt"`
+		FinishedAt     time.Time     `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	}
+)
+
+const (
+	RolloutStatusProgressing RolloutStatus = "progressing"
+	RolloutStatusSucceeded   RolloutStatus = "succeeded"
+	RolloutStatusFailed      RolloutStatus = "failed"
+	RolloutStatusRolledBack  RolloutStatus = "rolledBack"
+)
+
+func (r RolloutStatus) String() string {
+	return string(r)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	JobRunKind string
+
+	// ScheduledJob runs the command (with sh) in the image of the application on
+	// the schedule (cron format), with the same envs and volumes of the application
+	ScheduledJob struct {
+		ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+		UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+		ApplicationID primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		Owner         string             `bson:"owner" json:"-"`
+		Name          string             `bson:"name" json:"name"`
+		Schedule      string             `bson:"schedule" json:"schedule"`
+		Command       string             `bson:"command" json:"command"`
+		CronJobName   string             `bson:"cronJobName" json:"-"`
+	}
+
+	// JobRun is an execution of a scheduled job or of a one-off task, the logs are
+	// stored when the run finishes
+	JobRun struct {
+		ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+		FinishedAt     time.Time          `bson:"finishedAt" json:"finishedAt"`
+		ApplicationID  primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		Owner          string             `bson:"owner" json:"-"`
+		Kind           JobRunKind         `bson:"kind" json:"kind"`
+		ScheduledJobID primitive.ObjectID `bson:"scheduledJobID,omitempty" json:"scheduledJobID,omitempty"` //not set for tasks
+		Command        string             `bson:"command" json:"command"`
+		Status         JobStatus          `bson:"status" json:"status"`
+		JobName        string             `bson:"jobName" json:"-"`
+		Logs           string             `bson:"logs" json:"-"`
+	}
+)
+
+const (
+	JobRunKindScheduled JobRunKind = "scheduled"
+	JobRunKindTask      JobRunKind = "task"
+
+	// set on the jobs and cron jobs of the application, the value is the application id
+	JobOfLabel = "jobOf"
+	// set on the cron job of a scheduled job and inherited by its jobs, the value is the scheduled job id
+	ScheduledJobLabel = "scheduledJob"
+
+	// name of the container running the command
+	JobRunContainerName = "run"
+)
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const SecretMask = "********"
+
+type (
+	// SecretEnv is an env variable that is stored as a kubernetes secret,
+	// the value is encrypted in the database and never returned by the api
+	SecretEnv struct {
+		Key       string    `bson:"key" json:"key"`
+		Value     string    `bson:"value" json:"-"`
+		UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+	}
+)
+
+// the value is always masked, the plain value is only known by the user who set it
+func (s SecretEnv) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key       string    `json:"key"`
+		Value     string    `json:"value"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}{
+		Key:       s.Key,
+		Value:     SecretMask,
+		UpdatedAt: s.UpdatedAt,
+	})
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// StackTemplate describes a group of template applications deployed and
+	// deleted together (ex: a database and its dashboard)
+	StackTemplate struct {
+		Code          string           `bson:"code" json:"code"`
+		Available     bool             `bson:"available" json:"available"`
+		Name          string           `bson:"name" json:"name"`
+		Description   string           `bson:"description" json:"description"`
+		Documentation string           `bson:"documentation" json:"documentation"`
+		Components    []StackComponent `bson:"components" json:"components"` //deployed in order
+		SharedEnvs    []KeyValue       `bson:"sharedEnvs" json:"sharedEnvs"` //set in every component, the component envs take precedence
+		Links         []StackLink      `bson:"links" json:"links"`
+	}
+
+	// StackComponent is an application of the stack, the envs can reference the
+	// components deployed before it with {{component.host}}, {{component.port}}
+	// and {{component.env.KEY}}
+	StackComponent struct {
+		Name         string     `bson:"name" json:"name"` //the application is named <stack name>-<component name>
+		TemplateCode string     `bson:"templateCode" json:"templateCode"`
+		Ingress      bool       `bson:"ingress" json:"ingress"` //only managment components can be exposed
+		Envs         []KeyValue `bson:"envs" json:"envs"`
+	}
+
+	// StackLink links a component to a storage component of the same stack
+	StackLink struct {
+		From    string `bson:"from" json:"from"`
+		To      string `bson:"to" json:"to"`
+		EnvName string `bson:"envName" json:"envName"` //if empty the default one of the storage template is used
+	}
+
+	// Stack is the parent record of the applications created from a stack template
+	Stack struct {
+		ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+		UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+		Name       string             `bson:"name" json:"name"`
+		Owner      string             `bson:"owner" json:"owner"`
+		BasedOn    string             `bson:"basedOn" json:"basedOn"` //code of the stack template
+		State      ApplicationState   `bson:"state" json:"state"`
+		Components []StackApplication `bson:"components" json:"components"`
+	}
+
+	StackApplication struct {
+		Component       string             `bson:"component" json:"component"`
+		ApplicationID   primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		ApplicationName string             `bson:"applicationName" json:"applicationName"`
+	}
+)
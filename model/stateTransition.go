@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// StateTransition is a change of the state of an application, the transitions
+	// are kept in the history of the application to show its timeline
+	StateTransition struct {
+		ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+		ApplicationID primitive.ObjectID `bson:"applicationID" json:"applicationID"`
+		From          ApplicationState   `bson:"from" json:"from"` //empty for the creation of the application
+		To            ApplicationState   `bson:"to" json:"to"`
+		Reason        string             `bson:"reason" json:"reason"`
+		Actor         string             `bson:"actor" json:"actor"` //code of the user or name of the component that changed the state
+	}
+)
+
+const (
+	// components changing the state of the applications on their own
+	StateTransitionActorImageBuilder    = "imageBuilder"
+	StateTransitionActorContainerEvents = "containerEvents"
+	StateTransitionActorRolloutWatcher  = "rolloutWatcher"
+	StateTransitionActorCrashLoopPolicy = "crashLoopPolicy"
+	StateTransitionActorReconciler      = "reconciler"
+	StateTransitionActorSystem          = "system"
+)
+
+// states every state can move to, an application is created as pending when
+// built from a repository or starting when deployed from a template. Deleting
+// is final, the application is removed once its resources are deleted
+var applicationStateTransitions = map[ApplicationState][]ApplicationState{
+	"": {
+		ApplicationStatePending,
+		ApplicationStateStarting,
+	},
+	ApplicationStatePending: {
+		ApplicationStateBuilding,
+		ApplicationStateStarting,
+		ApplicationStateFailed,
+		ApplicationStateDeleting,
+	},
+	ApplicationStateBuilding: {
+		ApplicationStateStarting,
+		ApplicationStateRollingOut,
+		ApplicationStateFailed,
+	},
+	//the resources are being created, it can't be deleted until they are all saved
+	ApplicationStateStarting: {
+		ApplicationStateRunning,
+		ApplicationStateCrashed,
+		ApplicationStateStopped,
+		ApplicationStateFailed,
+	},
+	ApplicationStateRunning: {
+		ApplicationStateCrashed,
+		ApplicationStateStopped,
+		ApplicationStateRollingOut,
+		ApplicationStateFailed,
+		ApplicationStateDeleting,
+	},
+	ApplicationStateFailed: {
+		ApplicationStateRunning,
+		ApplicationStateCrashed,
+		ApplicationStateStopped,
+		ApplicationStateRollingOut,
+		ApplicationStateDeleting,
+	},
+	ApplicationStateCrashed: {
+		ApplicationStateRunning,
+		ApplicationStateStopped,
+		ApplicationStateRollingOut,
+		ApplicationStateFailed,
+		ApplicationStateDeleting,
+	},
+	//the first build of an application without deployment is started again
+	ApplicationStateRollingOut: {
+		ApplicationStateStarting,
+		ApplicationStateRunning,
+		ApplicationStateCrashed,
+		ApplicationStateFailed,
+		ApplicationStateDeleting,
+	},
+	ApplicationStateStopped: {
+		ApplicationStateStarting,
+		ApplicationStateFailed,
+		ApplicationStateDeleting,
+	},
+	ApplicationStateDeleting: {},
+}
+
+// staying in the same state is always allowed and it's not a transition
+func (a ApplicationState) CanTransitionTo(to ApplicationState) bool {
+	if a == to {
+		return true
+	}
+	for _, allowed := range applicationStateTransitions[a] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -1,20 +1,42 @@
 package model
 
+import "time"
+
+// Template is a version of an application template, every version is stored as
+// a separate document with the same code. Applications are pinned to the version
+// they were created from and can be upgraded to newer ones
 type Template struct {
-	Code            string          `bson:"code" json:"code"`
-	Available       bool            `bson:"available" json:"available"`
-	Name            string          `bson:"name" json:"name"`
-	ImageName       string          `bson:"imageName" json:"-"`
-	ImageID         string          `bson:"imageID" json:"-"`
-	ListeningPort   string          `bson:"listeningPort" json:"-"`
-	RequiredEnvs    []KeyValue      `bson:"requiredEnvs" json:"requiredEnvs"`
-	OptionalEnvs    []KeyValue      `bson:"optionalEnvs" json:"optionalEnvs"`
-	DefaultEnvs     []KeyValue      `bson:"defaultEnvs" json:"-"`
-	IsUpdatabale    bool            `bson:"isUpdatabale" json:"-"`
-	Description     string          `bson:"description" json:"description"`
-	Documentation   string          `bson:"documentation" json:"documentation"`
-	PersistancePath string          `bson:"persistancePath" json:"-"`
-	Kind            ApplicationKind `bson:"kind" json:"kind"`
+	Code            string              `bson:"code" json:"code"`
+	Version         int                 `bson:"version" json:"version"`
+	CreatedAt       time.Time           `bson:"createdAt" json:"createdAt"`
+	Deprecated      bool                `bson:"deprecated" json:"deprecated"` //deprecated templates can't be used for new applications
+	Available       bool                `bson:"available" json:"available"`
+	Name            string              `bson:"name" json:"name"`
+	ImageName       string              `bson:"imageName" json:"-"`
+	ImageID         string              `bson:"imageID" json:"-"`
+	ListeningPort   string              `bson:"listeningPort" json:"-"`
+	RequiredEnvs    []KeyValue          `bson:"requiredEnvs" json:"requiredEnvs"`
+	OptionalEnvs    []KeyValue          `bson:"optionalEnvs" json:"optionalEnvs"`
+	DefaultEnvs     []KeyValue          `bson:"defaultEnvs" json:"-"`
+	SecretEnvs      []string            `bson:"secretEnvs" json:"secretEnvs"`       //keys of the envs stored as secrets (ex: passwords)
+	GeneratedEnvs   []GeneratedEnv      `bson:"generatedEnvs" json:"generatedEnvs"` //envs generated when not set by the user
+	IsUpdatabale    bool                `bson:"isUpdatabale" json:"-"`
+	Description     string              `bson:"description" json:"description"`
+	Documentation   string              `bson:"documentation" json:"documentation"`
+	PersistancePath string              `bson:"persistancePath" json:"-"`
+	Kind            ApplicationKind     `bson:"kind" json:"kind"`
+	Resources       *ContainerResources `bson:"resources" json:"-"`             //limits of the container, the default ones if nil
+	Link            *TemplateLink       `bson:"link" json:"link,omitempty"`     //only for templates that can be linked to web applications
+	Backup          *TemplateBackup     `bson:"backup" json:"backup,omitempty"` //only for storage templates that support backups
+	Target          *TemplateTarget     `bson:"target" json:"target,omitempty"` //only for managment templates bound to a storage application
+}
+
+// TemplateTarget describes the storage applications a managment template can be
+// bound to, the envs are filled with {{target.host}}, {{target.port}} and
+// {{target.env.KEY}} of the bound application
+type TemplateTarget struct {
+	Templates []string   `bson:"templates" json:"templates"` //codes of the compatible storage templates
+	Envs      []KeyValue `bson:"envs" json:"-"`
 }
 
 /*
@@ -23,3 +45,20 @@ keyvalue{
 	value: description of the env var
 }
 */
+
+type GeneratedEnvKind string
+
+const (
+	GeneratedEnvKindPassword GeneratedEnvKind = "password"
+	GeneratedEnvKindUsername GeneratedEnvKind = "username"
+	GeneratedEnvKindDatabase GeneratedEnvKind = "database"
+)
+
+// GeneratedEnv is a rule used to create the value of a template env, generated
+// passwords are always stored as secrets
+type GeneratedEnv struct {
+	Key    string           `bson:"key" json:"key"`
+	Kind   GeneratedEnvKind `bson:"kind" json:"kind"`
+	Length int              `bson:"length" json:"-"` //random characters, 0 uses the default of the kind
+	Prefix string           `bson:"prefix" json:"-"` //ignored for passwords (ex: user_)
+}
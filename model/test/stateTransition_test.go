@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	states := []model.ApplicationState{
+		model.ApplicationStatePending,
+		model.ApplicationStateBuilding,
+		model.ApplicationStateStarting,
+		model.ApplicationStateRunning,
+		model.ApplicationStateFailed,
+		model.ApplicationStateCrashed,
+		model.ApplicationStateRollingOut,
+		model.ApplicationStateStopped,
+		model.ApplicationStateDeleting,
+	}
+	t.Run("staying in the same state is allowed", func(t *testing.T) {
+		for _, state := range states {
+			if !state.CanTransitionTo(state) {
+				t.Errorf("%s should be allowed to stay %s", state, state)
+			}
+		}
+	})
+
+	tests := []struct {
+		from    model.ApplicationState
+		to      model.ApplicationState
+		allowed bool
+	}{
+		{"", model.ApplicationStatePending, true},
+		{"", model.ApplicationStateStarting, true},
+		{"", model.ApplicationStateRunning, false},
+		{model.ApplicationStatePending, model.ApplicationStateBuilding, true},
+		{model.ApplicationStatePending, model.ApplicationStateRunning, false},
+		{model.ApplicationStateBuilding, model.ApplicationStateRollingOut, true},
+		{model.ApplicationStateBuilding, model.ApplicationStateDeleting, false},
+		{model.ApplicationStateStarting, model.ApplicationStateRunning, true},
+		{model.ApplicationStateStarting, model.ApplicationStateDeleting, false},
+		{model.ApplicationStateRunning, model.ApplicationStateRollingOut, true},
+		{model.ApplicationStateRunning, model.ApplicationStatePending, false},
+		{model.ApplicationStateRollingOut, model.ApplicationStateRunning, true},
+		{model.ApplicationStateRollingOut, model.ApplicationStateStopped, false},
+		{model.ApplicationStateCrashed, model.ApplicationStateStopped, true},
+		{model.ApplicationStateStopped, model.ApplicationStateStarting, true},
+		{model.ApplicationStateStopped, model.ApplicationStateRunning, false},
+		{model.ApplicationStateFailed, model.ApplicationStateDeleting, true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("from %q to %q", test.from, test.to), func(t *testing.T) {
+			if got := test.from.CanTransitionTo(test.to); got != test.allowed {
+				t.Errorf("expected allowed=%v, got %v", test.allowed, got)
+			}
+		})
+	}
+
+	t.Run("deleting is final", func(t *testing.T) {
+		for _, state := range states {
+			if state != model.ApplicationStateDeleting && model.ApplicationStateDeleting.CanTransitionTo(state) {
+				t.Errorf("moving from deleting to %s should not be allowed", state)
+			}
+		}
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 const (
 	RoleUser    Role = "user"
+	RoleAdmin   Role = "admin"
 	RoleTesting Role = "testing" //used only for unit testing
 )
 
@@ -20,6 +21,7 @@ type (
 		Code         string             `bson:"code" json:"code"`
 		Namespace    string             `bson:"namespace" json:"namespace"`
 		Role         Role               `bson:"role" json:"role"` //defaults to "user"
+		QuotaTier    string             `bson:"quotaTier" json:"quotaTier"`
 		UserSettings *UserSettings      `bson:"userSettings" json:"userSettings"`
 		Info         *UserInfo          `bson:"userInfo" json:"userInfo"`
 	}
@@ -0,0 +1,16 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// VolumeUsage is a persistent volume of a user application with the space used,
+// sizes are in bytes and Used is -1 when the usage is not available
+type VolumeUsage struct {
+	ApplicationID    primitive.ObjectID `json:"applicationID"`
+	ApplicationName  string             `json:"applicationName"`
+	Name             string             `json:"name"`
+	MountPath        string             `json:"mountPath"`
+	StorageClassName string             `json:"storageClassName"`
+	Size             int64              `json:"size"`
+	Used             int64              `json:"used"`
+	Template         bool               `json:"template"` //the data volume of a storage template
+}
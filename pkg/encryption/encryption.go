@@ -0,0 +1,65 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// Encrypter encrypts values with AES-256-GCM, the key is derived from the
+// configured secret so any string can be used as encryption key
+type Encrypter struct {
+	aead cipher.AEAD
+}
+
+func NewEncrypter(secret string) (*Encrypter, error) {
+	if secret == "" {
+		return nil, errors.New("encryption key can not be empty")
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypter{
+		aead: aead,
+	}, nil
+}
+
+// returns the base64 encoded nonce followed by the ciphertext
+func (e *Encrypter) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := e.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *Encrypter) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	nonceSize := e.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", ErrInvalidCiphertext
+	}
+	plaintext, err := e.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
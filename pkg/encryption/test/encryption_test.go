@@ -0,0 +1,70 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/pkg/encryption"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypter, err := encryption.NewEncrypter("test-key")
+	if err != nil {
+		t.Fatalf("error creating encrypter: %v", err)
+	}
+
+	plain := "super secret password"
+	encrypted, err := encrypter.Encrypt(plain)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+
+	t.Run("encrypted value is decrypted to the plain one", func(t *testing.T) {
+		if encrypted == plain {
+			t.Errorf("encrypted value should differ from the plain one")
+		}
+		decrypted, err := encrypter.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("error decrypting: %v", err)
+		}
+		if decrypted != plain {
+			t.Errorf("expected %q, got %q", plain, decrypted)
+		}
+	})
+
+	t.Run("same value is encrypted differently every time", func(t *testing.T) {
+		again, err := encrypter.Encrypt(plain)
+		if err != nil {
+			t.Fatalf("error encrypting: %v", err)
+		}
+		if again == encrypted {
+			t.Errorf("the nonce should make every encryption different")
+		}
+	})
+
+	t.Run("different key can not decrypt", func(t *testing.T) {
+		other, err := encryption.NewEncrypter("other-key")
+		if err != nil {
+			t.Fatalf("error creating encrypter: %v", err)
+		}
+		if _, err := other.Decrypt(encrypted); err != encryption.ErrInvalidCiphertext {
+			t.Errorf("a different key should not decrypt the value, got %v", err)
+		}
+	})
+
+	t.Run("tampered and invalid values are rejected", func(t *testing.T) {
+		tampered := []byte(encrypted)
+		tampered[len(tampered)-2] ^= 1
+		invalid := []string{"not base64!", "c2hvcnQ=", string(tampered)}
+		for _, value := range invalid {
+			if _, err := encrypter.Decrypt(value); err != encryption.ErrInvalidCiphertext {
+				t.Errorf("decrypting %q should fail with ErrInvalidCiphertext, got %v", value, err)
+			}
+		}
+	})
+
+	t.Run("empty key is rejected", func(t *testing.T) {
+		if _, err := encryption.NewEncrypter(""); err == nil {
+			t.Errorf("an empty key should be rejected")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+// Package metrics defines the prometheus metrics of the platform, they are
+// registered in the default registry exposed on /metrics
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "ipaas"
+
+const (
+	BuildRequestSent   = "sent"
+	BuildRequestFailed = "failed"
+)
+
+var (
+	BuildRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "build_requests_total",
+		Help:      "Build requests sent to the image builder by result.",
+	}, []string{"result"})
+
+	BuildResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "build_responses_total",
+		Help:      "Build responses received from the image builder by status and fault.",
+	}, []string{"status", "fault"})
+
+	ApplicationStartupSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "application_startup_seconds",
+		Help:      "Time from the creation of an application in pending state to its first running state.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"kind"})
+
+	ResponseQueueMessages = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rabbitmq_response_queue_messages",
+		Help:      "Messages waiting to be consumed in the image builder response queue.",
+	})
+
+	ResponseQueueDelaySeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rabbitmq_response_delay_seconds",
+		Help:      "Time between the publishing of an image builder response and its consumption, only for timestamped messages.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+	})
+
+	K8sCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "k8s_call_duration_seconds",
+		Help:      "Duration of the calls to the orchestrated service manager by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	K8sCallErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "k8s_call_errors_total",
+		Help:      "Failed calls to the orchestrated service manager by method.",
+	}, []string{"method"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the api requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+func ObserveK8sCall(method string, start time.Time, err error) {
+	K8sCallDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		K8sCallErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// counts the applications by kind and state when scraped, the counts come
+// from the database so they are shared by every replica of the backend
+type applicationsCollector struct {
+	count   func(ctx context.Context) ([]*model.ApplicationCount, error)
+	desc    *prometheus.Desc
+	errors  prometheus.Counter
+	timeout time.Duration
+}
+
+func NewApplicationsCollector(count func(ctx context.Context) ([]*model.ApplicationCount, error)) prometheus.Collector {
+	return &applicationsCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "applications"),
+			"Applications by kind and state.",
+			[]string{"kind", "state"}, nil),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "applications_count_errors_total",
+			Help:      "Failed counts of the applications during scrapes.",
+		}),
+		timeout: 5 * time.Second,
+	}
+}
+
+func (a *applicationsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.desc
+	a.errors.Describe(ch)
+}
+
+func (a *applicationsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	counts, err := a.count(ctx)
+	if err != nil {
+		a.errors.Inc()
+	}
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(a.desc, prometheus.GaugeValue, float64(count.Count), count.Kind.String(), count.State.String())
+	}
+	a.errors.Collect(ch)
+}
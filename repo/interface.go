@@ -40,15 +40,105 @@ type (
 		FindByOwnerAndKindAndIsPublicTrue(ctx context.Context, owner string, kind model.ApplicationKind) ([]*model.Application, error)
 		FindByOwnerAndKindAndIsPublicFalse(ctx context.Context, owner string, kind model.ApplicationKind) ([]*model.Application, error)
 		FindByOwnerAndIsUpdatableTrue(ctx context.Context, owner string) ([]*model.Application, error)
+		FindByEnvGroupID(ctx context.Context, envGroupID primitive.ObjectID) ([]*model.Application, error)
+		FindByTargetID(ctx context.Context, targetID primitive.ObjectID) ([]*model.Application, error)
+		FindAll(ctx context.Context) ([]*model.Application, error)
+		//returns the number of applications for every kind and state having at least one
+		CountByKindAndState(ctx context.Context) ([]*model.ApplicationCount, error)
 		InsertOne(ctx context.Context, a *model.Application) (id interface{}, err error)
+		//replaces the application only if its version wasn't changed since it was read,
+		//returns ErrVersionConflict otherwise. The version of a is incremented
 		UpdateByID(ctx context.Context, a *model.Application, id primitive.ObjectID) (bool, error)
+		//the granular updates don't check the version, they change only their fields
+		UpdateState(ctx context.Context, id primitive.ObjectID, state model.ApplicationState) error
+		//returns ErrNotFound if the application has no deployment
+		UpdateCurrentPod(ctx context.Context, id primitive.ObjectID, podName string) error
+		UpdateService(ctx context.Context, id primitive.ObjectID, service *model.Service) error
 		DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
 	}
 
 	TemplateRepoer interface {
+		//returns the latest version of the template
 		FindByCode(ctx context.Context, code string) (*model.Template, error)
+		FindByCodeAndVersion(ctx context.Context, code string, version int) (*model.Template, error)
+		//returns all the versions of the template, newest first
+		FindVersionsByCode(ctx context.Context, code string) ([]*model.Template, error)
+		//returns the latest version of every template
 		FindAll(ctx context.Context) ([]*model.Template, error)
+		//returns the latest version of the templates, only if it's available and not deprecated
 		FindAllAvailable(ctx context.Context) ([]*model.Template, error)
+		InsertOne(ctx context.Context, t *model.Template) (id interface{}, err error)
+		UpdateByCodeAndVersion(ctx context.Context, t *model.Template, code string, version int) (bool, error)
+	}
+
+	StackTemplateRepoer interface {
+		FindByCode(ctx context.Context, code string) (*model.StackTemplate, error)
+		FindAllAvailable(ctx context.Context) ([]*model.StackTemplate, error)
+	}
+
+	StackRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.Stack, error)
+		FindByNameAndOwner(ctx context.Context, name, owner string) (*model.Stack, error)
+		FindByOwner(ctx context.Context, owner string) ([]*model.Stack, error)
+		InsertOne(ctx context.Context, s *model.Stack) (id interface{}, err error)
+		UpdateByID(ctx context.Context, s *model.Stack, id primitive.ObjectID) (bool, error)
+		DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+	}
+
+	EnvGroupRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.EnvGroup, error)
+		FindByNameAndOwner(ctx context.Context, name, owner string) (*model.EnvGroup, error)
+		FindByOwner(ctx context.Context, owner string) ([]*model.EnvGroup, error)
+		InsertOne(ctx context.Context, g *model.EnvGroup) (id interface{}, err error)
+		UpdateByID(ctx context.Context, g *model.EnvGroup, id primitive.ObjectID) (bool, error)
+		DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+	}
+
+	AuditRepoer interface {
+		InsertOne(ctx context.Context, e *model.AuditEvent) (id interface{}, err error)
+		//returns the events of the application sorted from the newest to the oldest
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.AuditEvent, error)
+	}
+
+	StateHistoryRepoer interface {
+		InsertOne(ctx context.Context, t *model.StateTransition) (id interface{}, err error)
+		//returns the transitions of the application sorted from the oldest to the newest
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.StateTransition, error)
+	}
+
+	BackupRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.Backup, error)
+		FindByJobName(ctx context.Context, jobName string) (*model.Backup, error)
+		//returns the backups of the application sorted from the newest to the oldest
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.Backup, error)
+		InsertOne(ctx context.Context, b *model.Backup) (id interface{}, err error)
+		UpdateByID(ctx context.Context, b *model.Backup, id primitive.ObjectID) (bool, error)
+	}
+
+	ScheduledJobRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.ScheduledJob, error)
+		FindByNameAndApplicationID(ctx context.Context, name string, applicationID primitive.ObjectID) (*model.ScheduledJob, error)
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.ScheduledJob, error)
+		InsertOne(ctx context.Context, j *model.ScheduledJob) (id interface{}, err error)
+		UpdateByID(ctx context.Context, j *model.ScheduledJob, id primitive.ObjectID) (bool, error)
+		DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+	}
+
+	NotificationChannelRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.NotificationChannel, error)
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.NotificationChannel, error)
+		InsertOne(ctx context.Context, n *model.NotificationChannel) (id interface{}, err error)
+		UpdateByID(ctx context.Context, n *model.NotificationChannel, id primitive.ObjectID) (bool, error)
+		DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+	}
+
+	JobRunRepoer interface {
+		FindByID(ctx context.Context, id primitive.ObjectID) (*model.JobRun, error)
+		FindByJobName(ctx context.Context, jobName string) (*model.JobRun, error)
+		//returns the runs of the application sorted from the newest to the oldest
+		FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.JobRun, error)
+		InsertOne(ctx context.Context, r *model.JobRun) (id interface{}, err error)
+		UpdateByID(ctx context.Context, r *model.JobRun, id primitive.ObjectID) (bool, error)
 	}
 
 	TemporaryTokenStorage interface {
@@ -59,5 +149,6 @@ type (
 )
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
 )
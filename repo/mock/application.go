@@ -2,9 +2,11 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/model"
 	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -18,10 +20,24 @@ type ApplicationRepoerMock struct {
 	storage map[primitive.ObjectID]*model.Application
 }
 
+// the applications are stored and returned as copies like the database does,
+// changing a returned application doesn't change the stored one until it's updated
+func cloneApplication(application *model.Application) *model.Application {
+	data, err := bson.Marshal(application)
+	if err != nil {
+		panic(err)
+	}
+	clone := new(model.Application)
+	if err := bson.Unmarshal(data, clone); err != nil {
+		panic(err)
+	}
+	return clone
+}
+
 func (r *ApplicationRepoerMock) FindByID(ctx context.Context, _id primitive.ObjectID) (*model.Application, error) {
 	entity, ok := r.storage[_id]
 	if ok {
-		return entity, nil
+		return cloneApplication(entity), nil
 	}
 	return nil, repo.ErrNotFound
 }
@@ -29,7 +45,7 @@ func (r *ApplicationRepoerMock) FindByID(ctx context.Context, _id primitive.Obje
 func (r *ApplicationRepoerMock) FindByName(ctx context.Context, name string) (*model.Application, error) {
 	for _, entity := range r.storage {
 		if entity.Name == name {
-			return entity, nil
+			return cloneApplication(entity), nil
 		}
 	}
 	return nil, repo.ErrNotFound
@@ -38,7 +54,7 @@ func (r *ApplicationRepoerMock) FindByName(ctx context.Context, name string) (*m
 func (r *ApplicationRepoerMock) FindByNameAndOwner(ctx context.Context, name, owner string) (*model.Application, error) {
 	for _, entity := range r.storage {
 		if entity.Name == name && entity.Owner == owner {
-			return entity, nil
+			return cloneApplication(entity), nil
 		}
 	}
 	return nil, repo.ErrNotFound
@@ -57,17 +73,25 @@ func (r *ApplicationRepoerMock) FindByOwner(ctx context.Context, owner string) (
 	var entities []*model.Application
 	for _, entity := range r.storage {
 		if entity.Owner == owner {
-			entities = append(entities, entity)
+			entities = append(entities, cloneApplication(entity))
 		}
 	}
 	return entities, nil
 }
 
+func (r *ApplicationRepoerMock) FindAll(ctx context.Context) ([]*model.Application, error) {
+	entities := make([]*model.Application, 0, len(r.storage))
+	for _, entity := range r.storage {
+		entities = append(entities, cloneApplication(entity))
+	}
+	return entities, nil
+}
+
 func (r *ApplicationRepoerMock) FindByOwnerAndKind(ctx context.Context, owner string, kind model.ApplicationKind) ([]*model.Application, error) {
 	var entities []*model.Application
 	for _, entity := range r.storage {
 		if entity.Owner == owner && entity.Kind == kind {
-			entities = append(entities, entity)
+			entities = append(entities, cloneApplication(entity))
 		}
 	}
 	return entities, nil
@@ -77,7 +101,7 @@ func (r *ApplicationRepoerMock) FindByOwnerAndKindAndIsPublicTrue(ctx context.Co
 	var entities []*model.Application
 	for _, entity := range r.storage {
 		if entity.Owner == owner && entity.Kind == serviceType && entity.Visiblity == model.ApplicationVisiblityPublic {
-			entities = append(entities, entity)
+			entities = append(entities, cloneApplication(entity))
 		}
 	}
 	return entities, nil
@@ -87,7 +111,7 @@ func (r *ApplicationRepoerMock) FindByOwnerAndKindAndIsPublicFalse(ctx context.C
 	var entities []*model.Application
 	for _, entity := range r.storage {
 		if entity.Owner == owner && entity.Kind == serviceType && entity.Visiblity == model.ApplicationVisiblityPrivate {
-			entities = append(entities, entity)
+			entities = append(entities, cloneApplication(entity))
 		}
 	}
 	return entities, nil
@@ -97,7 +121,7 @@ func (r *ApplicationRepoerMock) FindByOwnerAndIsUpdatableTrue(ctx context.Contex
 	var entities []*model.Application
 	for _, entity := range r.storage {
 		if entity.Owner == owner && entity.IsUpdatable {
-			entities = append(entities, entity)
+			entities = append(entities, cloneApplication(entity))
 		}
 	}
 	return entities, nil
@@ -109,19 +133,57 @@ func (r *ApplicationRepoerMock) InsertOne(ctx context.Context, application *mode
 		id = application.ID
 	}
 	application.ID = id
-	r.storage[id] = application
+	r.storage[id] = cloneApplication(application)
 	return id, nil
 }
 
 func (r *ApplicationRepoerMock) UpdateByID(ctx context.Context, application *model.Application, _id primitive.ObjectID) (bool, error) {
-	_, ok := r.storage[_id]
+	entity, ok := r.storage[_id]
 	if !ok {
 		return false, repo.ErrNotFound
 	}
-	r.storage[_id] = application
+	if entity.Version != application.Version {
+		return false, repo.ErrVersionConflict
+	}
+	application.UpdatedAt = time.Now()
+	application.Version++
+	r.storage[_id] = cloneApplication(application)
 	return true, nil
 }
 
+func (r *ApplicationRepoerMock) UpdateState(ctx context.Context, _id primitive.ObjectID, state model.ApplicationState) error {
+	entity, ok := r.storage[_id]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	entity.State = state
+	entity.UpdatedAt = time.Now()
+	entity.Version++
+	return nil
+}
+
+func (r *ApplicationRepoerMock) UpdateCurrentPod(ctx context.Context, _id primitive.ObjectID, podName string) error {
+	entity, ok := r.storage[_id]
+	if !ok || entity.Service == nil || entity.Service.Deployment == nil {
+		return repo.ErrNotFound
+	}
+	entity.Service.Deployment.CurrentPodName = podName
+	entity.UpdatedAt = time.Now()
+	entity.Version++
+	return nil
+}
+
+func (r *ApplicationRepoerMock) UpdateService(ctx context.Context, _id primitive.ObjectID, service *model.Service) error {
+	entity, ok := r.storage[_id]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	entity.Service = cloneApplication(&model.Application{Service: service}).Service
+	entity.UpdatedAt = time.Now()
+	entity.Version++
+	return nil
+}
+
 func (r *ApplicationRepoerMock) DeleteByID(ctx context.Context, _id primitive.ObjectID) (bool, error) {
 	_, ok := r.storage[_id]
 	if !ok {
@@ -130,3 +192,38 @@ func (r *ApplicationRepoerMock) DeleteByID(ctx context.Context, _id primitive.Ob
 	delete(r.storage, _id)
 	return true, nil
 }
+
+func (r *ApplicationRepoerMock) FindByTargetID(ctx context.Context, targetID primitive.ObjectID) ([]*model.Application, error) {
+	var entities []*model.Application
+	for _, entity := range r.storage {
+		if entity.TargetID == targetID {
+			entities = append(entities, cloneApplication(entity))
+		}
+	}
+	return entities, nil
+}
+
+func (r *ApplicationRepoerMock) CountByKindAndState(ctx context.Context) ([]*model.ApplicationCount, error) {
+	counts := make(map[model.ApplicationCount]int)
+	for _, entity := range r.storage {
+		counts[model.ApplicationCount{Kind: entity.Kind, State: entity.State}]++
+	}
+	var entities []*model.ApplicationCount
+	for key, count := range counts {
+		entities = append(entities, &model.ApplicationCount{Kind: key.Kind, State: key.State, Count: count})
+	}
+	return entities, nil
+}
+
+func (r *ApplicationRepoerMock) FindByEnvGroupID(ctx context.Context, envGroupID primitive.ObjectID) ([]*model.Application, error) {
+	var entities []*model.Application
+	for _, entity := range r.storage {
+		for _, id := range entity.EnvGroups {
+			if id == envGroupID {
+				entities = append(entities, cloneApplication(entity))
+				break
+			}
+		}
+	}
+	return entities, nil
+}
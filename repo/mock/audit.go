@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewAuditRepoer() repo.AuditRepoer {
+	return &AuditRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.AuditEvent),
+	}
+}
+
+type AuditRepoerMock struct {
+	storage map[primitive.ObjectID]*model.AuditEvent
+}
+
+func (r *AuditRepoerMock) InsertOne(ctx context.Context, e *model.AuditEvent) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if e.ID != primitive.NilObjectID {
+		id = e.ID
+	}
+	e.ID = id
+	r.storage[id] = e
+	return id, nil
+}
+
+func (r *AuditRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.AuditEvent, error) {
+	var entities []*model.AuditEvent
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.After(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
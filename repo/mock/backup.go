@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewBackupRepoer() repo.BackupRepoer {
+	return &BackupRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.Backup),
+	}
+}
+
+type BackupRepoerMock struct {
+	storage map[primitive.ObjectID]*model.Backup
+}
+
+func (r *BackupRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Backup, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *BackupRepoerMock) FindByJobName(ctx context.Context, jobName string) (*model.Backup, error) {
+	for _, entity := range r.storage {
+		if entity.JobName == jobName {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *BackupRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.Backup, error) {
+	var entities []*model.Backup
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.After(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
+
+func (r *BackupRepoerMock) InsertOne(ctx context.Context, backup *model.Backup) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if backup.ID != primitive.NilObjectID {
+		id = backup.ID
+	}
+	backup.ID = id
+	r.storage[id] = backup
+	return id, nil
+}
+
+func (r *BackupRepoerMock) UpdateByID(ctx context.Context, backup *model.Backup, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	r.storage[id] = backup
+	return true, nil
+}
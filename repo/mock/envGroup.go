@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewEnvGroupRepoer() repo.EnvGroupRepoer {
+	return &EnvGroupRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.EnvGroup),
+	}
+}
+
+type EnvGroupRepoerMock struct {
+	storage map[primitive.ObjectID]*model.EnvGroup
+}
+
+func (r *EnvGroupRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.EnvGroup, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *EnvGroupRepoerMock) FindByNameAndOwner(ctx context.Context, name, owner string) (*model.EnvGroup, error) {
+	for _, entity := range r.storage {
+		if entity.Name == name && entity.Owner == owner {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *EnvGroupRepoerMock) FindByOwner(ctx context.Context, owner string) ([]*model.EnvGroup, error) {
+	var entities []*model.EnvGroup
+	for _, entity := range r.storage {
+		if entity.Owner == owner {
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
+
+func (r *EnvGroupRepoerMock) InsertOne(ctx context.Context, group *model.EnvGroup) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if group.ID != primitive.NilObjectID {
+		id = group.ID
+	}
+	t := time.Now()
+	group.ID = id
+	group.CreatedAt = t
+	group.UpdatedAt = t
+	r.storage[id] = group
+	return id, nil
+}
+
+func (r *EnvGroupRepoerMock) UpdateByID(ctx context.Context, group *model.EnvGroup, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	group.UpdatedAt = time.Now()
+	r.storage[id] = group
+	return true, nil
+}
+
+func (r *EnvGroupRepoerMock) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	delete(r.storage, id)
+	return true, nil
+}
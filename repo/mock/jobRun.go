@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewJobRunRepoer() repo.JobRunRepoer {
+	return &JobRunRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.JobRun),
+	}
+}
+
+type JobRunRepoerMock struct {
+	storage map[primitive.ObjectID]*model.JobRun
+}
+
+func (r *JobRunRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.JobRun, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *JobRunRepoerMock) FindByJobName(ctx context.Context, jobName string) (*model.JobRun, error) {
+	for _, entity := range r.storage {
+		if entity.JobName == jobName {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *JobRunRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.JobRun, error) {
+	var entities []*model.JobRun
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.After(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
+
+func (r *JobRunRepoerMock) InsertOne(ctx context.Context, run *model.JobRun) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if run.ID != primitive.NilObjectID {
+		id = run.ID
+	}
+	run.ID = id
+	r.storage[id] = run
+	return id, nil
+}
+
+func (r *JobRunRepoerMock) UpdateByID(ctx context.Context, run *model.JobRun, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	r.storage[id] = run
+	return true, nil
+}
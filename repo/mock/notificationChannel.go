@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewNotificationChannelRepoer() repo.NotificationChannelRepoer {
+	return &NotificationChannelRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.NotificationChannel),
+	}
+}
+
+type NotificationChannelRepoerMock struct {
+	storage map[primitive.ObjectID]*model.NotificationChannel
+}
+
+func (r *NotificationChannelRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.NotificationChannel, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *NotificationChannelRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.NotificationChannel, error) {
+	var entities []*model.NotificationChannel
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.Before(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
+
+func (r *NotificationChannelRepoerMock) InsertOne(ctx context.Context, channel *model.NotificationChannel) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if channel.ID != primitive.NilObjectID {
+		id = channel.ID
+	}
+	channel.ID = id
+	r.storage[id] = channel
+	return id, nil
+}
+
+func (r *NotificationChannelRepoerMock) UpdateByID(ctx context.Context, channel *model.NotificationChannel, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	r.storage[id] = channel
+	return true, nil
+}
+
+func (r *NotificationChannelRepoerMock) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	delete(r.storage, id)
+	return true, nil
+}
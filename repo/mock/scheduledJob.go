@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewScheduledJobRepoer() repo.ScheduledJobRepoer {
+	return &ScheduledJobRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.ScheduledJob),
+	}
+}
+
+type ScheduledJobRepoerMock struct {
+	storage map[primitive.ObjectID]*model.ScheduledJob
+}
+
+func (r *ScheduledJobRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ScheduledJob, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *ScheduledJobRepoerMock) FindByNameAndApplicationID(ctx context.Context, name string, applicationID primitive.ObjectID) (*model.ScheduledJob, error) {
+	for _, entity := range r.storage {
+		if entity.Name == name && entity.ApplicationID == applicationID {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *ScheduledJobRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.ScheduledJob, error) {
+	var entities []*model.ScheduledJob
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.Before(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
+
+func (r *ScheduledJobRepoerMock) InsertOne(ctx context.Context, job *model.ScheduledJob) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if job.ID != primitive.NilObjectID {
+		id = job.ID
+	}
+	job.ID = id
+	r.storage[id] = job
+	return id, nil
+}
+
+func (r *ScheduledJobRepoerMock) UpdateByID(ctx context.Context, job *model.ScheduledJob, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	r.storage[id] = job
+	return true, nil
+}
+
+func (r *ScheduledJobRepoerMock) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	delete(r.storage, id)
+	return true, nil
+}
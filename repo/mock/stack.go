@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewStackRepoer() repo.StackRepoer {
+	return &StackRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.Stack),
+	}
+}
+
+type StackRepoerMock struct {
+	storage map[primitive.ObjectID]*model.Stack
+}
+
+func (r *StackRepoerMock) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Stack, error) {
+	entity, ok := r.storage[id]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *StackRepoerMock) FindByNameAndOwner(ctx context.Context, name, owner string) (*model.Stack, error) {
+	for _, entity := range r.storage {
+		if entity.Name == name && entity.Owner == owner {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *StackRepoerMock) FindByOwner(ctx context.Context, owner string) ([]*model.Stack, error) {
+	var entities []*model.Stack
+	for _, entity := range r.storage {
+		if entity.Owner == owner {
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
+
+func (r *StackRepoerMock) InsertOne(ctx context.Context, stack *model.Stack) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if stack.ID != primitive.NilObjectID {
+		id = stack.ID
+	}
+	t := time.Now()
+	stack.ID = id
+	stack.CreatedAt = t
+	stack.UpdatedAt = t
+	r.storage[id] = stack
+	return id, nil
+}
+
+func (r *StackRepoerMock) UpdateByID(ctx context.Context, stack *model.Stack, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	stack.UpdatedAt = time.Now()
+	r.storage[id] = stack
+	return true, nil
+}
+
+func (r *StackRepoerMock) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, ok := r.storage[id]
+	if !ok {
+		return false, repo.ErrNotFound
+	}
+	delete(r.storage, id)
+	return true, nil
+}
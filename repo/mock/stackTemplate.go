@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+)
+
+func NewStackTemplateRepoer() repo.StackTemplateRepoer {
+	return &StackTemplateRepoerMock{
+		storage: make(map[string]*model.StackTemplate),
+	}
+}
+
+type StackTemplateRepoerMock struct {
+	storage map[string]*model.StackTemplate
+}
+
+func (r *StackTemplateRepoerMock) FindByCode(ctx context.Context, code string) (*model.StackTemplate, error) {
+	entity, ok := r.storage[code]
+	if ok {
+		return entity, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *StackTemplateRepoerMock) FindAllAvailable(ctx context.Context) ([]*model.StackTemplate, error) {
+	var entities []*model.StackTemplate
+	for _, entity := range r.storage {
+		if entity.Available {
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
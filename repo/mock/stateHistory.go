@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewStateHistoryRepoer() repo.StateHistoryRepoer {
+	return &StateHistoryRepoerMock{
+		storage: make(map[primitive.ObjectID]*model.StateTransition),
+	}
+}
+
+type StateHistoryRepoerMock struct {
+	storage map[primitive.ObjectID]*model.StateTransition
+}
+
+func (r *StateHistoryRepoerMock) InsertOne(ctx context.Context, t *model.StateTransition) (interface{}, error) {
+	id := primitive.NewObjectID()
+	if t.ID != primitive.NilObjectID {
+		id = t.ID
+	}
+	t.ID = id
+	r.storage[id] = t
+	return id, nil
+}
+
+func (r *StateHistoryRepoerMock) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.StateTransition, error) {
+	var entities []*model.StateTransition
+	for _, entity := range r.storage {
+		if entity.ApplicationID == applicationID {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].CreatedAt.Before(entities[j].CreatedAt)
+	})
+	return entities, nil
+}
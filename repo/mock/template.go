@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+)
+
+func NewTemplateRepoer() repo.TemplateRepoer {
+	return &TemplateRepoerMock{
+		storage: make(map[string][]*model.Template),
+	}
+}
+
+// the versions of every template are kept sorted from the newest to the oldest
+type TemplateRepoerMock struct {
+	storage map[string][]*model.Template
+}
+
+func (r *TemplateRepoerMock) FindByCode(ctx context.Context, code string) (*model.Template, error) {
+	versions, ok := r.storage[code]
+	if ok && len(versions) > 0 {
+		return versions[0], nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *TemplateRepoerMock) FindByCodeAndVersion(ctx context.Context, code string, version int) (*model.Template, error) {
+	for _, entity := range r.storage[code] {
+		if entity.Version == version {
+			return entity, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *TemplateRepoerMock) FindVersionsByCode(ctx context.Context, code string) ([]*model.Template, error) {
+	return r.storage[code], nil
+}
+
+func (r *TemplateRepoerMock) FindAll(ctx context.Context) ([]*model.Template, error) {
+	var entities []*model.Template
+	for _, versions := range r.storage {
+		entities = append(entities, versions[0])
+	}
+	return entities, nil
+}
+
+func (r *TemplateRepoerMock) FindAllAvailable(ctx context.Context) ([]*model.Template, error) {
+	var entities []*model.Template
+	for _, versions := range r.storage {
+		if versions[0].Available && !versions[0].Deprecated {
+			entities = append(entities, versions[0])
+		}
+	}
+	return entities, nil
+}
+
+func (r *TemplateRepoerMock) InsertOne(ctx context.Context, template *model.Template) (interface{}, error) {
+	template.CreatedAt = time.Now()
+	versions := append(r.storage[template.Code], template)
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	r.storage[template.Code] = versions
+	return template.Code, nil
+}
+
+func (r *TemplateRepoerMock) UpdateByCodeAndVersion(ctx context.Context, template *model.Template, code string, version int) (bool, error) {
+	for i, entity := range r.storage[code] {
+		if entity.Version == version {
+			r.storage[code][i] = template
+			return true, nil
+		}
+	}
+	return false, repo.ErrNotFound
+}
@@ -0,0 +1,127 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"github.com/ipaas-org/ipaas-backend/repo/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func insertApplication(t *testing.T, r repo.ApplicationRepoer) *model.Application {
+	app := &model.Application{
+		Name:  "test-app",
+		State: model.ApplicationStateRunning,
+		Service: &model.Service{
+			Deployment: &model.Deployment{},
+		},
+	}
+	if _, err := r.InsertOne(context.Background(), app); err != nil {
+		t.Fatalf("error inserting application: %v", err)
+	}
+	return app
+}
+
+func TestApplicationUpdateByIDVersion(t *testing.T) {
+	r := mock.NewApplicationRepoer()
+	ctx := context.Background()
+	app := insertApplication(t, r)
+
+	first, err := r.FindByID(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+	second, err := r.FindByID(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("error finding application: %v", err)
+	}
+
+	t.Run("update with the read version increments it", func(t *testing.T) {
+		first.Name = "first"
+		if _, err := r.UpdateByID(ctx, first, first.ID); err != nil {
+			t.Fatalf("error updating application: %v", err)
+		}
+		if first.Version != 1 {
+			t.Errorf("version should be incremented to 1, got %d", first.Version)
+		}
+	})
+
+	t.Run("update with a stale version is rejected", func(t *testing.T) {
+		second.Name = "second"
+		if _, err := r.UpdateByID(ctx, second, second.ID); err != repo.ErrVersionConflict {
+			t.Errorf("stale update should fail with ErrVersionConflict, got %v", err)
+		}
+		stored, err := r.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if stored.Name != "first" || stored.Version != 1 {
+			t.Errorf("stored application should be the first update, got %s version %d", stored.Name, stored.Version)
+		}
+	})
+
+	t.Run("granular updates increment the version", func(t *testing.T) {
+		if err := r.UpdateState(ctx, app.ID, model.ApplicationStateCrashed); err != nil {
+			t.Fatalf("error updating state: %v", err)
+		}
+		if _, err := r.UpdateByID(ctx, first, first.ID); err != repo.ErrVersionConflict {
+			t.Errorf("update after a granular update should fail with ErrVersionConflict, got %v", err)
+		}
+		if err := r.UpdateService(ctx, app.ID, &model.Service{Deployment: &model.Deployment{CurrentPodName: "new-pod"}}); err != nil {
+			t.Fatalf("error updating service: %v", err)
+		}
+		stored, err := r.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if stored.Version != 3 || stored.Service.Deployment.CurrentPodName != "new-pod" {
+			t.Errorf("service should be saved with version 3, got pod %s version %d", stored.Service.Deployment.CurrentPodName, stored.Version)
+		}
+	})
+
+	t.Run("unknown application is not found", func(t *testing.T) {
+		if _, err := r.UpdateByID(ctx, first, primitive.NewObjectID()); err != repo.ErrNotFound {
+			t.Errorf("update of unknown application should fail with ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestApplicationStoredAsCopy(t *testing.T) {
+	r := mock.NewApplicationRepoer()
+	ctx := context.Background()
+	app := insertApplication(t, r)
+
+	t.Run("changing a found application keeps the stored one", func(t *testing.T) {
+		found, err := r.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		found.Name = "changed"
+		found.Service.Deployment.CurrentPodName = "changed-pod"
+
+		stored, err := r.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if stored.Name != "test-app" || stored.Service.Deployment.CurrentPodName != "" {
+			t.Errorf("stored application should not change without an update, got %s with pod %s", stored.Name, stored.Service.Deployment.CurrentPodName)
+		}
+	})
+
+	t.Run("changing an updated application keeps the stored one", func(t *testing.T) {
+		if _, err := r.UpdateByID(ctx, app, app.ID); err != nil {
+			t.Fatalf("error updating application: %v", err)
+		}
+		app.Name = "changed after update"
+
+		stored, err := r.FindByID(ctx, app.ID)
+		if err != nil {
+			t.Fatalf("error finding application: %v", err)
+		}
+		if stored.Name != "test-app" {
+			t.Errorf("stored name should not change without an update, got %s", stored.Name)
+		}
+	})
+}
@@ -92,6 +92,18 @@ func (r *ApplicationRepoerMongo) FindByOwner(ctx context.Context, owner string)
 	return applications, nil
 }
 
+func (r *ApplicationRepoerMongo) FindAll(ctx context.Context) ([]*model.Application, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var applications []*model.Application
+	if err := cursor.All(ctx, &applications); err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
 func (r *ApplicationRepoerMongo) FindByOwnerAndKind(ctx context.Context, owner string, kind model.ApplicationKind) ([]*model.Application, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{
 		"$and": []bson.M{
@@ -187,19 +199,83 @@ func (r *ApplicationRepoerMongo) InsertOne(ctx context.Context, application *mod
 }
 
 func (r *ApplicationRepoerMongo) UpdateByID(ctx context.Context, application *model.Application, _id primitive.ObjectID) (bool, error) {
+	version := application.Version
+	filter := bson.M{
+		"_id":     _id,
+		"version": version,
+	}
+	//applications created before the versioning have no version
+	if version == 0 {
+		filter = bson.M{
+			"_id": _id,
+			"$or": []bson.M{
+				{"version": 0},
+				{"version": bson.M{"$exists": false}},
+			},
+		}
+	}
+
+	updatedAt := application.UpdatedAt
 	application.UpdatedAt = time.Now()
-	result, err := r.collection.UpdateOne(ctx, bson.M{
-		"_id": _id,
-	}, bson.M{
+	application.Version = version + 1
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
 		"$set": application,
 	})
+	if err == nil && result.MatchedCount == 0 {
+		err = r.notFoundOrConflict(ctx, _id)
+	}
 	if err != nil {
+		application.UpdatedAt = updatedAt
+		application.Version = version
 		if err == mongo.ErrNoDocuments {
 			return false, repo.ErrNotFound
 		}
 		return false, err
 	}
-	return result.MatchedCount > 0, err
+	return true, nil
+}
+
+// the update matched nothing, either the application doesn't exist or it
+// was updated by someone else
+func (r *ApplicationRepoerMongo) notFoundOrConflict(ctx context.Context, _id primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": _id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return repo.ErrNotFound
+	}
+	return repo.ErrVersionConflict
+}
+
+func (r *ApplicationRepoerMongo) updateFields(ctx context.Context, filter bson.M, fields bson.M) error {
+	fields["updatedAt"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set": fields,
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ApplicationRepoerMongo) UpdateState(ctx context.Context, _id primitive.ObjectID, state model.ApplicationState) error {
+	return r.updateFields(ctx, bson.M{"_id": _id}, bson.M{"state": state})
+}
+
+func (r *ApplicationRepoerMongo) UpdateCurrentPod(ctx context.Context, _id primitive.ObjectID, podName string) error {
+	return r.updateFields(ctx, bson.M{
+		"_id":                _id,
+		"service.deployment": bson.M{"$ne": nil},
+	}, bson.M{"service.deployment.currentPodName": podName})
+}
+
+func (r *ApplicationRepoerMongo) UpdateService(ctx context.Context, _id primitive.ObjectID, service *model.Service) error {
+	return r.updateFields(ctx, bson.M{"_id": _id}, bson.M{"service": service})
 }
 
 func (r *ApplicationRepoerMongo) DeleteByID(ctx context.Context, _id primitive.ObjectID) (bool, error) {
@@ -214,3 +290,56 @@ func (r *ApplicationRepoerMongo) DeleteByID(ctx context.Context, _id primitive.O
 	}
 	return result.DeletedCount > 0, nil
 }
+
+func (r *ApplicationRepoerMongo) FindByEnvGroupID(ctx context.Context, envGroupID primitive.ObjectID) ([]*model.Application, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"envGroups": envGroupID,
+	}, options.Find().SetSort(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	var applications []*model.Application
+	if err := cursor.All(ctx, &applications); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return applications, nil
+}
+
+func (r *ApplicationRepoerMongo) FindByTargetID(ctx context.Context, targetID primitive.ObjectID) ([]*model.Application, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"targetID": targetID,
+	}, options.Find().SetSort(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	var applications []*model.Application
+	if err := cursor.All(ctx, &applications); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return applications, nil
+}
+
+func (r *ApplicationRepoerMongo) CountByKindAndState(ctx context.Context) ([]*model.ApplicationCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"kind": "$kind", "state": "$state"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "kind": "$_id.kind", "state": "$_id.state", "count": 1}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var counts []*model.ApplicationCount
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
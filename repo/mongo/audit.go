@@ -0,0 +1,48 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewAuditRepoer(collection *mongo.Collection) repo.AuditRepoer {
+	return &AuditRepoerMongo{
+		collection: collection,
+	}
+}
+
+type AuditRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *AuditRepoerMongo) InsertOne(ctx context.Context, e *model.AuditEvent) (interface{}, error) {
+	e.ID = primitive.NewObjectID()
+	result, err := r.collection.InsertOne(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *AuditRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.AuditEvent, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	var events []*model.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return events, nil
+}
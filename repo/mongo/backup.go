@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewBackupRepoer(collection *mongo.Collection) repo.BackupRepoer {
+	return &BackupRepoerMongo{
+		collection: collection,
+	}
+}
+
+type BackupRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *BackupRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Backup, error) {
+	var backup model.Backup
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&backup); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *BackupRepoerMongo) FindByJobName(ctx context.Context, jobName string) (*model.Backup, error) {
+	var backup model.Backup
+	if err := r.collection.FindOne(ctx, bson.M{
+		"jobName": jobName,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&backup); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *BackupRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.Backup, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	var backups []*model.Backup
+	if err := cursor.All(ctx, &backups); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return backups, nil
+}
+
+func (r *BackupRepoerMongo) InsertOne(ctx context.Context, backup *model.Backup) (interface{}, error) {
+	backup.ID = primitive.NewObjectID()
+	result, err := r.collection.InsertOne(ctx, backup)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *BackupRepoerMongo) UpdateByID(ctx context.Context, backup *model.Backup, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": backup,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
@@ -0,0 +1,110 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewEnvGroupRepoer(collection *mongo.Collection) repo.EnvGroupRepoer {
+	return &EnvGroupRepoerMongo{
+		collection: collection,
+	}
+}
+
+type EnvGroupRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *EnvGroupRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.EnvGroup, error) {
+	var group model.EnvGroup
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&group); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *EnvGroupRepoerMongo) FindByNameAndOwner(ctx context.Context, name, owner string) (*model.EnvGroup, error) {
+	var group model.EnvGroup
+	if err := r.collection.FindOne(ctx, bson.M{
+		"$and": []bson.M{
+			{"name": name},
+			{"owner": owner},
+		},
+	}, options.FindOne().SetSort(bson.M{})).Decode(&group); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *EnvGroupRepoerMongo) FindByOwner(ctx context.Context, owner string) ([]*model.EnvGroup, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"owner": owner,
+	}, options.Find().SetSort(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	var groups []*model.EnvGroup
+	if err := cursor.All(ctx, &groups); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *EnvGroupRepoerMongo) InsertOne(ctx context.Context, group *model.EnvGroup) (interface{}, error) {
+	t := time.Now()
+	group.ID = primitive.NewObjectID()
+	group.CreatedAt = t
+	group.UpdatedAt = t
+	result, err := r.collection.InsertOne(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *EnvGroupRepoerMongo) UpdateByID(ctx context.Context, group *model.EnvGroup, id primitive.ObjectID) (bool, error) {
+	group.UpdatedAt = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": group,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
+
+func (r *EnvGroupRepoerMongo) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id": id,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
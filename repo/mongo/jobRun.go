@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewJobRunRepoer(collection *mongo.Collection) repo.JobRunRepoer {
+	return &JobRunRepoerMongo{
+		collection: collection,
+	}
+}
+
+type JobRunRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *JobRunRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.JobRun, error) {
+	var run model.JobRun
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&run); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *JobRunRepoerMongo) FindByJobName(ctx context.Context, jobName string) (*model.JobRun, error) {
+	var run model.JobRun
+	if err := r.collection.FindOne(ctx, bson.M{
+		"jobName": jobName,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&run); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *JobRunRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.JobRun, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	var runs []*model.JobRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *JobRunRepoerMongo) InsertOne(ctx context.Context, run *model.JobRun) (interface{}, error) {
+	run.ID = primitive.NewObjectID()
+	result, err := r.collection.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *JobRunRepoerMongo) UpdateByID(ctx context.Context, run *model.JobRun, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": run,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewNotificationChannelRepoer(collection *mongo.Collection) repo.NotificationChannelRepoer {
+	return &NotificationChannelRepoerMongo{
+		collection: collection,
+	}
+}
+
+type NotificationChannelRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *NotificationChannelRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.NotificationChannel, error) {
+	var channel model.NotificationChannel
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&channel); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *NotificationChannelRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.NotificationChannel, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var channels []*model.NotificationChannel
+	if err := cursor.All(ctx, &channels); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (r *NotificationChannelRepoerMongo) InsertOne(ctx context.Context, channel *model.NotificationChannel) (interface{}, error) {
+	if channel.ID.IsZero() {
+		channel.ID = primitive.NewObjectID()
+	}
+	result, err := r.collection.InsertOne(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *NotificationChannelRepoerMongo) UpdateByID(ctx context.Context, channel *model.NotificationChannel, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": channel,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
+
+func (r *NotificationChannelRepoerMongo) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id": id,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,105 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewScheduledJobRepoer(collection *mongo.Collection) repo.ScheduledJobRepoer {
+	return &ScheduledJobRepoerMongo{
+		collection: collection,
+	}
+}
+
+type ScheduledJobRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *ScheduledJobRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ScheduledJob, error) {
+	var job model.ScheduledJob
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *ScheduledJobRepoerMongo) FindByNameAndApplicationID(ctx context.Context, name string, applicationID primitive.ObjectID) (*model.ScheduledJob, error) {
+	var job model.ScheduledJob
+	if err := r.collection.FindOne(ctx, bson.M{
+		"name":          name,
+		"applicationID": applicationID,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *ScheduledJobRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.ScheduledJob, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.ScheduledJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *ScheduledJobRepoerMongo) InsertOne(ctx context.Context, job *model.ScheduledJob) (interface{}, error) {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *ScheduledJobRepoerMongo) UpdateByID(ctx context.Context, job *model.ScheduledJob, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": job,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
+
+func (r *ScheduledJobRepoerMongo) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id": id,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
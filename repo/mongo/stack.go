@@ -0,0 +1,110 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewStackRepoer(collection *mongo.Collection) repo.StackRepoer {
+	return &StackRepoerMongo{
+		collection: collection,
+	}
+}
+
+type StackRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *StackRepoerMongo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Stack, error) {
+	var stack model.Stack
+	if err := r.collection.FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&stack); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &stack, nil
+}
+
+func (r *StackRepoerMongo) FindByNameAndOwner(ctx context.Context, name, owner string) (*model.Stack, error) {
+	var stack model.Stack
+	if err := r.collection.FindOne(ctx, bson.M{
+		"$and": []bson.M{
+			{"name": name},
+			{"owner": owner},
+		},
+	}, options.FindOne().SetSort(bson.M{})).Decode(&stack); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &stack, nil
+}
+
+func (r *StackRepoerMongo) FindByOwner(ctx context.Context, owner string) ([]*model.Stack, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"owner": owner,
+	}, options.Find().SetSort(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	var stacks []*model.Stack
+	if err := cursor.All(ctx, &stacks); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return stacks, nil
+}
+
+func (r *StackRepoerMongo) InsertOne(ctx context.Context, stack *model.Stack) (interface{}, error) {
+	t := time.Now()
+	stack.ID = primitive.NewObjectID()
+	stack.CreatedAt = t
+	stack.UpdatedAt = t
+	result, err := r.collection.InsertOne(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *StackRepoerMongo) UpdateByID(ctx context.Context, stack *model.Stack, id primitive.ObjectID) (bool, error) {
+	stack.UpdatedAt = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+	}, bson.M{
+		"$set": stack,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
+
+func (r *StackRepoerMongo) DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id": id,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
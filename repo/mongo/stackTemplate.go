@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewStackTemplateRepoer(collection *mongo.Collection) repo.StackTemplateRepoer {
+	return &StackTemplateRepoerMongo{
+		collection: collection,
+	}
+}
+
+type StackTemplateRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *StackTemplateRepoerMongo) FindByCode(ctx context.Context, code string) (*model.StackTemplate, error) {
+	var template model.StackTemplate
+	if err := r.collection.FindOne(ctx, bson.M{
+		"code": code,
+	}, options.FindOne().SetSort(bson.M{})).Decode(&template); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *StackTemplateRepoerMongo) FindAllAvailable(ctx context.Context) ([]*model.StackTemplate, error) {
+	var templates []*model.StackTemplate
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"available": true,
+	}, options.Find().SetSort(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &templates); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return templates, nil
+}
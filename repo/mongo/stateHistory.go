@@ -0,0 +1,48 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewStateHistoryRepoer(collection *mongo.Collection) repo.StateHistoryRepoer {
+	return &StateHistoryRepoerMongo{
+		collection: collection,
+	}
+}
+
+type StateHistoryRepoerMongo struct {
+	collection *mongo.Collection
+}
+
+func (r *StateHistoryRepoerMongo) InsertOne(ctx context.Context, t *model.StateTransition) (interface{}, error) {
+	t.ID = primitive.NewObjectID()
+	result, err := r.collection.InsertOne(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *StateHistoryRepoerMongo) FindByApplicationID(ctx context.Context, applicationID primitive.ObjectID) ([]*model.StateTransition, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"applicationID": applicationID,
+	}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var transitions []*model.StateTransition
+	if err := cursor.All(ctx, &transitions); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return transitions, nil
+}
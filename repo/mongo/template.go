@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/model"
 	"github.com/ipaas-org/ipaas-backend/repo"
@@ -24,7 +25,7 @@ func (r *TemplateRepoerMongo) FindByCode(ctx context.Context, code string) (*mod
 	var template model.Template
 	if err := r.collection.FindOne(ctx, bson.M{
 		"code": code,
-	}, options.FindOne().SetSort(bson.M{})).Decode(&template); err != nil {
+	}, options.FindOne().SetSort(bson.M{"version": -1})).Decode(&template); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, repo.ErrNotFound
 		}
@@ -33,9 +34,30 @@ func (r *TemplateRepoerMongo) FindByCode(ctx context.Context, code string) (*mod
 	return &template, nil
 }
 
-func (r *TemplateRepoerMongo) FindAll(ctx context.Context) ([]*model.Template, error) {
+func (r *TemplateRepoerMongo) FindByCodeAndVersion(ctx context.Context, code string, version int) (*model.Template, error) {
+	filter := bson.M{
+		"code":    code,
+		"version": version,
+	}
+	//templates seeded before versioning have no version field
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	var template model.Template
+	if err := r.collection.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{})).Decode(&template); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *TemplateRepoerMongo) FindVersionsByCode(ctx context.Context, code string) ([]*model.Template, error) {
 	var templates []*model.Template
-	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{}))
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"code": code,
+	}, options.Find().SetSort(bson.M{"version": -1}))
 	if err != nil {
 		return nil, err
 	}
@@ -45,16 +67,63 @@ func (r *TemplateRepoerMongo) FindAll(ctx context.Context) ([]*model.Template, e
 	return templates, nil
 }
 
-func (r *TemplateRepoerMongo) FindAllAvailable(ctx context.Context) ([]*model.Template, error) {
-	var templates []*model.Template
-	cursor, err := r.collection.Find(ctx, bson.M{
-		"available": true,
-	}, options.Find().SetSort(bson.M{}))
+// returns the latest version of every template matching the filter applied after
+// the latest version is selected
+func (r *TemplateRepoerMongo) findLatest(ctx context.Context, filter bson.M) ([]*model.Template, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "code", Value: 1}, {Key: "version", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$code", "latest": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$latest"}}},
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.M{"code": 1}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
+	var templates []*model.Template
 	if err := cursor.All(ctx, &templates); err != nil {
 		return nil, err
 	}
 	return templates, nil
 }
+
+func (r *TemplateRepoerMongo) FindAll(ctx context.Context) ([]*model.Template, error) {
+	return r.findLatest(ctx, bson.M{})
+}
+
+func (r *TemplateRepoerMongo) FindAllAvailable(ctx context.Context) ([]*model.Template, error) {
+	return r.findLatest(ctx, bson.M{
+		"available":  true,
+		"deprecated": bson.M{"$ne": true},
+	})
+}
+
+func (r *TemplateRepoerMongo) InsertOne(ctx context.Context, template *model.Template) (interface{}, error) {
+	template.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+func (r *TemplateRepoerMongo) UpdateByCodeAndVersion(ctx context.Context, template *model.Template, code string, version int) (bool, error) {
+	filter := bson.M{
+		"code":    code,
+		"version": version,
+	}
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set": template,
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, repo.ErrNotFound
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, err
+}
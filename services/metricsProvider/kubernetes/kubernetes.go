@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	metricsprovider "github.com/ipaas-org/ipaas-backend/services/metricsProvider"
+	"github.com/tidwall/gjson"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var _ metricsprovider.MetricsProvider = new(KubernetesMetricsProvider)
+
+// uses the metrics api served by metrics-server, it only knows the current
+// usage so every series has a single point, network and requests are not available
+type KubernetesMetricsProvider struct {
+	clientset kubernetes.Interface
+}
+
+func NewKubernetesMetricsProvider(kubeConfigPath string) (*KubernetesMetricsProvider, error) {
+	var config *rest.Config
+	var err error
+	if kubeConfigPath == "inside" {
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting kubernetes config: %v", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kubernetes client: %v", err)
+	}
+	return &KubernetesMetricsProvider{clientset: clientset}, nil
+}
+
+func (k *KubernetesMetricsProvider) GetMetrics(ctx context.Context, target model.MetricsTarget, from, to time.Time, step time.Duration) (*model.ApplicationMetrics, error) {
+	deployment, err := k.clientset.AppsV1().Deployments(target.Namespace).Get(ctx, target.DeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting deployment: %v", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing deployment selector: %v", err)
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(target.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+	var restarts int32
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+	}
+
+	buf, err := k.clientset.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", target.Namespace, "pods").
+		Param("labelSelector", selector.String()).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pod metrics: %v", err)
+	}
+
+	var cpu, memory float64
+	now := time.Now()
+	for _, podMetrics := range gjson.GetBytes(buf, "items").Array() {
+		for _, container := range podMetrics.Get("containers").Array() {
+			cpu += parseQuantity(container.Get("usage.cpu").String())
+			memory += parseQuantity(container.Get("usage.memory").String())
+		}
+	}
+
+	return &model.ApplicationMetrics{
+		From:     from,
+		To:       to,
+		Step:     int64(step.Seconds()),
+		CPU:      []model.MetricPoint{{Timestamp: now, Value: cpu}},
+		Memory:   []model.MetricPoint{{Timestamp: now, Value: memory}},
+		Restarts: []model.MetricPoint{{Timestamp: now, Value: float64(restarts)}},
+	}, nil
+}
+
+func parseQuantity(value string) float64 {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0
+	}
+	return quantity.AsApproximateFloat64()
+}
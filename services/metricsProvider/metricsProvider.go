@@ -0,0 +1,18 @@
+package metricsprovider
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+)
+
+type MetricsProvider interface {
+	GetMetrics(ctx context.Context, target model.MetricsTarget, from, to time.Time, step time.Duration) (*model.ApplicationMetrics, error)
+}
+
+const (
+	MetricsProviderPrometheus = "prometheus"
+	MetricsProviderKubernetes = "kubernetes"
+	MetricsProviderMock       = "mock"
+)
@@ -0,0 +1,135 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	metricsprovider "github.com/ipaas-org/ipaas-backend/services/metricsProvider"
+	"github.com/tidwall/gjson"
+)
+
+var _ metricsprovider.MetricsProvider = new(PrometheusMetricsProvider)
+
+// uses the range queries of prometheus, the container metrics come from the
+// kubelet cadvisor, the restarts from kube-state-metrics and the requests from traefik
+type PrometheusMetricsProvider struct {
+	token         string
+	prometheusUrl *url.URL
+}
+
+func NewPrometheusMetricsProvider(token, prometheusUrl string) (*PrometheusMetricsProvider, error) {
+	parsedPrometheusUrl, err := url.Parse(prometheusUrl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse prometheus url (%s): %w", prometheusUrl, err)
+	}
+
+	return &PrometheusMetricsProvider{
+		token:         token,
+		prometheusUrl: parsedPrometheusUrl,
+	}, nil
+}
+
+func (p *PrometheusMetricsProvider) GetMetrics(ctx context.Context, target model.MetricsTarget, from, to time.Time, step time.Duration) (*model.ApplicationMetrics, error) {
+	metrics := &model.ApplicationMetrics{
+		From: from,
+		To:   to,
+		Step: int64(step.Seconds()),
+	}
+
+	//the pods of a deployment are named <deployment>-<replicaset hash>-<pod hash>
+	pods := fmt.Sprintf(`namespace=%q,pod=~"%s-[a-z0-9]+-[a-z0-9]+"`, target.Namespace, target.DeploymentName)
+	rate := fmt.Sprintf("%ds", max(int64(step.Seconds())*4, 60))
+	queries := map[*[]model.MetricPoint]string{
+		&metrics.CPU:             fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{%s,container!=""}[%s]))`, pods, rate),
+		&metrics.Memory:          fmt.Sprintf(`sum(container_memory_working_set_bytes{%s,container!=""})`, pods),
+		&metrics.Restarts:        fmt.Sprintf(`sum(kube_pod_container_status_restarts_total{%s})`, pods),
+		&metrics.NetworkReceive:  fmt.Sprintf(`sum(rate(container_network_receive_bytes_total{%s}[%s]))`, pods, rate),
+		&metrics.NetworkTransmit: fmt.Sprintf(`sum(rate(container_network_transmit_bytes_total{%s}[%s]))`, pods, rate),
+	}
+	if target.ServiceName != "" {
+		//traefik names the kubernetes services <namespace>-<service>-<port>
+		queries[&metrics.Requests] = fmt.Sprintf(`sum(rate(traefik_service_requests_total{service=~"%s-%s-.*"}[%s]))`, target.Namespace, target.ServiceName, rate)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(queries))
+	for series, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			points, err := p.queryRange(ctx, query, from, to, step)
+			if err != nil {
+				errs <- err
+				return
+			}
+			*series = points
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// returns the points of the first series of the result, the queries are
+// aggregated so there is at most one series
+func (p *PrometheusMetricsProvider) queryRange(ctx context.Context, query string, from, to time.Time, step time.Duration) ([]model.MetricPoint, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(from.Unix(), 10))
+	params.Set("end", strconv.FormatInt(to.Unix(), 10))
+	params.Set("step", strconv.FormatInt(int64(step.Seconds()), 10))
+
+	endpoint := p.prometheusUrl.JoinPath("api/v1/query_range")
+	endpoint.RawQuery = params.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", p.token))
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	body := gjson.ParseBytes(buf)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query prometheus: %s: %s", resp.Status, body.Get("error").String())
+	}
+
+	values := body.Get("data.result.0.values").Array()
+	points := make([]model.MetricPoint, 0, len(values))
+	for _, value := range values {
+		pair := value.Array()
+		if len(pair) != 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(pair[1].String(), 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, model.MetricPoint{
+			Timestamp: time.UnixMilli(int64(pair[0].Float() * 1000)),
+			Value:     v,
+		})
+	}
+	return points, nil
+}
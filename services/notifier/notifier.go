@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+)
+
+type Notifier interface {
+	// target is the email address or the url of the webhook
+	Notify(ctx context.Context, target string, notification *model.Notification) error
+}
+
+func Subject(n *model.Notification) string {
+	switch n.Event {
+	case model.NotificationEventApplicationCrashed:
+		return fmt.Sprintf("application %s crashed", n.ApplicationName)
+	case model.NotificationEventBuildFailed:
+		return fmt.Sprintf("build of application %s failed", n.ApplicationName)
+	case model.NotificationEventRolloutFailed:
+		return fmt.Sprintf("rollout of application %s failed", n.ApplicationName)
+	case model.NotificationEventTest:
+		return fmt.Sprintf("test notification for application %s", n.ApplicationName)
+	default:
+		return fmt.Sprintf("%s on application %s", n.Event, n.ApplicationName)
+	}
+}
+
+// plain text version of the notification used by email and chats
+func Text(n *model.Notification) string {
+	text := fmt.Sprintf("%s: %s", Subject(n), n.Message)
+	if n.Suppressed > 0 {
+		text += fmt.Sprintf(" (%d similar notifications were not sent)", n.Suppressed)
+	}
+	return text
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/notifier/webhook"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":         true,
+		"2001:4860::8888": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.0.0.1":        false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+		"224.0.0.1":       false,
+	}
+	for address, public := range cases {
+		t.Run(address, func(t *testing.T) {
+			if got := webhook.IsPublicIP(net.ParseIP(address)); got != public {
+				t.Fatalf("expected %v, got %v", public, got)
+			}
+		})
+	}
+}
+
+func TestPublicClientRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	notifier := webhook.NewWebhookNotifier(webhook.FormatJSON, webhook.NewPublicClient(time.Second))
+	err := notifier.Notify(context.Background(), server.URL, &model.Notification{Message: "test"})
+	if err == nil {
+		t.Fatal("expected the loopback target to be refused")
+	}
+	if called {
+		t.Fatal("expected the server to not be called")
+	}
+}
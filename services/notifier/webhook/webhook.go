@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/notifier"
+)
+
+var _ notifier.Notifier = new(WebhookNotifier)
+
+type Format string
+
+const (
+	FormatJSON    Format = "json"    //the notification as it is
+	FormatSlack   Format = "slack"   //{"text": "..."}
+	FormatDiscord Format = "discord" //{"content": "..."}
+)
+
+// posts the notification to the target url
+type WebhookNotifier struct {
+	format Format
+	client *http.Client
+}
+
+func NewWebhookNotifier(format Format, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{
+		format: format,
+		client: client,
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, target string, notification *model.Notification) error {
+	var payload interface{}
+	switch w.format {
+	case FormatSlack:
+		payload = map[string]string{"text": notifier.Text(notification)}
+	case FormatDiscord:
+		payload = map[string]string{"content": notifier.Text(notification)}
+	default:
+		payload = notification
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// the targets are set by the users, the client refuses to connect to
+// loopback, private and link local addresses so the webhooks can't reach
+// the services inside the cluster
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("address %s not allowed", address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+// the check is done on the resolved address, a host name pointing to an
+// internal address is refused when dialing
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -3,12 +3,17 @@ package serviceManager
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/model"
 )
 
 var (
 	ErrorCreatingResource error = fmt.Errorf("erorr")
+	ErrQuotaExceeded      error = fmt.Errorf("quota exceeded")
+	ErrResourceNotFound   error = fmt.Errorf("resource not found")
+	ErrRolloutFailed      error = fmt.Errorf("rollout failed")
 )
 
 // TODO: volume support is not implemented in
@@ -36,14 +41,39 @@ type OrchestratedServiceManager interface {
 	//to be used for creating a new namespace and then creating a new secret in it
 	CreateNewRegistrySecret(ctx context.Context, namespace, registryUrl, username, password string) (string, error)
 
+	//*resource quotas and limit ranges
+	GetResourceQuota(ctx context.Context, namespace, quotaName string) (*model.ResourceQuota, error)
+	CreateNewResourceQuota(ctx context.Context, namespace, quotaName string, hard model.QuotaResources, labels []model.KeyValue) (*model.ResourceQuota, error)
+	CreateNewLimitRange(ctx context.Context, namespace, limitRangeName, defaultCPU, defaultMemory, maxCPU, maxMemory string, labels []model.KeyValue) (*model.LimitRange, error)
+
 	//*deployments
 	GetDeployment(ctx context.Context, namespace, deploymentName string) (*model.Deployment, error)
-	CreateNewDeployment(ctx context.Context, namespace, deploymentName, app, imageRegistry string, replicas, port int32, labels []model.KeyValue, configMapName string, volume *model.Volume) (*model.Deployment, error)
-	UpdateDeployment(ctx context.Context, namespace, deploymentName, imageRegistry string, replicas, port int32, labels []model.KeyValue, configMapName string) (*model.Deployment, error)
+	//envSources are loaded in order, the keys of the last sources take precedence
+	//if resources is nil the default limits are used
+	CreateNewDeployment(ctx context.Context, namespace, deploymentName, app, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, volumes []*model.Volume, healthChecks *model.HealthChecks, resources *model.ContainerResources) (*model.Deployment, error)
+	//if envSources or healthChecks are nil the current ones are kept
+	UpdateDeployment(ctx context.Context, namespace, deploymentName, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, healthChecks *model.HealthChecks) (*model.Deployment, error)
+	//replaces all the volumes mounted in the deployment
+	UpdateDeploymentVolumes(ctx context.Context, namespace, deploymentName string, volumes []*model.Volume) (*model.Deployment, error)
+	//replaces the limits of the container, nil resets them to the default ones
+	UpdateDeploymentResources(ctx context.Context, namespace, deploymentName string, resources *model.ContainerResources) (*model.Deployment, error)
 	//gracePeriod in seconds, if 0 it will be deleted immediately, if negative it will be deleted after the default grace period
 	DeleteDeployment(ctx context.Context, namespace, deploymentName string, gracePeriod int64) error
 	WaitDeploymentReadyState(ctx context.Context, namespace, deploymentName string) (chan struct{}, chan error)
 	RestartDeployment(ctx context.Context, namespace, deploymentName string) error
+	//changes only the replicas, the pods are not restarted
+	ScaleDeployment(ctx context.Context, namespace, deploymentName string, replicas int32) error
+	//blocks until the last rollout of the deployment is completed, fails or the timeout is reached
+	WaitDeploymentRollout(ctx context.Context, namespace, deploymentName string, timeout time.Duration) error
+	//returns the pods of the deployment sorted from the newest to the oldest
+	ListDeploymentPods(ctx context.Context, namespace, deploymentName string) ([]*model.Pod, error)
+	//returns the last tailLines lines of the logs of the container, 0 returns all of them.
+	//previous returns the logs of the last terminated instance of the container
+	GetPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64, previous bool) (string, error)
+	//runs the command in a container of the pod attaching the streams, blocks until it exits
+	ExecInPod(ctx context.Context, namespace, podName, containerName string, command []string, streams model.ExecStreams) error
+	//forwards the connection to the port of the pod, blocks until either side closes it
+	PortForwardPod(ctx context.Context, namespace, podName string, port int, conn io.ReadWriter) error
 	//! review
 	// GetRevisions(ctx context.Context, namespace, deploymentName string) ([]model.Deployment, error)
 	// RollbackDeployment(ctx context.Context, namespace, deploymentName string, revision int64) error
@@ -69,7 +99,42 @@ type OrchestratedServiceManager interface {
 	//gracePeriod in seconds, if 0 it will be deleted immediately, if negative it will be deleted after the default grace period
 	DeleteConfigMap(ctx context.Context, namespace, configMapName string, gracePeriod int64) error
 
+	//*secrets
+	GetSecret(ctx context.Context, namespace, secretName string) (*model.Secret, error)
+	CreateNewSecret(ctx context.Context, namespace, secretName string, data, labels []model.KeyValue) (*model.Secret, error)
+	//replaces all the data of the secret with the new one
+	UpdateSecret(ctx context.Context, namespace, secretName string, data []model.KeyValue) (*model.Secret, error)
+	//gracePeriod in seconds, if 0 it will be deleted immediately, if negative it will be deleted after the default grace period
+	DeleteSecret(ctx context.Context, namespace, secretName string, gracePeriod int64) error
+
+	//*jobs and cron jobs
+	//every job container gets the JOB_NAME env with the name of the job
+	GetJob(ctx context.Context, namespace, jobName string) (*model.Job, error)
+	//returns the jobs having all the labels, jobs created by cron jobs inherit their labels
+	ListJobs(ctx context.Context, namespace string, labels []model.KeyValue) ([]*model.Job, error)
+	CreateNewJob(ctx context.Context, namespace, jobName string, spec model.JobSpec, labels []model.KeyValue) (*model.Job, error)
+	//gracePeriod in seconds, if 0 it will be deleted immediately, if negative it will be deleted after the default grace period
+	DeleteJob(ctx context.Context, namespace, jobName string, gracePeriod int64) error
+	//historyLimit is the number of finished jobs kept by the cron job
+	CreateNewCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec, historyLimit int32, labels []model.KeyValue) (*model.CronJob, error)
+	UpdateCronJobSchedule(ctx context.Context, namespace, cronJobName, schedule string) (*model.CronJob, error)
+	//replaces the schedule and the spec of the next jobs, the running one is not affected
+	UpdateCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec) (*model.CronJob, error)
+	//gracePeriod in seconds, if 0 it will be deleted immediately, if negative it will be deleted after the default grace period
+	DeleteCronJob(ctx context.Context, namespace, cronJobName string, gracePeriod int64) error
+	//returns the last tailLines lines of the logs of the container in the newest pod of the job, 0 returns all of them
+	GetJobLogs(ctx context.Context, namespace, jobName, containerName string, tailLines int64) (string, error)
+
 	//*pv and pvc
 	CreateNewPersistentVolumeClaim(ctx context.Context, namespace, pvcName, storageClassName string, storageSize int64, labels []model.KeyValue) (*model.PersistentVolumeClaim, error)
+	//the size can only be increased and the storage class must allow volume expansion
+	ResizePersistentVolumeClaim(ctx context.Context, namespace, pvcName string, storageSize int64) (*model.PersistentVolumeClaim, error)
+	//returns the bytes used by each pvc of the namespace mounted by a running pod
+	GetPersistentVolumeClaimsUsage(ctx context.Context, namespace string) (map[string]int64, error)
 	DeletePersistantVolumeClmain(ctx context.Context, namespace, pvcName string, gracePeriod int64) error
+
+	//*managed resources
+	//returns the deployments, services, ingress routes, config maps, secrets and pvcs of the applications in every namespace
+	ListManagedResources(ctx context.Context) ([]*model.ManagedResource, error)
+	DeleteManagedResource(ctx context.Context, resource *model.ManagedResource, gracePeriod int64) error
 }
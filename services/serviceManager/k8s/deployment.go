@@ -3,16 +3,24 @@ package k8smanager
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
 func convertK8sDeploymentToModelDeployment(deployment *appsv1.Deployment) *model.Deployment {
+	//containers without ports (workers) have port 0
+	port := int32(0)
+	if ports := deployment.Spec.Template.Spec.Containers[0].Ports; len(ports) > 0 {
+		port = ports[0].ContainerPort
+	}
 	return &model.Deployment{
 		BaseResource: model.BaseResource{
 			Name:      deployment.Name,
@@ -23,7 +31,84 @@ func convertK8sDeploymentToModelDeployment(deployment *appsv1.Deployment) *model
 		ImageRegistry: deployment.Spec.Template.Spec.Containers[0].Image,
 		CpuLimits:     deployment.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String(),
 		MemoryLimits:  deployment.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().String(),
-		Port:          deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort,
+		Port:          port,
+	}
+}
+
+// a port of 0 means the container does not listen on any port
+func containerPorts(port int32) []corev1.ContainerPort {
+	if port == 0 {
+		return nil
+	}
+	return []corev1.ContainerPort{{
+		ContainerPort: port,
+	}}
+}
+
+// deployments with a persistent volume can not run two pods at the same time
+// as the volume is ReadWriteOnce, so they are recreated instead of rolled
+func (k K8sOrchestratedServiceManager) deploymentStrategy(podSpec corev1.PodSpec) appsv1.DeploymentStrategy {
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			}
+		}
+	}
+	return appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: k.rollingUpdate,
+	}
+}
+
+// converts the env sources in the container envFrom, the order is kept so
+// the keys of the last sources take precedence over the first ones
+func convertModelEnvSourcesToK8sEnvFrom(envSources []model.EnvSource) []corev1.EnvFromSource {
+	envFrom := make([]corev1.EnvFromSource, 0, len(envSources))
+	for _, source := range envSources {
+		switch source.Kind {
+		case model.EnvSourceKindConfigMap:
+			envFrom = append(envFrom, corev1.EnvFromSource{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: source.Name,
+					},
+				},
+			})
+		case model.EnvSourceKindSecret:
+			envFrom = append(envFrom, corev1.EnvFromSource{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: source.Name,
+					},
+				},
+			})
+		}
+	}
+	return envFrom
+}
+
+// replaces the volumes of the pod and the mounts of its container, volumes
+// without a persistent volume claim are skipped
+func applyVolumesToPodSpec(podSpec *corev1.PodSpec, volumes []*model.Volume) {
+	podSpec.Volumes = make([]corev1.Volume, 0, len(volumes))
+	podSpec.Containers[0].VolumeMounts = make([]corev1.VolumeMount, 0, len(volumes))
+	for _, volume := range volumes {
+		if volume == nil || volume.PersistantVolumeClaim == nil {
+			continue
+		}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: volume.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: volume.PersistantVolumeClaim.Name,
+				},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+		})
 	}
 }
 
@@ -36,7 +121,37 @@ func (k K8sOrchestratedServiceManager) GetDeployment(ctx context.Context, namesp
 	return convertK8sDeploymentToModelDeployment(deployment), nil
 }
 
-func (k K8sOrchestratedServiceManager) CreateNewDeployment(ctx context.Context, namespace, deploymentName, app, imageRegistry string, replicas, port int32, labels []model.KeyValue, configMapName string, volume *model.Volume) (*model.Deployment, error) {
+// returns the limits of a container, empty values use the default ones
+func (k K8sOrchestratedServiceManager) containerLimits(resources *model.ContainerResources) (corev1.ResourceList, error) {
+	limits := corev1.ResourceList{
+		corev1.ResourceCPU:    k.cpuResource,
+		corev1.ResourceMemory: k.memoryResource,
+	}
+	if resources == nil {
+		return limits, nil
+	}
+	if resources.CPU != "" {
+		cpu, err := resource.ParseQuantity(resources.CPU)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu resource %q: %v", resources.CPU, err)
+		}
+		limits[corev1.ResourceCPU] = cpu
+	}
+	if resources.Memory != "" {
+		memory, err := resource.ParseQuantity(resources.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory resource %q: %v", resources.Memory, err)
+		}
+		limits[corev1.ResourceMemory] = memory
+	}
+	return limits, nil
+}
+
+func (k K8sOrchestratedServiceManager) CreateNewDeployment(ctx context.Context, namespace, deploymentName, app, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, volumes []*model.Volume, healthChecks *model.HealthChecks, resources *model.ContainerResources) (*model.Deployment, error) {
+	limits, err := k.containerLimits(resources)
+	if err != nil {
+		return nil, err
+	}
 	k8sLabels := convertModelDataToK8sData(labels)
 	if k8sLabels[model.AppLabel] == "" {
 		k8sLabels[model.AppLabel] = app
@@ -70,54 +185,35 @@ func (k K8sOrchestratedServiceManager) CreateNewDeployment(ctx context.Context,
 							Name:  app,
 							Image: imageRegistry,
 							Resources: corev1.ResourceRequirements{
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    k.cpuResource,
-									corev1.ResourceMemory: k.memoryResource,
-								}},
-							EnvFrom: []corev1.EnvFromSource{
-								{
-									ConfigMapRef: &corev1.ConfigMapEnvSource{
-										LocalObjectReference: corev1.LocalObjectReference{
-											Name: configMapName,
-										},
-									}}},
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: port,
-								}},
+								Limits: limits,
+							},
+							Ports: containerPorts(port),
 						},
 					},
 				},
 			},
 		}}
-	if volume != nil {
-		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{
-			{
-				Name: volume.Name,
-				VolumeSource: corev1.VolumeSource{
-					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: volume.PersistantVolumeClaim.Name,
-					},
-				},
-			}}
-		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{
-				Name:      volume.Name,
-				MountPath: volume.MountPath,
-			},
-		}
-	}
+	deployment.Spec.Template.Spec.Containers[0].EnvFrom = convertModelEnvSourcesToK8sEnvFrom(envSources)
+	applyHealthChecksToContainer(&deployment.Spec.Template.Spec.Containers[0], healthChecks, port)
+	applyVolumesToPodSpec(&deployment.Spec.Template.Spec, volumes)
+
+	deployment.Spec.Strategy = k.deploymentStrategy(deployment.Spec.Template.Spec)
+	deployment.Spec.ProgressDeadlineSeconds = &k.progressDeadlineSeconds
 
 	createdDeployment, err := k.clientset.AppsV1().Deployments(namespace).
 		Create(ctx, deployment, metav1.CreateOptions{})
 	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
 		return nil, fmt.Errorf("error creating deployment: %v", err)
 	}
 
 	return convertK8sDeploymentToModelDeployment(createdDeployment), nil
 }
 
-func (k K8sOrchestratedServiceManager) UpdateDeployment(ctx context.Context, namespace, deploymentName string, imageRegistry string, replicas, port int32, labels []model.KeyValue, configMapName string) (*model.Deployment, error) {
+// if envSources or healthChecks are nil the current ones are kept
+func (k K8sOrchestratedServiceManager) UpdateDeployment(ctx context.Context, namespace, deploymentName string, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, healthChecks *model.HealthChecks) (*model.Deployment, error) {
 	deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("error getting deployment: %v", err)
@@ -125,30 +221,72 @@ func (k K8sOrchestratedServiceManager) UpdateDeployment(ctx context.Context, nam
 
 	deployment.Spec.Replicas = &replicas
 	deployment.Spec.Template.Spec.Containers[0].Image = imageRegistry
-	deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = port
-	if configMapName != "" {
-		deployment.Spec.Template.Spec.Containers[0].EnvFrom = []corev1.EnvFromSource{
-			{
-				ConfigMapRef: &corev1.ConfigMapEnvSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: configMapName,
-					},
-				},
-			}}
+	deployment.Spec.Template.Spec.Containers[0].Ports = containerPorts(port)
+	if envSources != nil {
+		deployment.Spec.Template.Spec.Containers[0].EnvFrom = convertModelEnvSourcesToK8sEnvFrom(envSources)
 	}
 
+	applyHealthChecksToContainer(&deployment.Spec.Template.Spec.Containers[0], healthChecks, port)
+	deployment.Spec.Strategy = k.deploymentStrategy(deployment.Spec.Template.Spec)
+	deployment.Spec.ProgressDeadlineSeconds = &k.progressDeadlineSeconds
+
 	k8sLabels := convertModelDataToK8sData(labels)
 	deployment.Labels = k8sLabels
 	deployment.Spec.Template.Labels = k8sLabels
 
 	updatedDeployment, err := k.clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
 	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
 		return nil, fmt.Errorf("error updating deployment: %v", err)
 	}
 
 	return convertK8sDeploymentToModelDeployment(updatedDeployment), nil
 }
 
+// replaces all the volumes of the deployment, the strategy is updated as
+// deployments with persistent volumes must be recreated
+func (k K8sOrchestratedServiceManager) UpdateDeploymentVolumes(ctx context.Context, namespace, deploymentName string, volumes []*model.Volume) (*model.Deployment, error) {
+	deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting deployment: %v", err)
+	}
+
+	applyVolumesToPodSpec(&deployment.Spec.Template.Spec, volumes)
+	deployment.Spec.Strategy = k.deploymentStrategy(deployment.Spec.Template.Spec)
+
+	updatedDeployment, err := k.clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating deployment volumes: %v", err)
+	}
+
+	return convertK8sDeploymentToModelDeployment(updatedDeployment), nil
+}
+
+// replaces the limits of the deployment container, nil resets them to the default ones
+func (k K8sOrchestratedServiceManager) UpdateDeploymentResources(ctx context.Context, namespace, deploymentName string, resources *model.ContainerResources) (*model.Deployment, error) {
+	limits, err := k.containerLimits(resources)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting deployment: %v", err)
+	}
+
+	deployment.Spec.Template.Spec.Containers[0].Resources.Limits = limits
+	updatedDeployment, err := k.clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
+		return nil, fmt.Errorf("error updating deployment resources: %v", err)
+	}
+
+	return convertK8sDeploymentToModelDeployment(updatedDeployment), nil
+}
+
 func (k K8sOrchestratedServiceManager) DeleteDeployment(ctx context.Context, namespace, deploymentName string, gracePeriod int64) error {
 	grace := &gracePeriod
 	if gracePeriod < 0 {
@@ -172,6 +310,17 @@ func (k K8sOrchestratedServiceManager) RestartDeployment(ctx context.Context, na
 	return nil
 }
 
+// only changes the number of replicas of the deployment, the pod template is
+// left untouched so no rollout is triggered
+func (k K8sOrchestratedServiceManager) ScaleDeployment(ctx context.Context, namespace, deploymentName string, replicas int32) error {
+	_, err := k.clientset.AppsV1().Deployments(namespace).Patch(ctx,
+		deploymentName, types.MergePatchType, []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)), metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error scaling deployment: %v", err)
+	}
+	return nil
+}
+
 func (k K8sOrchestratedServiceManager) WaitDeploymentReadyState(ctx context.Context, namespace, deploymentName string) (chan struct{}, chan error) {
 	done := make(chan struct{})
 	errChan := make(chan error)
@@ -202,6 +351,15 @@ func (k K8sOrchestratedServiceManager) WaitDeploymentReadyState(ctx context.Cont
 					errChan <- fmt.Errorf("error casting object to deployment")
 				}
 				for _, condition := range deployment.Status.Conditions {
+					//pods rejected by the namespace quota never show up, the replica set
+					//reports the failure on the deployment conditions
+					if condition.Type == appsv1.DeploymentReplicaFailure &&
+						condition.Status == corev1.ConditionTrue &&
+						isQuotaExceededMessage(condition.Message) {
+						deploymentWatch.Stop()
+						errChan <- fmt.Errorf("%w: %s", serviceManager.ErrQuotaExceeded, condition.Message)
+						return
+					}
 					if condition.Type == appsv1.DeploymentAvailable &&
 						condition.Status == corev1.ConditionTrue &&
 						condition.Reason == "MinimumReplicasAvailable" {
@@ -215,3 +373,65 @@ func (k K8sOrchestratedServiceManager) WaitDeploymentReadyState(ctx context.Cont
 	}()
 	return done, errChan
 }
+
+// checks the rollout status the same way kubectl rollout status does,
+// returns true when all the replicas are updated and available
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (bool, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing &&
+			condition.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Errorf("%w: %s", serviceManager.ErrRolloutFailed, condition.Message)
+		}
+		if condition.Type == appsv1.DeploymentReplicaFailure &&
+			condition.Status == corev1.ConditionTrue &&
+			isQuotaExceededMessage(condition.Message) {
+			return false, fmt.Errorf("%w: %s", serviceManager.ErrQuotaExceeded, condition.Message)
+		}
+	}
+	if deployment.Spec.Replicas != nil && deployment.Status.UpdatedReplicas < *deployment.Spec.Replicas {
+		return false, nil
+	}
+	//old replicas are still being terminated
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return false, nil
+	}
+	if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
+		return false, nil
+	}
+	return true, nil
+}
+
+// blocks until the last rollout of the deployment is completed, fails, or the timeout is reached
+func (k K8sOrchestratedServiceManager) WaitDeploymentRollout(ctx context.Context, namespace, deploymentName string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: timeout reached", serviceManager.ErrRolloutFailed)
+			}
+			return fmt.Errorf("error getting deployment: %v", err)
+		}
+
+		done, err := deploymentRolloutStatus(deployment)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: timeout reached", serviceManager.ErrRolloutFailed)
+		case <-ticker.C:
+		}
+	}
+}
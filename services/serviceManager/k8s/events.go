@@ -1,22 +1,21 @@
 package k8smanager
 
 import (
-	"context"
+	"time"
 
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/watch"
+	"github.com/ipaas-org/ipaas-backend/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
 )
 
-func (k *K8sOrchestratedServiceManager) GetEventsChan(ctx context.Context) (watch.Interface, error) {
-	// initialEvents := true
-	watcher, err := k.clientset.CoreV1().Pods("").Watch(ctx,
-		v1.ListOptions{
-			AllowWatchBookmarks: true,
-			// SendInitialEvents:   &initialEvents,
-			LabelSelector: "ipaasManaged=true",
-		})
-	if err != nil {
-		return nil, err
-	}
-	return watcher, nil
+// returns an informer factory limited to the resources managed by ipaas in every
+// namespace, the informers list the resources before watching them and relist
+// from the last resource version when the watch is closed. Every resync period
+// all the cached resources are delivered again as updates
+func (k *K8sOrchestratedServiceManager) NewInformerFactory(resync time.Duration) informers.SharedInformerFactory {
+	return informers.NewSharedInformerFactoryWithOptions(k.clientset, resync,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = model.IpaasManagedLabel + "=true"
+			options.AllowWatchBookmarks = true
+		}))
 }
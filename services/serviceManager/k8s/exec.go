@@ -0,0 +1,62 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// adapts the resize channel to the queue read by the executor
+type terminalSizeQueue <-chan model.TerminalSize
+
+func (q terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q
+	if !ok {
+		return nil
+	}
+	return &remotecommand.TerminalSize{Width: size.Width, Height: size.Height}
+}
+
+// runs the command in the container through the exec subresource of the pod, if
+// containerName is empty the only container of the pod is used. Blocks until the
+// command exits or the context is cancelled
+func (k K8sOrchestratedServiceManager) ExecInPod(ctx context.Context, namespace, podName, containerName string, command []string, streams model.ExecStreams) error {
+	req := k.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil && !streams.Tty,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(k.kubeConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("error creating executor: %v", err)
+	}
+
+	options := remotecommand.StreamOptions{
+		Stdin:  streams.Stdin,
+		Stdout: streams.Stdout,
+		Tty:    streams.Tty,
+	}
+	if !streams.Tty {
+		options.Stderr = streams.Stderr
+	}
+	if streams.Resize != nil {
+		options.TerminalSizeQueue = terminalSizeQueue(streams.Resize)
+	}
+	if err := executor.StreamWithContext(ctx, options); err != nil {
+		return fmt.Errorf("error streaming exec: %v", err)
+	}
+	return nil
+}
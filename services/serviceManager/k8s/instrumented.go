@@ -0,0 +1,359 @@
+package k8smanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/pkg/metrics"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+)
+
+var _ serviceManager.OrchestratedServiceManager = new(InstrumentedK8sOrchestratedServiceManager)
+
+// records the duration and the errors of every call to the service manager,
+// the methods not in OrchestratedServiceManager and the long lived streams
+// (exec, port forward and the ready state watch) are not instrumented
+type InstrumentedK8sOrchestratedServiceManager struct {
+	*K8sOrchestratedServiceManager
+}
+
+func NewInstrumentedK8sOrchestratedServiceManager(k *K8sOrchestratedServiceManager) *InstrumentedK8sOrchestratedServiceManager {
+	return &InstrumentedK8sOrchestratedServiceManager{K8sOrchestratedServiceManager: k}
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewNamespace(ctx context.Context, namespace string, labels []model.KeyValue) (*model.BaseResource, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewNamespace(ctx, namespace, labels)
+	metrics.ObserveK8sCall("CreateNewNamespace", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteNamespace(ctx context.Context, namespace string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteNamespace(ctx, namespace, gracePeriod)
+	metrics.ObserveK8sCall("DeleteNamespace", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewRegistrySecret(ctx context.Context, namespace, registryUrl, username, password string) (string, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewRegistrySecret(ctx, namespace, registryUrl, username, password)
+	metrics.ObserveK8sCall("CreateNewRegistrySecret", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetResourceQuota(ctx context.Context, namespace, quotaName string) (*model.ResourceQuota, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetResourceQuota(ctx, namespace, quotaName)
+	metrics.ObserveK8sCall("GetResourceQuota", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewResourceQuota(ctx context.Context, namespace, quotaName string, hard model.QuotaResources, labels []model.KeyValue) (*model.ResourceQuota, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewResourceQuota(ctx, namespace, quotaName, hard, labels)
+	metrics.ObserveK8sCall("CreateNewResourceQuota", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewLimitRange(ctx context.Context, namespace, limitRangeName, defaultCPU, defaultMemory, maxCPU, maxMemory string, labels []model.KeyValue) (*model.LimitRange, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewLimitRange(ctx, namespace, limitRangeName, defaultCPU, defaultMemory, maxCPU, maxMemory, labels)
+	metrics.ObserveK8sCall("CreateNewLimitRange", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetDeployment(ctx context.Context, namespace, deploymentName string) (*model.Deployment, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetDeployment(ctx, namespace, deploymentName)
+	metrics.ObserveK8sCall("GetDeployment", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewDeployment(ctx context.Context, namespace, deploymentName, app, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, volumes []*model.Volume, healthChecks *model.HealthChecks, resources *model.ContainerResources) (*model.Deployment, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewDeployment(ctx, namespace, deploymentName, app, imageRegistry, replicas, port, labels, envSources, volumes, healthChecks, resources)
+	metrics.ObserveK8sCall("CreateNewDeployment", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateDeployment(ctx context.Context, namespace, deploymentName, imageRegistry string, replicas, port int32, labels []model.KeyValue, envSources []model.EnvSource, healthChecks *model.HealthChecks) (*model.Deployment, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateDeployment(ctx, namespace, deploymentName, imageRegistry, replicas, port, labels, envSources, healthChecks)
+	metrics.ObserveK8sCall("UpdateDeployment", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateDeploymentVolumes(ctx context.Context, namespace, deploymentName string, volumes []*model.Volume) (*model.Deployment, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateDeploymentVolumes(ctx, namespace, deploymentName, volumes)
+	metrics.ObserveK8sCall("UpdateDeploymentVolumes", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateDeploymentResources(ctx context.Context, namespace, deploymentName string, resources *model.ContainerResources) (*model.Deployment, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateDeploymentResources(ctx, namespace, deploymentName, resources)
+	metrics.ObserveK8sCall("UpdateDeploymentResources", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteDeployment(ctx context.Context, namespace, deploymentName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteDeployment(ctx, namespace, deploymentName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteDeployment", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) RestartDeployment(ctx context.Context, namespace, deploymentName string) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.RestartDeployment(ctx, namespace, deploymentName)
+	metrics.ObserveK8sCall("RestartDeployment", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) ScaleDeployment(ctx context.Context, namespace, deploymentName string, replicas int32) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.ScaleDeployment(ctx, namespace, deploymentName, replicas)
+	metrics.ObserveK8sCall("ScaleDeployment", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) WaitDeploymentRollout(ctx context.Context, namespace, deploymentName string, timeout time.Duration) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.WaitDeploymentRollout(ctx, namespace, deploymentName, timeout)
+	metrics.ObserveK8sCall("WaitDeploymentRollout", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) ListDeploymentPods(ctx context.Context, namespace, deploymentName string) ([]*model.Pod, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.ListDeploymentPods(ctx, namespace, deploymentName)
+	metrics.ObserveK8sCall("ListDeploymentPods", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64, previous bool) (string, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetPodLogs(ctx, namespace, podName, containerName, tailLines, previous)
+	metrics.ObserveK8sCall("GetPodLogs", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetService(ctx context.Context, namespace, serviceName string) (*model.Service, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetService(ctx, namespace, serviceName)
+	metrics.ObserveK8sCall("GetService", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewService(ctx context.Context, namespace, serviceName, app string, port int32, labels []model.KeyValue) (*model.Service, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewService(ctx, namespace, serviceName, app, port, labels)
+	metrics.ObserveK8sCall("CreateNewService", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateService(ctx context.Context, namespace, serviceName string, port int32) (*model.Service, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateService(ctx, namespace, serviceName, port)
+	metrics.ObserveK8sCall("UpdateService", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteService(ctx context.Context, namespace, serviceName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteService(ctx, namespace, serviceName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteService", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetIngressRoute(ctx context.Context, namespace, ingressRouteName string) (*model.IngressRoute, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetIngressRoute(ctx, namespace, ingressRouteName)
+	metrics.ObserveK8sCall("GetIngressRoute", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewIngressRoute(ctx context.Context, namespace, ingressRouteName, match, serviceName string, listeningPort int32, labels []model.KeyValue) (*model.IngressRoute, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewIngressRoute(ctx, namespace, ingressRouteName, match, serviceName, listeningPort, labels)
+	metrics.ObserveK8sCall("CreateNewIngressRoute", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateIngressRoute(ctx context.Context, namespace, ingressRouteName, newMatch string, newPort int32) (*model.IngressRoute, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateIngressRoute(ctx, namespace, ingressRouteName, newMatch, newPort)
+	metrics.ObserveK8sCall("UpdateIngressRoute", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteIngressRoute(ctx context.Context, namespace, ingressRouteName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteIngressRoute(ctx, namespace, ingressRouteName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteIngressRoute", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetConfigMap(ctx context.Context, namespace, configMapName string) (*model.ConfigMap, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetConfigMap(ctx, namespace, configMapName)
+	metrics.ObserveK8sCall("GetConfigMap", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewConfigMap(ctx context.Context, namespace, configMapName string, data, labels []model.KeyValue) (*model.ConfigMap, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewConfigMap(ctx, namespace, configMapName, data, labels)
+	metrics.ObserveK8sCall("CreateNewConfigMap", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateConfigMap(ctx context.Context, namespace, configMapName string, data []model.KeyValue) (*model.ConfigMap, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateConfigMap(ctx, namespace, configMapName, data)
+	metrics.ObserveK8sCall("UpdateConfigMap", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteConfigMap(ctx context.Context, namespace, configMapName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteConfigMap(ctx, namespace, configMapName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteConfigMap", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetSecret(ctx context.Context, namespace, secretName string) (*model.Secret, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetSecret(ctx, namespace, secretName)
+	metrics.ObserveK8sCall("GetSecret", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewSecret(ctx context.Context, namespace, secretName string, data, labels []model.KeyValue) (*model.Secret, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewSecret(ctx, namespace, secretName, data, labels)
+	metrics.ObserveK8sCall("CreateNewSecret", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateSecret(ctx context.Context, namespace, secretName string, data []model.KeyValue) (*model.Secret, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateSecret(ctx, namespace, secretName, data)
+	metrics.ObserveK8sCall("UpdateSecret", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteSecret(ctx context.Context, namespace, secretName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteSecret(ctx, namespace, secretName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteSecret", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetJob(ctx context.Context, namespace, jobName string) (*model.Job, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetJob(ctx, namespace, jobName)
+	metrics.ObserveK8sCall("GetJob", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) ListJobs(ctx context.Context, namespace string, labels []model.KeyValue) ([]*model.Job, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.ListJobs(ctx, namespace, labels)
+	metrics.ObserveK8sCall("ListJobs", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewJob(ctx context.Context, namespace, jobName string, spec model.JobSpec, labels []model.KeyValue) (*model.Job, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewJob(ctx, namespace, jobName, spec, labels)
+	metrics.ObserveK8sCall("CreateNewJob", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteJob(ctx context.Context, namespace, jobName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteJob(ctx, namespace, jobName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteJob", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec, historyLimit int32, labels []model.KeyValue) (*model.CronJob, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewCronJob(ctx, namespace, cronJobName, schedule, spec, historyLimit, labels)
+	metrics.ObserveK8sCall("CreateNewCronJob", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateCronJobSchedule(ctx context.Context, namespace, cronJobName, schedule string) (*model.CronJob, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateCronJobSchedule(ctx, namespace, cronJobName, schedule)
+	metrics.ObserveK8sCall("UpdateCronJobSchedule", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) UpdateCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec) (*model.CronJob, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.UpdateCronJob(ctx, namespace, cronJobName, schedule, spec)
+	metrics.ObserveK8sCall("UpdateCronJob", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteCronJob(ctx context.Context, namespace, cronJobName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteCronJob(ctx, namespace, cronJobName, gracePeriod)
+	metrics.ObserveK8sCall("DeleteCronJob", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetJobLogs(ctx context.Context, namespace, jobName, containerName string, tailLines int64) (string, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetJobLogs(ctx, namespace, jobName, containerName, tailLines)
+	metrics.ObserveK8sCall("GetJobLogs", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) CreateNewPersistentVolumeClaim(ctx context.Context, namespace, pvcName, storageClassName string, storageSize int64, labels []model.KeyValue) (*model.PersistentVolumeClaim, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.CreateNewPersistentVolumeClaim(ctx, namespace, pvcName, storageClassName, storageSize, labels)
+	metrics.ObserveK8sCall("CreateNewPersistentVolumeClaim", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) ResizePersistentVolumeClaim(ctx context.Context, namespace, pvcName string, storageSize int64) (*model.PersistentVolumeClaim, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.ResizePersistentVolumeClaim(ctx, namespace, pvcName, storageSize)
+	metrics.ObserveK8sCall("ResizePersistentVolumeClaim", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) GetPersistentVolumeClaimsUsage(ctx context.Context, namespace string) (map[string]int64, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.GetPersistentVolumeClaimsUsage(ctx, namespace)
+	metrics.ObserveK8sCall("GetPersistentVolumeClaimsUsage", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeletePersistantVolumeClmain(ctx context.Context, namespace, pvcName string, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeletePersistantVolumeClmain(ctx, namespace, pvcName, gracePeriod)
+	metrics.ObserveK8sCall("DeletePersistantVolumeClmain", start, err)
+	return err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) ListManagedResources(ctx context.Context) ([]*model.ManagedResource, error) {
+	start := time.Now()
+	result, err := i.K8sOrchestratedServiceManager.ListManagedResources(ctx)
+	metrics.ObserveK8sCall("ListManagedResources", start, err)
+	return result, err
+}
+
+func (i *InstrumentedK8sOrchestratedServiceManager) DeleteManagedResource(ctx context.Context, resource *model.ManagedResource, gracePeriod int64) error {
+	start := time.Now()
+	err := i.K8sOrchestratedServiceManager.DeleteManagedResource(ctx, resource, gracePeriod)
+	metrics.ObserveK8sCall("DeleteManagedResource", start, err)
+	return err
+}
@@ -0,0 +1,340 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const jobSharedVolumeName = "shared"
+
+func convertK8sJobToModelJob(job *batchv1.Job) *model.Job {
+	j := &model.Job{
+		BaseResource: model.BaseResource{
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Labels:    convertK8sDataToModelData(job.Labels),
+		},
+		Status: model.JobStatusPending,
+	}
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			j.CronJobName = owner.Name
+		}
+	}
+	if job.Status.StartTime != nil {
+		j.StartedAt = job.Status.StartTime.Time
+	}
+	if job.Status.Active > 0 {
+		j.Status = model.JobStatusRunning
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			j.Status = model.JobStatusSucceeded
+			j.FinishedAt = condition.LastTransitionTime.Time
+		case batchv1.JobFailed:
+			j.Status = model.JobStatusFailed
+			j.FinishedAt = condition.LastTransitionTime.Time
+		}
+	}
+	return j
+}
+
+func convertK8sCronJobToModelCronJob(cronJob *batchv1.CronJob) *model.CronJob {
+	return &model.CronJob{
+		BaseResource: model.BaseResource{
+			Name:      cronJob.Name,
+			Namespace: cronJob.Namespace,
+			Labels:    convertK8sDataToModelData(cronJob.Labels),
+		},
+		Schedule: cronJob.Spec.Schedule,
+	}
+}
+
+// every container gets the JOB_NAME env with the name of the job, jobs created
+// by a cron job have a generated name so it's the only way to know it
+func (k K8sOrchestratedServiceManager) convertModelJobContainerToK8sContainer(container model.JobContainer, sharedDir string) corev1.Container {
+	envs := []corev1.EnvVar{{
+		Name: "JOB_NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "metadata.labels['" + batchv1.JobNameLabel + "']",
+			},
+		},
+	}}
+	for _, env := range container.Envs {
+		envs = append(envs, corev1.EnvVar{Name: env.Key, Value: env.Value})
+	}
+	c := corev1.Container{
+		Name:    container.Name,
+		Image:   container.Image,
+		Command: container.Command,
+		Env:     envs,
+		EnvFrom: convertModelEnvSourcesToK8sEnvFrom(container.EnvSources),
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    k.cpuResource,
+				corev1.ResourceMemory: k.memoryResource,
+			}},
+	}
+	if sharedDir != "" {
+		c.VolumeMounts = []corev1.VolumeMount{{
+			Name:      jobSharedVolumeName,
+			MountPath: sharedDir,
+		}}
+	}
+	return c
+}
+
+func (k K8sOrchestratedServiceManager) convertModelJobSpecToK8sJobSpec(spec model.JobSpec, k8sLabels map[string]string) batchv1.JobSpec {
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		ImagePullSecrets: []corev1.LocalObjectReference{
+			{
+				Name: "registrypullsecret",
+			}},
+	}
+	for _, container := range spec.InitContainers {
+		podSpec.InitContainers = append(podSpec.InitContainers, k.convertModelJobContainerToK8sContainer(container, spec.SharedDir))
+	}
+	for _, container := range spec.Containers {
+		podSpec.Containers = append(podSpec.Containers, k.convertModelJobContainerToK8sContainer(container, spec.SharedDir))
+	}
+	if spec.SharedDir != "" {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: jobSharedVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	for _, volume := range spec.Volumes {
+		if volume == nil || volume.PersistantVolumeClaim == nil {
+			continue
+		}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: volume.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: volume.PersistantVolumeClaim.Name,
+				},
+			},
+		})
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      volume.Name,
+				MountPath: volume.MountPath,
+			})
+		}
+	}
+
+	backoffLimit := spec.BackoffLimit
+	jobSpec := batchv1.JobSpec{
+		BackoffLimit: &backoffLimit,
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: k8sLabels,
+			},
+			Spec: podSpec,
+		},
+	}
+	if spec.TTLSecondsAfterFinished > 0 {
+		ttl := spec.TTLSecondsAfterFinished
+		jobSpec.TTLSecondsAfterFinished = &ttl
+	}
+	return jobSpec
+}
+
+func (k K8sOrchestratedServiceManager) GetJob(ctx context.Context, namespace, jobName string) (*model.Job, error) {
+	job, err := k.clientset.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return nil, fmt.Errorf("error getting job: %v", err)
+	}
+	return convertK8sJobToModelJob(job), nil
+}
+
+// returns the jobs having all the labels, jobs created by cron jobs inherit their labels
+func (k K8sOrchestratedServiceManager) ListJobs(ctx context.Context, namespace string, labels []model.KeyValue) ([]*model.Job, error) {
+	selector := make([]string, 0, len(labels))
+	for _, label := range labels {
+		selector = append(selector, label.Key+"="+label.Value)
+	}
+	jobs, err := k.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: strings.Join(selector, ","),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %v", err)
+	}
+	modelJobs := make([]*model.Job, 0, len(jobs.Items))
+	for i := range jobs.Items {
+		modelJobs = append(modelJobs, convertK8sJobToModelJob(&jobs.Items[i]))
+	}
+	return modelJobs, nil
+}
+
+func (k K8sOrchestratedServiceManager) CreateNewJob(ctx context.Context, namespace, jobName string, spec model.JobSpec, labels []model.KeyValue) (*model.Job, error) {
+	k8sLabels := convertModelDataToK8sData(labels)
+	job, err := k.clientset.BatchV1().Jobs(namespace).Create(ctx,
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   jobName,
+				Labels: k8sLabels,
+			},
+			Spec: k.convertModelJobSpecToK8sJobSpec(spec, k8sLabels),
+		}, metav1.CreateOptions{})
+	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
+		return nil, fmt.Errorf("error creating job: %v", err)
+	}
+	return convertK8sJobToModelJob(job), nil
+}
+
+func (k K8sOrchestratedServiceManager) DeleteJob(ctx context.Context, namespace, jobName string, gracePeriod int64) error {
+	grace := &gracePeriod
+	if gracePeriod < 0 {
+		grace = nil
+	}
+	//the pods of the job are not deleted by default
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().Jobs(namespace).Delete(ctx, jobName, metav1.DeleteOptions{
+		GracePeriodSeconds: grace,
+		PropagationPolicy:  &propagation,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting job: %v", err)
+	}
+	return nil
+}
+
+// the cron job never runs two jobs at the same time, historyLimit finished jobs are kept
+func (k K8sOrchestratedServiceManager) CreateNewCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec, historyLimit int32, labels []model.KeyValue) (*model.CronJob, error) {
+	k8sLabels := convertModelDataToK8sData(labels)
+	successfulHistory := historyLimit
+	failedHistory := historyLimit
+	cronJob, err := k.clientset.BatchV1().CronJobs(namespace).Create(ctx,
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   cronJobName,
+				Labels: k8sLabels,
+			},
+			Spec: batchv1.CronJobSpec{
+				Schedule:                   schedule,
+				ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+				SuccessfulJobsHistoryLimit: &successfulHistory,
+				FailedJobsHistoryLimit:     &failedHistory,
+				JobTemplate: batchv1.JobTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: k8sLabels,
+					},
+					Spec: k.convertModelJobSpecToK8sJobSpec(spec, k8sLabels),
+				},
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
+		return nil, fmt.Errorf("error creating cron job: %v", err)
+	}
+	return convertK8sCronJobToModelCronJob(cronJob), nil
+}
+
+func (k K8sOrchestratedServiceManager) UpdateCronJobSchedule(ctx context.Context, namespace, cronJobName, schedule string) (*model.CronJob, error) {
+	cronJob, err := k.clientset.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return nil, fmt.Errorf("error getting cron job: %v", err)
+	}
+	cronJob.Spec.Schedule = schedule
+	updatedCronJob, err := k.clientset.BatchV1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating cron job: %v", err)
+	}
+	return convertK8sCronJobToModelCronJob(updatedCronJob), nil
+}
+
+// replaces the schedule and the spec of the jobs created by the cron job, the
+// running job is not affected
+func (k K8sOrchestratedServiceManager) UpdateCronJob(ctx context.Context, namespace, cronJobName, schedule string, spec model.JobSpec) (*model.CronJob, error) {
+	cronJob, err := k.clientset.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return nil, fmt.Errorf("error getting cron job: %v", err)
+	}
+	cronJob.Spec.Schedule = schedule
+	cronJob.Spec.JobTemplate.Spec = k.convertModelJobSpecToK8sJobSpec(spec, cronJob.Spec.JobTemplate.Labels)
+	updatedCronJob, err := k.clientset.BatchV1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating cron job: %v", err)
+	}
+	return convertK8sCronJobToModelCronJob(updatedCronJob), nil
+}
+
+func (k K8sOrchestratedServiceManager) DeleteCronJob(ctx context.Context, namespace, cronJobName string, gracePeriod int64) error {
+	grace := &gracePeriod
+	if gracePeriod < 0 {
+		grace = nil
+	}
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().CronJobs(namespace).Delete(ctx, cronJobName, metav1.DeleteOptions{
+		GracePeriodSeconds: grace,
+		PropagationPolicy:  &propagation,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting cron job: %v", err)
+	}
+	return nil
+}
+
+// returns the last tailLines lines of the container logs of the newest pod of the
+// job, if tailLines is 0 all the logs are returned
+func (k K8sOrchestratedServiceManager) GetJobLogs(ctx context.Context, namespace, jobName, containerName string, tailLines int64) (string, error) {
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: batchv1.JobNameLabel + "=" + jobName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error listing job pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("%w: no pods for job %s", serviceManager.ErrResourceNotFound, jobName)
+	}
+	newest := &pods.Items[0]
+	for i := range pods.Items {
+		if pods.Items[i].CreationTimestamp.After(newest.CreationTimestamp.Time) {
+			newest = &pods.Items[i]
+		}
+	}
+
+	options := &corev1.PodLogOptions{Container: containerName}
+	if tailLines > 0 {
+		options.TailLines = &tailLines
+	}
+	logs, err := k.clientset.CoreV1().Pods(namespace).GetLogs(newest.Name, options).DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return "", fmt.Errorf("error getting job logs: %v", err)
+	}
+	return string(logs), nil
+}
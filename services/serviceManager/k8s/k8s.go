@@ -2,10 +2,15 @@ package k8smanager
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
 	traefikv "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/generated/clientset/versioned"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -14,14 +19,18 @@ import (
 var _ serviceManager.OrchestratedServiceManager = new(K8sOrchestratedServiceManager)
 
 type K8sOrchestratedServiceManager struct {
-	clientset      kubernetes.Interface
-	kubeConfig     *rest.Config
-	traefikClient  *traefikv.Clientset
-	cpuResource    resource.Quantity
-	memoryResource resource.Quantity
+	clientset               kubernetes.Interface
+	kubeConfig              *rest.Config
+	traefikClient           *traefikv.Clientset
+	cpuResource             resource.Quantity
+	memoryResource          resource.Quantity
+	rollingUpdate           *appsv1.RollingUpdateDeployment
+	progressDeadlineSeconds int32
 }
 
-func NewK8sOrchestratedServiceManager(kubeConfigPath, cpuResource, memoryResource string) (*K8sOrchestratedServiceManager, error) {
+// maxSurge and maxUnavailable are used by the rolling update strategy of the deployments,
+// if a rollout does not progress for longer than the progress deadline it's considered failed
+func NewK8sOrchestratedServiceManager(kubeConfigPath, cpuResource, memoryResource, maxSurge, maxUnavailable string, progressDeadline time.Duration) (*K8sOrchestratedServiceManager, error) {
 	var config *rest.Config
 	var err error
 	if kubeConfigPath == "inside" {
@@ -46,6 +55,19 @@ func NewK8sOrchestratedServiceManager(kubeConfigPath, cpuResource, memoryResourc
 		return nil, fmt.Errorf("error creating traefik client: %v", err)
 	}
 
+	manager, err := NewK8sOrchestratedServiceManagerForClientset(clientset, cpuResource, memoryResource, maxSurge, maxUnavailable, progressDeadline)
+	if err != nil {
+		return nil, err
+	}
+	manager.kubeConfig = config
+	manager.traefikClient = traefikClient
+	return manager, nil
+}
+
+// creates the manager on top of an existing clientset (ex: a fake one in the tests),
+// without the kube config and the traefik client the ingress routes, the exec in the
+// pods and the port forwards are not available
+func NewK8sOrchestratedServiceManagerForClientset(clientset kubernetes.Interface, cpuResource, memoryResource, maxSurge, maxUnavailable string, progressDeadline time.Duration) (*K8sOrchestratedServiceManager, error) {
 	cpuQuantity, err := resource.ParseQuantity(cpuResource)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing cpu resource: %v", err)
@@ -55,11 +77,28 @@ func NewK8sOrchestratedServiceManager(kubeConfigPath, cpuResource, memoryResourc
 	if err != nil {
 		return nil, fmt.Errorf("error parsing memory resource: %v", err)
 	}
+
+	surge := intstr.Parse(maxSurge)
+	unavailable := intstr.Parse(maxUnavailable)
+
 	return &K8sOrchestratedServiceManager{
 		clientset:      clientset,
-		kubeConfig:     config,
-		traefikClient:  traefikClient,
 		cpuResource:    cpuQuantity,
 		memoryResource: memoryQuantity,
+		rollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       &surge,
+			MaxUnavailable: &unavailable,
+		},
+		progressDeadlineSeconds: int32(progressDeadline.Seconds()),
 	}, nil
 }
+
+// k8s rejects requests exceeding a resource quota with a forbidden error,
+// the only way to distinguish it from other forbidden errors is the message
+func isQuotaExceededMessage(message string) bool {
+	return strings.Contains(message, "exceeded quota")
+}
+
+func isQuotaExceededError(err error) bool {
+	return apierrors.IsForbidden(err) && isQuotaExceededMessage(err.Error())
+}
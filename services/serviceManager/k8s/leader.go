@@ -0,0 +1,40 @@
+package k8smanager
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// returns a leader elector holding the lease name in namespace with identity,
+// the lease is released when the context of Run is cancelled so that another
+// instance can take over without waiting for it to expire
+func (k *K8sOrchestratedServiceManager) NewLeaderElector(namespace, name, identity string, leaseDuration, renewDeadline, retryPeriod time.Duration, callbacks leaderelection.LeaderCallbacks) (*leaderelection.LeaderElector, error) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Client: k.clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		Callbacks:       callbacks,
+		ReleaseOnCancel: true,
+		Name:            name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating leader elector: %v", err)
+	}
+	return elector, nil
+}
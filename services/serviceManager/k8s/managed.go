@@ -0,0 +1,101 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// every resource created for an application has both labels
+var managedApplicationSelector = model.IpaasManagedLabel + "=true," + model.AppIDLabel
+
+func newManagedResource(kind model.ManagedResourceKind, meta metav1.ObjectMeta) *model.ManagedResource {
+	return &model.ManagedResource{
+		Kind:          kind,
+		Name:          meta.Name,
+		Namespace:     meta.Namespace,
+		ApplicationID: meta.Labels[model.AppIDLabel],
+		Terminating:   meta.DeletionTimestamp != nil,
+		CreatedAt:     meta.CreationTimestamp.Time,
+	}
+}
+
+// lists the resources of the applications in every namespace, the namespace
+// level resources (quotas, env groups, ...) have no appID label and are not returned.
+// Jobs and cron jobs are left to their own handlers
+func (k *K8sOrchestratedServiceManager) ListManagedResources(ctx context.Context) ([]*model.ManagedResource, error) {
+	options := metav1.ListOptions{LabelSelector: managedApplicationSelector}
+	var resources []*model.ManagedResource
+
+	deployments, err := k.clientset.AppsV1().Deployments("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %v", err)
+	}
+	for _, deployment := range deployments.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindDeployment, deployment.ObjectMeta))
+	}
+
+	services, err := k.clientset.CoreV1().Services("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing services: %v", err)
+	}
+	for _, service := range services.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindService, service.ObjectMeta))
+	}
+
+	ingressRoutes, err := k.traefikClient.TraefikV1alpha1().IngressRoutes("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing ingress routes: %v", err)
+	}
+	for _, ingressRoute := range ingressRoutes.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindIngressRoute, ingressRoute.ObjectMeta))
+	}
+
+	configMaps, err := k.clientset.CoreV1().ConfigMaps("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing config maps: %v", err)
+	}
+	for _, configMap := range configMaps.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindConfigMap, configMap.ObjectMeta))
+	}
+
+	secrets, err := k.clientset.CoreV1().Secrets("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets: %v", err)
+	}
+	for _, secret := range secrets.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindSecret, secret.ObjectMeta))
+	}
+
+	pvcs, err := k.clientset.CoreV1().PersistentVolumeClaims("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing persistent volume claims: %v", err)
+	}
+	for _, pvc := range pvcs.Items {
+		resources = append(resources, newManagedResource(model.ManagedResourceKindPVC, pvc.ObjectMeta))
+	}
+
+	return resources, nil
+}
+
+// deletes a resource returned by ListManagedResources
+func (k *K8sOrchestratedServiceManager) DeleteManagedResource(ctx context.Context, resource *model.ManagedResource, gracePeriod int64) error {
+	switch resource.Kind {
+	case model.ManagedResourceKindDeployment:
+		return k.DeleteDeployment(ctx, resource.Namespace, resource.Name, gracePeriod)
+	case model.ManagedResourceKindService:
+		return k.DeleteService(ctx, resource.Namespace, resource.Name, gracePeriod)
+	case model.ManagedResourceKindIngressRoute:
+		return k.DeleteIngressRoute(ctx, resource.Namespace, resource.Name, gracePeriod)
+	case model.ManagedResourceKindConfigMap:
+		return k.DeleteConfigMap(ctx, resource.Namespace, resource.Name, gracePeriod)
+	case model.ManagedResourceKindSecret:
+		return k.DeleteSecret(ctx, resource.Namespace, resource.Name, gracePeriod)
+	case model.ManagedResourceKindPVC:
+		return k.DeletePersistantVolumeClmain(ctx, resource.Namespace, resource.Name, gracePeriod)
+	default:
+		return fmt.Errorf("unknown managed resource kind %s", resource.Kind)
+	}
+}
@@ -2,10 +2,84 @@ package k8smanager
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func (k *K8sOrchestratedServiceManager) DeletePod(ctx context.Context, namespace, podName string) error {
 	return k.clientset.CoreV1().Pods(namespace).Delete(ctx, podName, metav1.DeleteOptions{})
 }
+
+// returns the last tailLines lines of the logs of the container, 0 returns all of them.
+// If previous is true the logs of the last terminated instance of the container are returned
+func (k *K8sOrchestratedServiceManager) GetPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64, previous bool) (string, error) {
+	options := &corev1.PodLogOptions{Container: containerName, Previous: previous}
+	if tailLines > 0 {
+		options.TailLines = &tailLines
+	}
+	logs, err := k.clientset.CoreV1().Pods(namespace).GetLogs(podName, options).DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return "", fmt.Errorf("error getting pod logs: %v", err)
+	}
+	return string(logs), nil
+}
+
+func convertK8sPodToModelPod(pod *corev1.Pod) *model.Pod {
+	p := &model.Pod{
+		BaseResource: model.BaseResource{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels:    convertK8sDataToModelData(pod.Labels),
+		},
+		Phase:       string(pod.Status.Phase),
+		Terminating: pod.DeletionTimestamp != nil,
+		CreatedAt:   pod.CreationTimestamp.Time,
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			p.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	if len(pod.Status.ContainerStatuses) > 0 {
+		p.RestartCount = pod.Status.ContainerStatuses[0].RestartCount
+	}
+	return p
+}
+
+// returns the pods selected by the deployment, sorted from the newest to the oldest
+func (k *K8sOrchestratedServiceManager) ListDeploymentPods(ctx context.Context, namespace, deploymentName string) ([]*model.Pod, error) {
+	deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting deployment: %v", err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing deployment selector: %v", err)
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	modelPods := make([]*model.Pod, 0, len(pods.Items))
+	for i := range pods.Items {
+		modelPods = append(modelPods, convertK8sPodToModelPod(&pods.Items[i]))
+	}
+	sort.Slice(modelPods, func(i, j int) bool {
+		return modelPods[i].CreatedAt.After(modelPods[j].CreatedAt)
+	})
+	return modelPods, nil
+}
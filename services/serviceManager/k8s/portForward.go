@@ -0,0 +1,96 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// forwards a single connection to the port of the pod through the portforward
+// subresource, blocks until the pod closes the connection, conn reaches EOF
+// or the context is cancelled
+func (k K8sOrchestratedServiceManager) PortForwardPod(ctx context.Context, namespace, podName string, port int, conn io.ReadWriter) error {
+	transport, upgrader, err := spdy.RoundTripperFor(k.kubeConfig)
+	if err != nil {
+		return fmt.Errorf("error creating round tripper: %v", err)
+	}
+
+	req := k.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return fmt.Errorf("error dialing pod %s: %v", podName, err)
+	}
+	defer streamConn.Close()
+
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("error creating error stream: %v", err)
+	}
+	//the error stream is only read
+	errorStream.Close()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("error creating data stream: %v", err)
+	}
+	defer dataStream.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			errCh <- fmt.Errorf("error reading error stream: %v", err)
+		case len(message) > 0:
+			errCh <- fmt.Errorf("error forwarding port %d to pod %s: %s", port, podName, message)
+		default:
+			errCh <- nil
+		}
+	}()
+
+	remoteDone := make(chan struct{})
+	go func() {
+		defer close(remoteDone)
+		io.Copy(conn, dataStream)
+	}()
+
+	localDone := make(chan struct{})
+	go func() {
+		defer close(localDone)
+		io.Copy(dataStream, conn)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-remoteDone:
+	case <-localDone:
+	}
+
+	//the data stream is closed before waiting for the error stream, otherwise
+	//the kubelet keeps the forwarding open
+	dataStream.Close()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,63 @@
+package k8smanager
+
+import (
+	"github.com/ipaas-org/ipaas-backend/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// converts the model probe to a k8s probe, if the probe does not specify
+// a port the container port is used
+func convertModelProbeToK8sProbe(probe *model.Probe, containerPort int32) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+
+	port := intstr.FromInt32(containerPort)
+	if probe.Port != "" {
+		port = intstr.Parse(probe.Port)
+	}
+
+	k8sProbe := &corev1.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+
+	switch probe.Kind {
+	case model.ProbeKindHTTP:
+		path := probe.Path
+		if path == "" {
+			path = "/"
+		}
+		k8sProbe.ProbeHandler = corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: port,
+			},
+		}
+	case model.ProbeKindExec:
+		k8sProbe.ProbeHandler = corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: probe.Command,
+			},
+		}
+	default:
+		k8sProbe.ProbeHandler = corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: port,
+			},
+		}
+	}
+	return k8sProbe
+}
+
+func applyHealthChecksToContainer(container *corev1.Container, healthChecks *model.HealthChecks, containerPort int32) {
+	if healthChecks == nil {
+		return
+	}
+	container.LivenessProbe = convertModelProbeToK8sProbe(healthChecks.Liveness, containerPort)
+	container.ReadinessProbe = convertModelProbeToK8sProbe(healthChecks.Readiness, containerPort)
+	container.StartupProbe = convertModelProbeToK8sProbe(healthChecks.Startup, containerPort)
+}
@@ -0,0 +1,198 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const resourceDeploymentsCount corev1.ResourceName = "count/deployments.apps"
+
+func convertK8sResourceListToModelQuotaResources(list corev1.ResourceList) model.QuotaResources {
+	resources := model.QuotaResources{}
+	if q, ok := list[corev1.ResourceLimitsCPU]; ok {
+		resources.CPU = q.String()
+	}
+	if q, ok := list[corev1.ResourceLimitsMemory]; ok {
+		resources.Memory = q.String()
+	}
+	if q, ok := list[corev1.ResourceRequestsStorage]; ok {
+		resources.Storage = q.String()
+	}
+	if q, ok := list[corev1.ResourcePods]; ok {
+		resources.Pods = q.Value()
+	}
+	if q, ok := list[resourceDeploymentsCount]; ok {
+		resources.Applications = q.Value()
+	}
+	return resources
+}
+
+func convertModelQuotaResourcesToK8sResourceList(resources model.QuotaResources) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	quantities := map[corev1.ResourceName]string{
+		corev1.ResourceLimitsCPU:       resources.CPU,
+		corev1.ResourceLimitsMemory:    resources.Memory,
+		corev1.ResourceRequestsStorage: resources.Storage,
+	}
+	for name, value := range quantities {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s quantity: %v", name, err)
+		}
+		list[name] = q
+	}
+	if resources.Pods > 0 {
+		list[corev1.ResourcePods] = *resource.NewQuantity(resources.Pods, resource.DecimalSI)
+	}
+	if resources.Applications > 0 {
+		list[resourceDeploymentsCount] = *resource.NewQuantity(resources.Applications, resource.DecimalSI)
+	}
+	return list, nil
+}
+
+func convertK8sResourceQuotaToModelResourceQuota(quota *corev1.ResourceQuota) *model.ResourceQuota {
+	return &model.ResourceQuota{
+		BaseResource: model.BaseResource{
+			Name:      quota.Name,
+			Namespace: quota.Namespace,
+			Labels:    convertK8sDataToModelData(quota.Labels),
+		},
+		Hard: convertK8sResourceListToModelQuotaResources(quota.Spec.Hard),
+		Used: convertK8sResourceListToModelQuotaResources(quota.Status.Used),
+	}
+}
+
+func (k K8sOrchestratedServiceManager) GetResourceQuota(ctx context.Context, namespace, quotaName string) (*model.ResourceQuota, error) {
+	quota, err := k.clientset.CoreV1().ResourceQuotas(namespace).Get(ctx, quotaName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return nil, fmt.Errorf("error getting resource quota: %v", err)
+	}
+	return convertK8sResourceQuotaToModelResourceQuota(quota), nil
+}
+
+// if the quota already exists its limits and labels are updated, so that the
+// namespace setup can be run again safely
+func (k K8sOrchestratedServiceManager) CreateNewResourceQuota(ctx context.Context, namespace, quotaName string, hard model.QuotaResources, labels []model.KeyValue) (*model.ResourceQuota, error) {
+	hardList, err := convertModelQuotaResourcesToK8sResourceList(hard)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := k.clientset.CoreV1().ResourceQuotas(namespace).Create(ctx,
+		&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      quotaName,
+				Namespace: namespace,
+				Labels:    convertModelDataToK8sData(labels),
+			},
+			Spec: corev1.ResourceQuotaSpec{
+				Hard: hardList,
+			},
+		}, metav1.CreateOptions{})
+	if err == nil {
+		return convertK8sResourceQuotaToModelResourceQuota(quota), nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("error creating resource quota: %v", err)
+	}
+
+	quota, err = k.clientset.CoreV1().ResourceQuotas(namespace).Get(ctx, quotaName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting resource quota: %v", err)
+	}
+	quota.Labels = convertModelDataToK8sData(labels)
+	quota.Spec.Hard = hardList
+	quota, err = k.clientset.CoreV1().ResourceQuotas(namespace).Update(ctx, quota, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating resource quota: %v", err)
+	}
+	return convertK8sResourceQuotaToModelResourceQuota(quota), nil
+}
+
+// the default cpu and memory are applied to every container that does not specify
+// its own limits, the max values are the upper bound for a single container.
+// If the limit range already exists its limits and labels are updated
+func (k K8sOrchestratedServiceManager) CreateNewLimitRange(ctx context.Context, namespace, limitRangeName, defaultCPU, defaultMemory, maxCPU, maxMemory string, labels []model.KeyValue) (*model.LimitRange, error) {
+	defaults, err := convertModelQuotaResourcesToK8sResourceList(model.QuotaResources{CPU: defaultCPU, Memory: defaultMemory})
+	if err != nil {
+		return nil, err
+	}
+	maxs, err := convertModelQuotaResourcesToK8sResourceList(model.QuotaResources{CPU: maxCPU, Memory: maxMemory})
+	if err != nil {
+		return nil, err
+	}
+
+	item := corev1.LimitRangeItem{
+		Type:           corev1.LimitTypeContainer,
+		Default:        corev1.ResourceList{},
+		DefaultRequest: corev1.ResourceList{},
+		Max:            corev1.ResourceList{},
+	}
+	//limit ranges use the plain resource names, not the limits.* ones used by quotas
+	if q, ok := defaults[corev1.ResourceLimitsCPU]; ok {
+		item.Default[corev1.ResourceCPU] = q
+		item.DefaultRequest[corev1.ResourceCPU] = q
+	}
+	if q, ok := defaults[corev1.ResourceLimitsMemory]; ok {
+		item.Default[corev1.ResourceMemory] = q
+		item.DefaultRequest[corev1.ResourceMemory] = q
+	}
+	if q, ok := maxs[corev1.ResourceLimitsCPU]; ok {
+		item.Max[corev1.ResourceCPU] = q
+	}
+	if q, ok := maxs[corev1.ResourceLimitsMemory]; ok {
+		item.Max[corev1.ResourceMemory] = q
+	}
+
+	limitRange, err := k.clientset.CoreV1().LimitRanges(namespace).Create(ctx,
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      limitRangeName,
+				Namespace: namespace,
+				Labels:    convertModelDataToK8sData(labels),
+			},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{item},
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("error creating limit range: %v", err)
+		}
+		limitRange, err = k.clientset.CoreV1().LimitRanges(namespace).Get(ctx, limitRangeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting limit range: %v", err)
+		}
+		limitRange.Labels = convertModelDataToK8sData(labels)
+		limitRange.Spec.Limits = []corev1.LimitRangeItem{item}
+		limitRange, err = k.clientset.CoreV1().LimitRanges(namespace).Update(ctx, limitRange, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error updating limit range: %v", err)
+		}
+	}
+
+	return &model.LimitRange{
+		BaseResource: model.BaseResource{
+			Name:      limitRange.Name,
+			Namespace: limitRange.Namespace,
+			Labels:    convertK8sDataToModelData(limitRange.Labels),
+		},
+		DefaultCPU:    defaultCPU,
+		DefaultMemory: defaultMemory,
+		MaxCPU:        maxCPU,
+		MaxMemory:     maxMemory,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package k8smanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func convertSecretToModelSecret(secret *v1.Secret) *model.Secret {
+	keys := make([]string, 0, len(secret.Data)+len(secret.StringData))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	for key := range secret.StringData {
+		if _, ok := secret.Data[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return &model.Secret{
+		BaseResource: model.BaseResource{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+			Labels:    convertK8sDataToModelData(secret.Labels),
+		},
+		Keys: keys,
+	}
+}
+
+func (k K8sOrchestratedServiceManager) GetSecret(ctx context.Context, namespace, secretName string) (*model.Secret, error) {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrResourceNotFound, err)
+		}
+		return nil, fmt.Errorf("error getting secret: %v", err)
+	}
+
+	return convertSecretToModelSecret(secret), nil
+}
+
+func (k K8sOrchestratedServiceManager) CreateNewSecret(ctx context.Context, namespace, secretName string, data, labels []model.KeyValue) (*model.Secret, error) {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Create(ctx,
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Labels:    convertModelDataToK8sData(labels),
+				Namespace: namespace,
+			},
+			Type:       v1.SecretTypeOpaque,
+			StringData: convertModelDataToK8sData(data),
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating secret: %v", err)
+	}
+
+	return convertSecretToModelSecret(secret), nil
+}
+
+// replaces all the data of the secret with the new one
+func (k K8sOrchestratedServiceManager) UpdateSecret(ctx context.Context, namespace, secretName string, data []model.KeyValue) (*model.Secret, error) {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret: %v", err)
+	}
+
+	secret.Data = nil
+	secret.StringData = convertModelDataToK8sData(data)
+	updatedSecret, err := k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating secret: %v", err)
+	}
+
+	return convertSecretToModelSecret(updatedSecret), nil
+}
+
+func (k K8sOrchestratedServiceManager) DeleteSecret(ctx context.Context, namespace, secretName string, gracePeriod int64) error {
+	grace := &gracePeriod
+	if gracePeriod < 0 {
+		grace = nil
+	}
+	err := k.clientset.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{
+		GracePeriodSeconds: grace,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting secret: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	k8smanager "github.com/ipaas-org/ipaas-backend/services/serviceManager/k8s"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// the fake clientset keeps the objects in memory, the tests using it don't need a cluster
+func getFakeK8sManager(t *testing.T) (*k8smanager.K8sOrchestratedServiceManager, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset()
+	manager, err := k8smanager.NewK8sOrchestratedServiceManagerForClientset(clientset, "100m", "100Mi", "1", "0", 5*time.Minute)
+	if err != nil {
+		t.Fatalf("error creating fake k8s manager: %v", err)
+	}
+	return manager, clientset
+}
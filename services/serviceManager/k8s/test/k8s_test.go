@@ -26,7 +26,7 @@ func getTestK8sManager() *k8smanager.K8sOrchestratedServiceManager {
 	if err != nil {
 		panic(err)
 	}
-	manager, err := k8smanager.NewK8sOrchestratedServiceManager(home+"/.kube/config", "100m", "100Mi")
+	manager, err := k8smanager.NewK8sOrchestratedServiceManager(home+"/.kube/config", "100m", "100Mi", "1", "0", 5*time.Minute)
 	if err != nil {
 		panic(err)
 	}
@@ -125,7 +125,7 @@ which is on multiple lines`,
 		Key:   model.ResourceNameLabel,
 		Value: "test-deployment",
 	})
-	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, labels, configMap.Name, nil)
+	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, labels, []model.EnvSource{{Kind: model.EnvSourceKindConfigMap, Name: configMap.Name}}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("error creating deployment: %v\n", err)
 	}
@@ -177,7 +177,7 @@ func TestRestartDeployment(t *testing.T) {
 	}
 	t.Log("configmap created: ", configMap)
 
-	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, configMap.Name, nil)
+	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, []model.EnvSource{{Kind: model.EnvSourceKindConfigMap, Name: configMap.Name}}, nil, nil, nil)
 	if err != nil {
 		t.Errorf("error creating deployment: %v\n", err)
 	}
@@ -261,7 +261,7 @@ func TestFullApplicationStartup(t *testing.T) {
 	}
 	t.Log("configmap created: ", configMap)
 
-	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", image, 1, 8080, defaultLabels, configMap.Name, nil)
+	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", image, 1, 8080, defaultLabels, []model.EnvSource{{Kind: model.EnvSourceKindConfigMap, Name: configMap.Name}}, nil, nil, nil)
 	if err != nil {
 		t.Errorf("error creating deployment: %v\n", err)
 	}
@@ -319,7 +319,7 @@ which is on multiple lines`,
 		Key:   model.ResourceNameLabel,
 		Value: "test-service",
 	})
-	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, configMap.Name, nil)
+	dep, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, []model.EnvSource{{Kind: model.EnvSourceKindConfigMap, Name: configMap.Name}}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("error creating deployment: %v\n", err)
 	}
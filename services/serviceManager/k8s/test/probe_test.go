@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func getContainer(t *testing.T, clientset *fake.Clientset, namespace, deploymentName string) corev1.Container {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting deployment: %v", err)
+	}
+	return deployment.Spec.Template.Spec.Containers[0]
+}
+
+func TestDeploymentProbes(t *testing.T) {
+	manager, clientset := getFakeK8sManager(t)
+	ctx := context.Background()
+	namespace := "test-namespace"
+
+	healthChecks := &model.HealthChecks{
+		Liveness: &model.Probe{
+			Kind:             model.ProbeKindHTTP,
+			PeriodSeconds:    10,
+			FailureThreshold: 3,
+		},
+		Readiness: &model.Probe{
+			Kind: model.ProbeKindTCP,
+			Port: "8080",
+		},
+		Startup: &model.Probe{
+			Kind:    model.ProbeKindExec,
+			Command: []string{"cat", "/tmp/ready"},
+		},
+	}
+	if _, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, nil, nil, healthChecks, nil); err != nil {
+		t.Fatalf("error creating deployment: %v", err)
+	}
+	container := getContainer(t, clientset, namespace, "test-deployment")
+
+	t.Run("http probe defaults to the root path on the container port", func(t *testing.T) {
+		liveness := container.LivenessProbe
+		if liveness == nil || liveness.HTTPGet == nil {
+			t.Fatalf("liveness probe should be an http probe, got %+v", liveness)
+		}
+		if liveness.HTTPGet.Path != "/" || liveness.HTTPGet.Port.IntValue() != 80 {
+			t.Errorf("liveness probe should check / on port 80, got %s on %s", liveness.HTTPGet.Path, liveness.HTTPGet.Port.String())
+		}
+		if liveness.PeriodSeconds != 10 || liveness.FailureThreshold != 3 {
+			t.Errorf("liveness probe timings not applied: %+v", liveness)
+		}
+	})
+
+	t.Run("tcp probe uses its own port", func(t *testing.T) {
+		readiness := container.ReadinessProbe
+		if readiness == nil || readiness.TCPSocket == nil {
+			t.Fatalf("readiness probe should be a tcp probe, got %+v", readiness)
+		}
+		if readiness.TCPSocket.Port.IntValue() != 8080 {
+			t.Errorf("readiness probe should use port 8080, got %s", readiness.TCPSocket.Port.String())
+		}
+	})
+
+	t.Run("exec probe runs the command", func(t *testing.T) {
+		startup := container.StartupProbe
+		if startup == nil || startup.Exec == nil {
+			t.Fatalf("startup probe should be an exec probe, got %+v", startup)
+		}
+		if len(startup.Exec.Command) != 2 || startup.Exec.Command[1] != "/tmp/ready" {
+			t.Errorf("startup probe should run the command, got %v", startup.Exec.Command)
+		}
+	})
+
+	t.Run("empty health checks set no probes", func(t *testing.T) {
+		if _, err := manager.CreateNewDeployment(ctx, namespace, "test-worker", "worker-test", "ubuntu/nginx", 1, 0, defaultLabels, nil, nil, &model.HealthChecks{}, nil); err != nil {
+			t.Fatalf("error creating deployment: %v", err)
+		}
+		container := getContainer(t, clientset, namespace, "test-worker")
+		if container.LivenessProbe != nil || container.ReadinessProbe != nil || container.StartupProbe != nil {
+			t.Errorf("deployment without probes should have none, got %+v", container)
+		}
+	})
+}
+
+func TestUpdateDeploymentProbes(t *testing.T) {
+	manager, clientset := getFakeK8sManager(t)
+	ctx := context.Background()
+	namespace := "test-namespace"
+
+	healthChecks := &model.HealthChecks{
+		Liveness: &model.Probe{Kind: model.ProbeKindTCP},
+	}
+	if _, err := manager.CreateNewDeployment(ctx, namespace, "test-deployment", "nginx-test", "ubuntu/nginx", 1, 80, defaultLabels, nil, nil, healthChecks, nil); err != nil {
+		t.Fatalf("error creating deployment: %v", err)
+	}
+
+	t.Run("nil health checks keep the probes", func(t *testing.T) {
+		if _, err := manager.UpdateDeployment(ctx, namespace, "test-deployment", "ubuntu/nginx:latest", 1, 80, defaultLabels, nil, nil); err != nil {
+			t.Fatalf("error updating deployment: %v", err)
+		}
+		container := getContainer(t, clientset, namespace, "test-deployment")
+		if container.LivenessProbe == nil || container.LivenessProbe.TCPSocket == nil {
+			t.Errorf("liveness probe should be kept, got %+v", container.LivenessProbe)
+		}
+	})
+
+	t.Run("new health checks replace the probes", func(t *testing.T) {
+		healthChecks := &model.HealthChecks{
+			Readiness: &model.Probe{Kind: model.ProbeKindHTTP, Path: "/health"},
+		}
+		if _, err := manager.UpdateDeployment(ctx, namespace, "test-deployment", "ubuntu/nginx:latest", 1, 80, defaultLabels, nil, healthChecks); err != nil {
+			t.Fatalf("error updating deployment: %v", err)
+		}
+		container := getContainer(t, clientset, namespace, "test-deployment")
+		if container.LivenessProbe != nil {
+			t.Errorf("liveness probe should be removed, got %+v", container.LivenessProbe)
+		}
+		if container.ReadinessProbe == nil || container.ReadinessProbe.HTTPGet == nil || container.ReadinessProbe.HTTPGet.Path != "/health" {
+			t.Errorf("readiness probe should check /health, got %+v", container.ReadinessProbe)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipaas-org/ipaas-backend/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourceQuota(t *testing.T) {
+	manager, clientset := getFakeK8sManager(t)
+	ctx := context.Background()
+	namespace := "test-namespace"
+
+	hard := model.QuotaResources{
+		CPU:          "2",
+		Memory:       "4Gi",
+		Storage:      "10Gi",
+		Pods:         10,
+		Applications: 5,
+	}
+
+	t.Run("hard limits are converted to k8s resources", func(t *testing.T) {
+		quota, err := manager.CreateNewResourceQuota(ctx, namespace, "test-quota", hard, defaultLabels)
+		if err != nil {
+			t.Fatalf("error creating resource quota: %v", err)
+		}
+		if quota.Hard != hard {
+			t.Errorf("hard limits should be %+v, got %+v", hard, quota.Hard)
+		}
+
+		k8sQuota, err := clientset.CoreV1().ResourceQuotas(namespace).Get(ctx, "test-quota", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting resource quota: %v", err)
+		}
+		if cpu := k8sQuota.Spec.Hard[corev1.ResourceLimitsCPU]; cpu.String() != "2" {
+			t.Errorf("cpu limit should be 2, got %s", cpu.String())
+		}
+		if pods := k8sQuota.Spec.Hard[corev1.ResourcePods]; pods.Value() != 10 {
+			t.Errorf("pods limit should be 10, got %d", pods.Value())
+		}
+	})
+
+	t.Run("creating an existing quota updates it", func(t *testing.T) {
+		hard := hard
+		hard.CPU = "4"
+		hard.Applications = 0
+		quota, err := manager.CreateNewResourceQuota(ctx, namespace, "test-quota", hard, defaultLabels)
+		if err != nil {
+			t.Fatalf("error creating existing resource quota: %v", err)
+		}
+		if quota.Hard != hard {
+			t.Errorf("hard limits should be updated to %+v, got %+v", hard, quota.Hard)
+		}
+
+		quota, err = manager.GetResourceQuota(ctx, namespace, "test-quota")
+		if err != nil {
+			t.Fatalf("error getting resource quota: %v", err)
+		}
+		if quota.Hard != hard {
+			t.Errorf("stored hard limits should be %+v, got %+v", hard, quota.Hard)
+		}
+	})
+
+	t.Run("invalid quantities are rejected", func(t *testing.T) {
+		if _, err := manager.CreateNewResourceQuota(ctx, namespace, "test-quota", model.QuotaResources{CPU: "not-a-quantity"}, defaultLabels); err == nil {
+			t.Errorf("invalid quantities should be rejected")
+		}
+	})
+}
+
+func TestLimitRange(t *testing.T) {
+	manager, clientset := getFakeK8sManager(t)
+	ctx := context.Background()
+	namespace := "test-namespace"
+
+	if _, err := manager.CreateNewLimitRange(ctx, namespace, "test-limits", "100m", "128Mi", "1", "1Gi", defaultLabels); err != nil {
+		t.Fatalf("error creating limit range: %v", err)
+	}
+	if _, err := manager.CreateNewLimitRange(ctx, namespace, "test-limits", "200m", "256Mi", "2", "2Gi", defaultLabels); err != nil {
+		t.Fatalf("error creating existing limit range: %v", err)
+	}
+
+	limitRange, err := clientset.CoreV1().LimitRanges(namespace).Get(ctx, "test-limits", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting limit range: %v", err)
+	}
+	item := limitRange.Spec.Limits[0]
+	if cpu := item.Default[corev1.ResourceCPU]; cpu.String() != "200m" {
+		t.Errorf("default cpu should be updated to 200m, got %s", cpu.String())
+	}
+	if memory := item.Max[corev1.ResourceMemory]; memory.String() != "2Gi" {
+		t.Errorf("max memory should be updated to 2Gi, got %s", memory.String())
+	}
+}
@@ -2,6 +2,7 @@ package k8smanager
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
@@ -9,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/ipaas-org/ipaas-backend/model"
+	"github.com/ipaas-org/ipaas-backend/services/serviceManager"
 )
 
 // 1 * 1024 * 1024 * 1024 = 1Gi
@@ -37,8 +39,19 @@ func (k K8sOrchestratedServiceManager) CreateNewPersistentVolumeClaim(ctx contex
 			}, metav1.CreateOptions{})
 
 	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
 		return nil, err
 	}
+	return convertK8sPersistentVolumeClaimToModel(pvc), nil
+}
+
+func convertK8sPersistentVolumeClaimToModel(pvc *corev1.PersistentVolumeClaim) *model.PersistentVolumeClaim {
+	storageClassName := ""
+	if pvc.Spec.StorageClassName != nil {
+		storageClassName = *pvc.Spec.StorageClassName
+	}
 	return &model.PersistentVolumeClaim{
 		BaseResource: model.BaseResource{
 			Name:      pvc.Name,
@@ -48,7 +61,87 @@ func (k K8sOrchestratedServiceManager) CreateNewPersistentVolumeClaim(ctx contex
 		StorageClassName: storageClassName,
 		AccessModes:      string(corev1.ReadWriteOncePod),
 		StorageSize:      pvc.Spec.Resources.Limits.Storage().Value(),
-	}, nil
+	}
+}
+
+// the size can only be increased and the storage class must allow volume expansion,
+// the file system is resized by the storage provider (some require a pod restart)
+func (k K8sOrchestratedServiceManager) ResizePersistentVolumeClaim(ctx context.Context, namespace, pvcName string, storageSize int64) (*model.PersistentVolumeClaim, error) {
+	pvc, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting PVC: %v", err)
+	}
+
+	storageQuantity := resource.NewQuantity(storageSize, resource.BinarySI)
+	pvc.Spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: *storageQuantity,
+	}
+	pvc.Spec.Resources.Limits = corev1.ResourceList{
+		corev1.ResourceStorage: *storageQuantity,
+	}
+
+	updatedPVC, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+	if err != nil {
+		if isQuotaExceededError(err) {
+			return nil, fmt.Errorf("%w: %v", serviceManager.ErrQuotaExceeded, err)
+		}
+		return nil, fmt.Errorf("error resizing PVC: %v", err)
+	}
+	return convertK8sPersistentVolumeClaimToModel(updatedPVC), nil
+}
+
+// subset of the kubelet stats summary, only the volumes of the pods are read
+type kubeletStatsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			UsedBytes *int64 `json:"usedBytes"`
+			PVCRef    *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// the usage is read from the stats summary of the kubelets running the pods of the
+// namespace, pvcs not mounted by a running pod are not in the returned map
+func (k K8sOrchestratedServiceManager) GetPersistentVolumeClaimsUsage(ctx context.Context, namespace string) (map[string]int64, error) {
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+	nodes := make(map[string]bool)
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" && pod.Status.Phase == corev1.PodRunning {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	usage := make(map[string]int64)
+	for node := range nodes {
+		raw, err := k.clientset.CoreV1().RESTClient().Get().
+			Resource("nodes").
+			Name(node).
+			SubResource("proxy").
+			Suffix("stats/summary").
+			DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting stats summary of node %s: %v", node, err)
+		}
+		var summary kubeletStatsSummary
+		if err := json.Unmarshal(raw, &summary); err != nil {
+			return nil, fmt.Errorf("error decoding stats summary of node %s: %v", node, err)
+		}
+		for _, pod := range summary.Pods {
+			for _, volume := range pod.Volumes {
+				if volume.PVCRef == nil || volume.PVCRef.Namespace != namespace || volume.UsedBytes == nil {
+					continue
+				}
+				usage[volume.PVCRef.Name] = *volume.UsedBytes
+			}
+		}
+	}
+	return usage, nil
 }
 
 func (k *K8sOrchestratedServiceManager) DeletePersistantVolumeClmain(ctx context.Context, namespace, pvcName string, gracePeriod int64) error {